@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/agent"
+	"github.com/JustSkiv/goschedviz/internal/collector/remote"
+)
+
+// defaultAgentAddr is the address agents listen on and viewers connect to by default.
+const defaultAgentAddr = "localhost:7070"
+
+// runAgent monitors the target without UI and serves snapshots to remote viewers.
+func runAgent(args []string) error {
+	fs := flag.NewFlagSet("agent", flag.ExitOnError)
+	var opts monitorOptions
+	opts.register(fs)
+	listen := fs.String("listen", defaultAgentAddr, "TCP address to serve snapshots on, e.g. ':7070' for all interfaces")
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goschedviz agent [-listen <addr>] -target=<program> [flags] [-- <target args>]\n\n")
+		fmt.Fprintf(fs.Output(), "Any source of the default command can be served: -target, '-', -follow or -url.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+
+	collector, err := opts.newCollector(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	a := agent.New(collector, agent.Hello{
+		Started:  time.Now(),
+		Target:   opts.describe(fs.Args()),
+		Hostname: hostname,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Serving %s on %s, press Ctrl+C to stop", opts.describe(fs.Args()), ln.Addr())
+	return a.Serve(ctx, ln)
+}
+
+// runView shows snapshots served by one or more agents, one tab each.
+func runView(args []string) error {
+	fs := flag.NewFlagSet("view", flag.ExitOnError)
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goschedviz view <host:port> [<host:port>...]\n\n")
+		fmt.Fprintf(fs.Output(), "Connects to agents started with 'goschedviz agent' (default %s).\n", defaultAgentAddr)
+		fmt.Fprintf(fs.Output(), "With several agents press Tab or 1-9 to switch between them.\n")
+	}
+
+	fs.Parse(args)
+
+	addrs := fs.Args()
+	if len(addrs) == 0 {
+		addrs = []string{defaultAgentAddr}
+	}
+
+	sources := make([]source, len(addrs))
+	for i, addr := range addrs {
+		sources[i] = source{name: addr, c: remote.New(addr)}
+	}
+
+	return runSources(sources, nil)
+}
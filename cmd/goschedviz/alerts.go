@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/alert"
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// attachAlerts loads alert rules and gives every source its own engine.
+// An empty path leaves sources without alerts.
+func attachAlerts(path string, sources []source) error {
+	if path == "" {
+		return nil
+	}
+	rules, err := alert.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to load alert rules: %w", err)
+	}
+	for i := range sources {
+		sources[i].alerts = alert.NewEngine(rules, alert.WithSource(sources[i].name))
+	}
+	return nil
+}
+
+// convertAlerts converts firing rules to UI format. Durations are measured up to now,
+// the receive time of the latest snapshot.
+func convertAlerts(firing []alert.Firing, now time.Time) []ui.AlertValues {
+	alerts := make([]ui.AlertValues, len(firing))
+	for i, f := range firing {
+		alerts[i] = ui.AlertValues{
+			Name:      f.Rule.Name,
+			Condition: f.Rule.Condition,
+			Duration:  max(now.Sub(f.Since), 0),
+			Widgets:   f.Rule.Highlights(),
+		}
+	}
+	return alerts
+}
+
+// alertsHint describes firing rules and a failed action for the status line.
+func alertsHint(alerts []ui.AlertValues, err error) string {
+	var parts []string
+	if len(alerts) > 0 {
+		names := make([]string, len(alerts))
+		for i, a := range alerts {
+			names[i] = fmt.Sprintf("%s (%s)", a.Name, a.Duration.Round(time.Second))
+		}
+		parts = append(parts, "ALERT: "+strings.Join(names, ", "))
+	}
+	if err != nil {
+		parts = append(parts, "Alert action failed: "+err.Error())
+	}
+	return joinStatus(parts...)
+}
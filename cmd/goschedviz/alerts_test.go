@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/alert"
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+func TestAttachAlerts(t *testing.T) {
+	sources := []source{{name: "./a"}, {name: "./b"}}
+	require.NoError(t, attachAlerts("", sources))
+	assert.Nil(t, sources[0].alerts, "No rules file, no alerts")
+
+	path := filepath.Join(t.TempDir(), "alerts.rules")
+	require.NoError(t, os.WriteFile(path, []byte("busy: grq > 100\n"), 0o644))
+	require.NoError(t, attachAlerts(path, sources))
+	assert.NotNil(t, sources[0].alerts)
+	assert.NotSame(t, sources[0].alerts, sources[1].alerts, "Every source has its own engine")
+
+	require.NoError(t, os.WriteFile(path, []byte("busy: grq >\n"), 0o644))
+	assert.ErrorContains(t, attachAlerts(path, sources), "failed to load alert rules")
+}
+
+func TestConvertAlerts(t *testing.T) {
+	rule, err := alert.ParseRule("busy: grq > 100 for 5s => highlight grq lrq, bell")
+	require.NoError(t, err)
+
+	now := time.Unix(100, 0)
+	alerts := convertAlerts([]alert.Firing{{Rule: rule, Since: now.Add(-12 * time.Second)}}, now)
+	assert.Equal(t, []ui.AlertValues{{
+		Name:      "busy",
+		Condition: "grq > 100",
+		Duration:  12 * time.Second,
+		Widgets:   []string{"grq", "lrq"},
+	}}, alerts)
+}
+
+func TestAlertsHint(t *testing.T) {
+	assert.Empty(t, alertsHint(nil, nil))
+
+	alerts := []ui.AlertValues{
+		{Name: "busy", Duration: 12300 * time.Millisecond},
+		{Name: "stalled", Duration: 3 * time.Second},
+	}
+	assert.Equal(t, "ALERT: busy (12s), stalled (3s)", alertsHint(alerts, nil))
+	assert.Equal(t, "ALERT: busy (12s), stalled (3s) | Alert action failed: timeout",
+		alertsHint(alerts, errors.New("timeout")))
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/collector/godebug"
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// checkThresholds holds limits the scheduler has to stay within, negative limits are disabled.
+type checkThresholds struct {
+	maxGRQ        int
+	maxThreads    int
+	maxGoroutines int
+	maxIdle       float64 // Largest share of time with idle Ps, from 0 to 1
+}
+
+// register defines threshold flags in the flag set.
+func (t *checkThresholds) register(fs *flag.FlagSet) {
+	fs.IntVar(&t.maxGRQ, "max-grq", -1, "Fail if the global run queue gets longer (-1 to disable)")
+	fs.IntVar(&t.maxThreads, "max-threads", -1, "Fail if the program creates more threads (-1 to disable)")
+	fs.IntVar(&t.maxGoroutines, "max-goroutines", -1, "Fail if the goroutines count peaks higher, requires metrics.Reporter (-1 to disable)")
+	fs.Float64Var(&t.maxIdle, "max-idle", -1, "Fail if Ps are idle for a larger fraction of time, from 0 to 1 (-1 to disable)")
+}
+
+// checkStats aggregates snapshots thresholds are checked against.
+type checkStats struct {
+	snapshots      int
+	maxGRQ         int
+	maxThreads     int
+	peakGoroutines int // Zero if the program doesn't report goroutines count
+
+	last     domain.SchedulerSnapshot // Previous snapshot, stands for the time until the next one
+	lastAt   time.Time
+	duration time.Duration
+	idle     time.Duration // Time with at least one idle P
+}
+
+// add accounts for the snapshot.
+func (s *checkStats) add(snapshot domain.SchedulerSnapshot) {
+	at := domain.SnapshotTime(snapshot)
+	if s.snapshots > 0 {
+		if dt := at.Sub(s.lastAt); dt > 0 {
+			s.duration += dt
+			if s.last.IdleProcs > 0 {
+				s.idle += dt
+			}
+		}
+	}
+	s.last, s.lastAt = snapshot, at
+
+	s.snapshots++
+	s.maxGRQ = max(s.maxGRQ, snapshot.RunQueue)
+	s.maxThreads = max(s.maxThreads, snapshot.Threads)
+	s.peakGoroutines = max(s.peakGoroutines, snapshot.Goroutines)
+}
+
+// idleFraction returns the share of time with idle Ps.
+func (s *checkStats) idleFraction() float64 {
+	if s.duration == 0 {
+		return 0
+	}
+	return float64(s.idle) / float64(s.duration)
+}
+
+// checkAssertion is the outcome of a single check.
+type checkAssertion struct {
+	Name    string   `json:"name"`
+	Passed  bool     `json:"passed"`
+	Value   *float64 `json:"value,omitempty"`
+	Limit   *float64 `json:"limit,omitempty"`
+	Message string   `json:"message"`
+}
+
+// checkResult is the outcome of a check run.
+type checkResult struct {
+	Target     string           `json:"target"`
+	Passed     bool             `json:"passed"`
+	Duration   float64          `json:"duration"` // Seconds
+	Snapshots  int              `json:"snapshots"`
+	Exit       string           `json:"exit,omitempty"`
+	Assertions []checkAssertion `json:"assertions"`
+	Output     []string         `json:"output,omitempty"` // Output of the program, without trace lines
+}
+
+// failures returns the number of failed assertions.
+func (r checkResult) failures() int {
+	n := 0
+	for _, a := range r.Assertions {
+		if !a.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+// runCheck runs the target without UI for a fixed time or until it exits
+// and fails if the scheduler exceeds any of the thresholds.
+func runCheck(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	var opts monitorOptions
+	opts.register(fs)
+	var limits checkThresholds
+	limits.register(fs)
+	test := fs.String("test", "", "Package whose tests are run instead of -target, built with 'go test -c'")
+	duration := fs.Duration("duration", 0, "How long to run the target, 0 to wait until it exits")
+	format := fs.String("format", "text", "Result format: text, json or junit")
+	output := fs.String("o", "", "File to write the result to (default stdout)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n")
+		fmt.Fprintf(fs.Output(), "  goschedviz check [-duration <d>] [thresholds] -target=<program> [flags] [-- <target args>]\n")
+		fmt.Fprintf(fs.Output(), "  goschedviz check [-duration <d>] [thresholds] -test=<package> [flags] [-- <test flags>]\n\n")
+		fmt.Fprintf(fs.Output(), "Exits with status 1 if any threshold is exceeded.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+
+	var extra []godebug.Option
+	if *test != "" {
+		if len(opts.targets) > 0 {
+			return errors.New("-test and -target can't be used together")
+		}
+		opts.targets = targetFlags{*test}
+		extra = append(extra, godebug.WithTest())
+	}
+	if !opts.launchesTarget(fs.Args()) || len(opts.targets) != 1 {
+		return errors.New("check requires a single target program, use -target or -test")
+	}
+	if *format != "text" && *format != "json" && *format != "junit" {
+		return fmt.Errorf("unknown format %q, use text, json or junit", *format)
+	}
+	// A restarted or rebuilt target never finishes, so the run needs a deadline
+	restart, err := godebug.ParseRestartPolicy(opts.restart)
+	if err != nil {
+		return err
+	}
+	if *duration <= 0 && (opts.watch || restart != godebug.RestartNever) {
+		return errors.New("-restart and -watch keep the target running, set -duration")
+	}
+	if limits.maxIdle > 1 {
+		return fmt.Errorf("-max-idle is a fraction from 0 to 1, got %g", limits.maxIdle)
+	}
+
+	collector, err := opts.newCollector(fs.Args(), extra...)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	result, err := check(ctx, collector, limits)
+	if err != nil {
+		return err
+	}
+	result.Target = opts.targets[0]
+
+	if err := writeCheckOutput(*output, result, *format); err != nil {
+		return err
+	}
+
+	if !result.Passed {
+		return fmt.Errorf("scheduler check failed: %d of %d assertions", result.failures(), len(result.Assertions))
+	}
+	return nil
+}
+
+// writeCheckOutput writes the result to the file, or to stdout if path is empty.
+func writeCheckOutput(path string, result checkResult, format string) error {
+	if path == "" {
+		if err := writeCheckResult(os.Stdout, result, format); err != nil {
+			return fmt.Errorf("failed to write result: %w", err)
+		}
+		return nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create result file: %w", err)
+	}
+	err = writeCheckResult(f, result, format)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	return nil
+}
+
+// check collects snapshots until the context is done or the target exits
+// and evaluates thresholds against them.
+func check(ctx context.Context, c collector, limits checkThresholds) (checkResult, error) {
+	started := time.Now()
+	snapshots, err := c.Start(ctx)
+	if err != nil {
+		return checkResult{}, fmt.Errorf("failed to start collector: %w", err)
+	}
+
+	var stats checkStats
+	exited := false
+collect:
+	for {
+		select {
+		case <-ctx.Done():
+			break collect
+		case snapshot, ok := <-snapshots:
+			if !ok {
+				// The target finished on its own unless it was stopped by the deadline
+				exited = ctx.Err() == nil
+				break collect
+			}
+			stats.add(snapshot)
+		}
+	}
+	if err := c.Stop(); err != nil {
+		return checkResult{}, fmt.Errorf("failed to stop collector: %w", err)
+	}
+
+	result := checkResult{
+		Duration:   time.Since(started).Seconds(),
+		Snapshots:  stats.snapshots,
+		Assertions: evaluateCheck(stats, limits),
+	}
+	if r, ok := c.(exitReporter); ok && exited {
+		if status, ok := r.Exit(); ok {
+			result.Exit = status.String()
+			a := checkAssertion{Name: "exit", Passed: !status.Failed(), Message: "target " + status.String()}
+			result.Assertions = append(result.Assertions, a)
+		}
+	}
+	if l, ok := c.(logSource); ok {
+		for _, entry := range l.Logs() {
+			result.Output = append(result.Output, entry.Text)
+		}
+	}
+
+	result.Passed = result.failures() == 0
+	return result, nil
+}
+
+// evaluateCheck checks the stats against enabled thresholds. Without snapshots
+// nothing can be checked, so a missing trace fails the run.
+func evaluateCheck(stats checkStats, limits checkThresholds) []checkAssertion {
+	assertions := []checkAssertion{{
+		Name:    "snapshots",
+		Passed:  stats.snapshots > 0,
+		Value:   ptr(float64(stats.snapshots)),
+		Message: fmt.Sprintf("%d snapshots received", stats.snapshots),
+	}}
+	if stats.snapshots == 0 {
+		assertions[0].Message = "no scheduler trace received"
+	}
+
+	limit := func(name string, value, max float64, format string) {
+		a := checkAssertion{
+			Name:    name,
+			Passed:  value <= max,
+			Value:   ptr(value),
+			Limit:   ptr(max),
+			Message: fmt.Sprintf(format, value, max),
+		}
+		assertions = append(assertions, a)
+	}
+	if limits.maxGRQ >= 0 {
+		limit("max-grq", float64(stats.maxGRQ), float64(limits.maxGRQ), "global run queue peaked at %.0f, limit %.0f")
+	}
+	if limits.maxThreads >= 0 {
+		limit("max-threads", float64(stats.maxThreads), float64(limits.maxThreads), "threads peaked at %.0f, limit %.0f")
+	}
+	if limits.maxGoroutines >= 0 {
+		if stats.peakGoroutines == 0 && stats.snapshots > 0 {
+			assertions = append(assertions, checkAssertion{
+				Name:    "max-goroutines",
+				Limit:   ptr(float64(limits.maxGoroutines)),
+				Message: "goroutines count not reported, use metrics.Reporter in the program",
+			})
+		} else {
+			limit("max-goroutines", float64(stats.peakGoroutines), float64(limits.maxGoroutines), "goroutines peaked at %.0f, limit %.0f")
+		}
+	}
+	if limits.maxIdle >= 0 {
+		limit("max-idle", stats.idleFraction(), limits.maxIdle, "Ps idle for %.2f of the time, limit %.2f")
+	}
+	return assertions
+}
+
+// ptr returns a pointer to the value.
+func ptr(v float64) *float64 {
+	return &v
+}
+
+// writeCheckResult writes the result in the format: text, json or junit.
+func writeCheckResult(w io.Writer, result checkResult, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	case "junit":
+		return writeJUnit(w, result)
+	}
+
+	verdict := "PASS"
+	if !result.Passed {
+		verdict = "FAIL"
+	}
+	fmt.Fprintf(w, "%s %s (%.1fs, %d snapshots)\n", verdict, result.Target, result.Duration, result.Snapshots)
+	for _, a := range result.Assertions {
+		status := "ok  "
+		if !a.Passed {
+			status = "FAIL"
+		}
+		if _, err := fmt.Fprintf(w, "  %s %-15s %s\n", status, a.Name, a.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JUnit XML report, a test case per assertion.
+type (
+	junitSuites struct {
+		XMLName xml.Name     `xml:"testsuites"`
+		Suites  []junitSuite `xml:"testsuite"`
+	}
+	junitSuite struct {
+		Name     string      `xml:"name,attr"`
+		Tests    int         `xml:"tests,attr"`
+		Failures int         `xml:"failures,attr"`
+		Time     string      `xml:"time,attr"`
+		Cases    []junitCase `xml:"testcase"`
+		Output   string      `xml:"system-out,omitempty"`
+	}
+	junitCase struct {
+		Name      string        `xml:"name,attr"`
+		ClassName string        `xml:"classname,attr"`
+		Failure   *junitFailure `xml:"failure"`
+	}
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// writeJUnit writes the result as a JUnit XML report CI systems can show as test results.
+func writeJUnit(w io.Writer, result checkResult) error {
+	suite := junitSuite{
+		Name:     "goschedviz check " + result.Target,
+		Tests:    len(result.Assertions),
+		Failures: result.failures(),
+		Time:     fmt.Sprintf("%.3f", result.Duration),
+		Output:   strings.Join(result.Output, "\n"),
+	}
+	for _, a := range result.Assertions {
+		tc := junitCase{Name: a.Name, ClassName: "goschedviz"}
+		if !a.Passed {
+			tc.Failure = &junitFailure{Message: a.Message, Text: a.Message}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// disabled has every threshold turned off.
+var disabled = checkThresholds{maxGRQ: -1, maxThreads: -1, maxGoroutines: -1, maxIdle: -1}
+
+func TestCheck(t *testing.T) {
+	snapshots := make(chan domain.SchedulerSnapshot, 4)
+	snapshots <- domain.SchedulerSnapshot{TimeMs: 1000, GoMaxProcs: 4, IdleProcs: 2, Threads: 6, RunQueue: 0}
+	snapshots <- domain.SchedulerSnapshot{TimeMs: 2000, GoMaxProcs: 4, IdleProcs: 0, Threads: 9, RunQueue: 12}
+	snapshots <- domain.SchedulerSnapshot{TimeMs: 4000, GoMaxProcs: 4, IdleProcs: 0, Threads: 8, RunQueue: 3}
+	snapshots <- domain.SchedulerSnapshot{TimeMs: 5000, GoMaxProcs: 4, IdleProcs: 1, Threads: 8, RunQueue: 0}
+	close(snapshots)
+	collector := &MockCollector{snapshots: snapshots}
+
+	limits := checkThresholds{maxGRQ: 10, maxThreads: 9, maxGoroutines: -1, maxIdle: 0.25}
+	result, err := check(context.Background(), collector, limits)
+	require.NoError(t, err)
+	assert.True(t, collector.stopCalled)
+
+	assert.Equal(t, 4, result.Snapshots)
+	assert.False(t, result.Passed)
+	assert.Equal(t, 1, result.failures())
+	require.Len(t, result.Assertions, 4)
+	assert.Equal(t, "snapshots", result.Assertions[0].Name)
+	assert.True(t, result.Assertions[0].Passed)
+
+	grq := result.Assertions[1]
+	assert.Equal(t, "max-grq", grq.Name)
+	assert.False(t, grq.Passed)
+	assert.Equal(t, "global run queue peaked at 12, limit 10", grq.Message)
+	assert.True(t, result.Assertions[2].Passed, "Threads equal to the limit pass")
+	// Half of the snapshots have idle Ps, but they stand for a quarter of the time
+	assert.True(t, result.Assertions[3].Passed)
+	assert.Equal(t, "Ps idle for 0.25 of the time, limit 0.25", result.Assertions[3].Message)
+
+	_, err = check(context.Background(), &MockCollector{startError: assert.AnError}, limits)
+	assert.Error(t, err)
+
+	stopped := make(chan domain.SchedulerSnapshot)
+	close(stopped)
+	_, err = check(context.Background(), &MockCollector{snapshots: stopped, stopError: assert.AnError}, limits)
+	assert.ErrorIs(t, err, assert.AnError, "Failure to stop the target is reported")
+}
+
+func TestCheck_Duration(t *testing.T) {
+	collector := &MockCollector{snapshots: make(chan domain.SchedulerSnapshot)}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	result, err := check(ctx, collector, disabled)
+	require.NoError(t, err)
+	assert.True(t, collector.stopCalled)
+	assert.False(t, result.Passed, "A run without trace fails")
+	assert.Equal(t, "no scheduler trace received", result.Assertions[0].Message)
+}
+
+func TestEvaluateCheck_Goroutines(t *testing.T) {
+	limits := disabled
+	limits.maxGoroutines = 100
+
+	assertions := evaluateCheck(checkStats{snapshots: 3}, limits)
+	require.Len(t, assertions, 2)
+	assert.False(t, assertions[1].Passed)
+	assert.Contains(t, assertions[1].Message, "not reported")
+
+	assertions = evaluateCheck(checkStats{snapshots: 3, peakGoroutines: 80}, limits)
+	assert.True(t, assertions[1].Passed)
+	assert.Equal(t, 80.0, *assertions[1].Value)
+}
+
+func TestWriteCheckResult(t *testing.T) {
+	result := checkResult{
+		Target:    "./cmd/server",
+		Duration:  2.5,
+		Snapshots: 3,
+		Assertions: []checkAssertion{
+			{Name: "snapshots", Passed: true, Message: "3 snapshots received"},
+			{Name: "max-grq", Value: ptr(12), Limit: ptr(10), Message: "global run queue peaked at 12, limit 10"},
+		},
+		Output: []string{"listening on :8080"},
+	}
+
+	var text bytes.Buffer
+	require.NoError(t, writeCheckResult(&text, result, "text"))
+	assert.Equal(t, "FAIL ./cmd/server (2.5s, 3 snapshots)\n"+
+		"  ok   snapshots       3 snapshots received\n"+
+		"  FAIL max-grq         global run queue peaked at 12, limit 10\n", text.String())
+
+	var js bytes.Buffer
+	require.NoError(t, writeCheckResult(&js, result, "json"))
+	var decoded checkResult
+	require.NoError(t, json.Unmarshal(js.Bytes(), &decoded))
+	assert.Equal(t, result, decoded)
+
+	var junit bytes.Buffer
+	require.NoError(t, writeCheckResult(&junit, result, "junit"))
+	var suites junitSuites
+	require.NoError(t, xml.Unmarshal(junit.Bytes(), &suites))
+	require.Len(t, suites.Suites, 1)
+	suite := suites.Suites[0]
+	assert.Equal(t, 2, suite.Tests)
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, "listening on :8080", suite.Output)
+	require.Len(t, suite.Cases, 2)
+	assert.Nil(t, suite.Cases[0].Failure)
+	require.NotNil(t, suite.Cases[1].Failure)
+	assert.Equal(t, "global run queue peaked at 12, limit 10", suite.Cases[1].Failure.Message)
+}
+
+func TestWriteCheckOutput(t *testing.T) {
+	result := checkResult{Target: "main.go", Passed: true, Snapshots: 1}
+	path := filepath.Join(t.TempDir(), "result.json")
+	require.NoError(t, writeCheckOutput(path, result, "json"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"target": "main.go"`)
+
+	err = writeCheckOutput(filepath.Join(t.TempDir(), "missing", "result.json"), result, "json")
+	assert.ErrorContains(t, err, "failed to create result file")
+}
+
+func TestRunCheck_Errors(t *testing.T) {
+	assert.ErrorContains(t, runCheck(nil), "single target")
+	assert.ErrorContains(t, runCheck([]string{"-follow", "app.log"}), "single target")
+	assert.ErrorContains(t, runCheck([]string{"-target", "main.go", "-test", "./pkg"}), "can't be used together")
+	assert.ErrorContains(t, runCheck([]string{"-target", "main.go", "-format", "xml"}), "unknown format")
+	assert.ErrorContains(t, runCheck([]string{"-target", "main.go", "-max-idle", "50"}), "fraction")
+	assert.ErrorContains(t, runCheck([]string{"-target", "main.go", "-watch"}), "set -duration")
+	assert.ErrorContains(t, runCheck([]string{"-target", "main.go", "-restart", "on-failure"}), "set -duration")
+}
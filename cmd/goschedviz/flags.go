@@ -0,0 +1,231 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/collector/godebug"
+	"github.com/JustSkiv/goschedviz/internal/collector/poll"
+	"github.com/JustSkiv/goschedviz/internal/collector/stream"
+)
+
+// envFlags collects repeatable -env KEY=VALUE flags.
+type envFlags []string
+
+func (e *envFlags) String() string {
+	return strings.Join(*e, ",")
+}
+
+func (e *envFlags) Set(value string) error {
+	key, _, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected KEY=VALUE, got %q", value)
+	}
+	*e = append(*e, value)
+	return nil
+}
+
+// targetFlags collects repeatable -target flags.
+type targetFlags []string
+
+func (t *targetFlags) String() string {
+	return strings.Join(*t, ",")
+}
+
+func (t *targetFlags) Set(value string) error {
+	if value == "" {
+		return errors.New("empty target")
+	}
+	*t = append(*t, value)
+	return nil
+}
+
+// validationFlags selects how strictly parsed snapshots are checked.
+type validationFlags struct {
+	strict  bool
+	lenient bool
+}
+
+// register defines validation flags in the flag set.
+func (v *validationFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&v.strict, "strict", false, "Also drop snapshots where every queue and counter is zero")
+	fs.BoolVar(&v.lenient, "lenient", false, "Keep snapshots that fail validation and report them as suspicious")
+}
+
+// validation returns the selected validation mode.
+func (v validationFlags) validation() (godebug.Validation, error) {
+	switch {
+	case v.strict && v.lenient:
+		return 0, errors.New("-strict and -lenient can't be used together")
+	case v.strict:
+		return godebug.ValidationStrict, nil
+	case v.lenient:
+		return godebug.ValidationLenient, nil
+	}
+	return godebug.ValidationDefault, nil
+}
+
+// errNoSource is returned when none of the supported metric sources is specified.
+var errNoSource = errors.New("please specify target program path with -target flag, '-' to read stdin, -follow with a log file or -url with a metrics endpoint")
+
+// monitorOptions holds flags shared by commands that monitor a live program.
+type monitorOptions struct {
+	targets targetFlags
+	period  int
+	follow  string
+	url     string
+	socket  string
+	env     envFlags
+	detail  bool
+	gc      bool
+	restart string
+	watch   bool
+	build   godebug.BuildOptions
+	check   validationFlags
+}
+
+// register defines monitoring flags in the flag set.
+func (o *monitorOptions) register(fs *flag.FlagSet) {
+	fs.Var(&o.targets, "target", "Program to monitor: .go file, package path, module directory or prebuilt executable (repeatable)")
+	fs.IntVar(&o.period, "period", 1000, "GODEBUG schedtrace period in milliseconds")
+	fs.StringVar(&o.follow, "follow", "", "Log file with schedtrace output to follow like 'tail -F'")
+	fs.StringVar(&o.url, "url", "", "URL of a metrics.Handler endpoint to poll every -period milliseconds")
+	fs.StringVar(&o.socket, "metrics-socket", "", "Unix socket to receive metrics.Reporter output when reading stdin or -follow")
+	fs.Var(&o.env, "env", "Environment variable KEY=VALUE for the target program (repeatable)")
+	fs.BoolVar(&o.detail, "detail", false, "Enable GODEBUG scheddetail=1 to show per-P, per-M and per-G state")
+	fs.BoolVar(&o.gc, "gc", false, "Enable GODEBUG gctrace=1 to show garbage collection cycles")
+	fs.StringVar(&o.restart, "restart", "never", "Restart the target when it exits: never, on-failure or always")
+	fs.BoolVar(&o.watch, "watch", false, "Rebuild and restart the target when its sources change")
+	fs.BoolVar(&o.build.Race, "race", false, "Build the target with the race detector")
+	fs.StringVar(&o.build.Tags, "tags", "", "Comma-separated build tags for the target")
+	fs.StringVar(&o.build.GCFlags, "gcflags", "", "Arguments passed to the compiler when building the target")
+	fs.StringVar(&o.build.LDFlags, "ldflags", "", "Arguments passed to the linker when building the target")
+	fs.BoolVar(&o.build.TrimPath, "trimpath", false, "Remove file system paths from the target binary")
+	o.check.register(fs)
+}
+
+// readsStdin reports whether schedtrace output should be read from stdin.
+// args are positional arguments left after flag parsing.
+func (o *monitorOptions) readsStdin(args []string) bool {
+	if len(o.targets) == 0 {
+		return len(args) > 0 && args[0] == "-"
+	}
+	return len(o.targets) == 1 && o.targets[0] == "-"
+}
+
+// describe returns a short description of the monitored source.
+func (o *monitorOptions) describe(args []string) string {
+	switch {
+	case o.url != "":
+		return o.url
+	case o.follow != "":
+		return o.follow
+	case o.readsStdin(args):
+		return "stdin"
+	}
+	return o.targets.String()
+}
+
+// launchesTarget reports whether the target program is started by goschedviz
+// rather than read from another source.
+func (o *monitorOptions) launchesTarget(args []string) bool {
+	return len(o.targets) > 0 && o.url == "" && o.follow == "" && !o.readsStdin(args)
+}
+
+// newSources creates a source for every configured target, or a single source
+// for other kinds of input. Target programs are named after their -target value.
+func (o *monitorOptions) newSources(args []string) ([]source, error) {
+	if len(o.targets) < 2 {
+		c, err := o.newCollector(args)
+		if err != nil {
+			return nil, err
+		}
+		return []source{{c: c}}, nil
+	}
+
+	if !o.launchesTarget(args) || slices.Contains(o.targets, "-") {
+		return nil, errors.New("several -target values can only be used with target programs")
+	}
+	if len(args) > 0 {
+		return nil, errors.New("target arguments can't be used with several -target values")
+	}
+
+	sources := make([]source, len(o.targets))
+	for i, target := range o.targets {
+		single := *o
+		single.targets = targetFlags{target}
+		c, err := single.newCollector(nil)
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = source{name: target, c: c}
+	}
+	return sources, nil
+}
+
+// newCollector creates a collector for the configured source.
+// For a target program args are passed as its command-line arguments.
+func (o *monitorOptions) newCollector(args []string, opts ...godebug.Option) (collector, error) {
+	if len(o.targets) > 1 {
+		return nil, errors.New("only one -target can be used with this command")
+	}
+
+	validation, err := o.check.validation()
+	if err != nil {
+		return nil, err
+	}
+
+	streamOpts := []stream.Option{stream.WithValidation(validation)}
+	if o.socket != "" {
+		if o.follow == "" && !o.readsStdin(args) {
+			return nil, errors.New("-metrics-socket can only be used when reading stdin or with -follow")
+		}
+		streamOpts = append(streamOpts, stream.WithMetricsSocket(o.socket))
+	}
+
+	restart, err := godebug.ParseRestartPolicy(o.restart)
+	if err != nil {
+		return nil, err
+	}
+	if restart != godebug.RestartNever && !o.launchesTarget(args) {
+		return nil, errors.New("-restart can only be used with -target")
+	}
+	if !o.build.IsZero() && !o.launchesTarget(args) {
+		return nil, errors.New("build flags can only be used with -target")
+	}
+	if o.watch && !o.launchesTarget(args) {
+		return nil, errors.New("-watch can only be used with -target")
+	}
+
+	switch {
+	case o.url != "":
+		return poll.New(o.url, time.Duration(o.period)*time.Millisecond), nil
+	case o.follow != "":
+		return stream.NewFollower(o.follow, stream.DefaultPollInterval, streamOpts...), nil
+	case o.readsStdin(args):
+		return stream.New(os.Stdin, streamOpts...), nil
+	case len(o.targets) > 0:
+		opts = append([]godebug.Option{
+			godebug.WithArgs(args...),
+			godebug.WithEnv(o.env...),
+			godebug.WithRestart(restart),
+			godebug.WithBuildOptions(o.build),
+		}, opts...)
+		if o.detail {
+			opts = append(opts, godebug.WithSchedDetail())
+		}
+		if o.gc {
+			opts = append(opts, godebug.WithGCTrace())
+		}
+		if o.watch {
+			opts = append(opts, godebug.WithWatch())
+		}
+		return godebug.New(o.targets[0], o.period, opts...), nil
+	}
+	return nil, errNoSource
+}
@@ -8,9 +8,13 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/JustSkiv/goschedviz/internal/collector/godebug"
+	"github.com/JustSkiv/goschedviz/internal/alert"
 	"github.com/JustSkiv/goschedviz/internal/domain"
 	"github.com/JustSkiv/goschedviz/internal/ui"
 	"github.com/JustSkiv/goschedviz/internal/ui/termui"
@@ -28,26 +32,128 @@ type presenter interface {
 	Done() <-chan struct{}
 }
 
+// statusReporter is implemented by collectors that can describe their own state,
+// e.g. replay position.
+type statusReporter interface {
+	Status() string
+}
+
+// exitReporter is implemented by collectors that start the monitored program.
+// Exit reports false while the program is running.
+type exitReporter interface {
+	Exit() (domain.ExitStatus, bool)
+}
+
+// logSource is implemented by collectors that keep output of the monitored program.
+type logSource interface {
+	Logs() []domain.LogEntry
+}
+
+// diagnosticsSource is implemented by collectors that parse trace output
+// and count lines they couldn't use.
+type diagnosticsSource interface {
+	Diagnostics() domain.ParseReport
+}
+
+// source is a collector shown in its own tab when several are monitored.
+type source struct {
+	name    string
+	c       collector
+	alerts  *alert.Engine      // nil without alert rules
+	summary *domain.Summarizer // nil without the end-of-session summary
+}
+
 func main() {
-	var (
-		targetPath = flag.String("target", "", "Path to Go program to monitor")
-		period     = flag.Int("period", 1000, "GODEBUG schedtrace period in milliseconds")
-	)
+	var err error
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
 
-	flag.Parse()
+	switch command {
+	case "record":
+		err = runRecord(os.Args[2:])
+	case "replay":
+		err = runReplay(os.Args[2:])
+	case "agent":
+		err = runAgent(os.Args[2:])
+	case "view":
+		err = runView(os.Args[2:])
+	case "check":
+		err = runCheck(os.Args[2:])
+	case "report":
+		err = runReport(os.Args[2:])
+	default:
+		err = runMonitor(os.Args[1:])
+	}
 
-	if *targetPath == "" {
-		fmt.Println("Please specify target program path with -target flag")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
+}
+
+// runMonitor monitors a live program or stream in the terminal UI.
+func runMonitor(args []string) error {
+	fs := flag.NewFlagSet("goschedviz", flag.ExitOnError)
+	var opts monitorOptions
+	opts.register(fs)
+	alerts := fs.String("alerts", "", "File with alert rules evaluated against every snapshot")
+	var summary summaryOptions
+	summary.register(fs)
+
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage:\n")
+		fmt.Fprintf(out, "  goschedviz -target=<program> [flags] [-- <target args>]\n")
+		fmt.Fprintf(out, "  goschedviz -target=<program> -target=<program> [flags]\n")
+		fmt.Fprintf(out, "  <program> 2>&1 | goschedviz [flags] -\n")
+		fmt.Fprintf(out, "  goschedviz -follow=<log file> [flags]\n")
+		fmt.Fprintf(out, "  goschedviz -url=<metrics endpoint> [flags]\n")
+		fmt.Fprintf(out, "  goschedviz record -o <file> -target=<program> [flags] [-- <target args>]\n")
+		fmt.Fprintf(out, "  goschedviz replay [flags] <file>\n")
+		fmt.Fprintf(out, "  goschedviz report [-o <file>] [flags] <file>\n")
+		fmt.Fprintf(out, "  goschedviz agent [-listen <addr>] -target=<program> [flags] [-- <target args>]\n")
+		fmt.Fprintf(out, "  goschedviz view <host:port> [<host:port>...]\n")
+		fmt.Fprintf(out, "  goschedviz check [-duration <d>] [thresholds] -target=<program>|-test=<package> [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
 
-	// Create collector
-	collector := godebug.New(*targetPath, *period)
+	fs.Parse(args)
 
+	sources, err := opts.newSources(fs.Args())
+	if err != nil {
+		return err
+	}
+	if err := attachAlerts(*alerts, sources); err != nil {
+		return err
+	}
+	summary.attach(sources)
+
+	if err := runSources(sources, nil); err != nil {
+		return err
+	}
+	return summary.write(os.Stdout, sources)
+}
+
+// runSources shows metrics from several collectors in the terminal UI, one tab each,
+// until the user quits. Bindings map key event IDs to additional handlers.
+func runSources(sources []source, bindings map[string]func()) error {
 	// Create UI
 	presenter := termui.New()
+	for key, handler := range bindings {
+		presenter.Bind(key, handler)
+	}
+
+	tabs := newTabSelector(len(sources))
+	if len(sources) > 1 {
+		presenter.Bind("<Tab>", tabs.next)
+		for i := 0; i < len(sources) && i < 9; i++ {
+			presenter.Bind(strconv.Itoa(i+1), func() { tabs.set(i) })
+		}
+	}
 	if err := presenter.Start(); err != nil {
-		log.Fatalf("Failed to initialize UI: %v", err)
+		return fmt.Errorf("failed to initialize UI: %w", err)
 	}
 	defer presenter.Stop()
 
@@ -58,45 +164,136 @@ func main() {
 	// Handle Ctrl+C
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt)
+	defer signal.Stop(sigChan)
 	go func() {
-		<-sigChan
-		cancel()
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
 	}()
 
-	if err := monitorScheduler(ctx, collector, presenter); err != nil {
-		log.Println("Error:", err)
-		return
-	}
-
+	return monitorSources(ctx, sources, presenter, tabs)
 }
 
 func monitorScheduler(ctx context.Context, c collector, p presenter) error {
-	snapshots, err := c.Start(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to start collector: %w", err)
+	return monitorSources(ctx, []source{{c: c}}, p, newTabSelector(1))
+}
+
+// monitorSources collects snapshots of every source into its own state and shows the selected one.
+func monitorSources(ctx context.Context, sources []source, p presenter, tabs *tabSelector) error {
+	channels := make([]<-chan domain.SchedulerSnapshot, len(sources))
+	for i, s := range sources {
+		snapshots, err := s.c.Start(ctx)
+		if err != nil {
+			stopCollectors(sources[:i])
+			if s.name != "" {
+				return fmt.Errorf("failed to start collector for %s: %w", s.name, err)
+			}
+			return fmt.Errorf("failed to start collector: %w", err)
+		}
+		channels[i] = snapshots
 	}
-	defer func() {
-		if err := c.Stop(); err != nil {
-			log.Println("Failed to stop collector:", err)
+	defer stopCollectors(sources)
+	defer closeAlerts(sources)
+
+	quit := make(chan struct{})
+	defer close(quit)
+
+	states := make([]*domain.MonitorState, len(sources))
+	ended := make(chan int)
+	for i := range sources {
+		states[i] = &domain.MonitorState{}
+		go func() {
+			for {
+				select {
+				case snapshot, ok := <-channels[i]:
+					if !ok {
+						select {
+						case ended <- i:
+						case <-quit:
+						}
+						return
+					}
+					states[i].Update(snapshot)
+					if e := sources[i].alerts; e != nil {
+						e.Evaluate(snapshot)
+					}
+					if s := sources[i].summary; s != nil {
+						s.Add(snapshot)
+					}
+				case <-quit:
+					return
+				}
+			}
+		}()
+	}
+
+	names := make([]string, len(sources))
+	for i, s := range sources {
+		names[i] = s.name
+		if names[i] == "" {
+			names[i] = fmt.Sprintf("#%d", i+1)
 		}
-	}()
+	}
+
+	update := func() {
+		i := tabs.selected()
+		latest, history := states[i].GetSnapshot()
+		uiData := convertToUIData(latest, history)
+		c := sources[i].c
+		if sr, ok := c.(statusReporter); ok {
+			uiData.Status = sr.Status()
+		}
+		if ls, ok := c.(logSource); ok {
+			uiData.Logs = convertLogs(ls.Logs())
+		}
+		if er, ok := c.(exitReporter); ok {
+			if status, exited := er.Exit(); exited {
+				uiData.Exit = convertExit(status)
+			}
+		}
+		if ds, ok := c.(diagnosticsSource); ok {
+			report := ds.Diagnostics()
+			uiData.Diagnostics = convertDiagnostics(report)
+			uiData.Status = joinStatus(uiData.Status, diagnosticsHint(report))
+		}
+		if e := sources[i].alerts; e != nil {
+			now := latest.Received
+			if now.IsZero() {
+				now = time.Now()
+			}
+			uiData.Alerts = convertAlerts(e.Firing(), now)
+			uiData.Status = joinStatus(uiData.Status, alertsHint(uiData.Alerts, e.Err()))
+		}
+		if len(sources) > 1 {
+			uiData.Tabs = names
+			uiData.Tab = i
+			uiData.Split, uiData.SplitWindow = convertSplit(sources, names, states, time.Now())
+		}
+		p.Update(uiData)
+	}
 
-	state := &domain.MonitorState{}
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 
+	running := len(sources)
 	for {
 		select {
-		case snapshot, ok := <-snapshots:
-			if !ok {
+		case i := <-ended:
+			// Keep the last state of an exited program on screen until the user quits
+			if _, ok := sources[i].c.(exitReporter); ok {
+				continue
+			}
+			if running--; running == 0 {
 				return nil
 			}
-			state.Update(snapshot)
 
 		case <-ticker.C:
-			latest, history := state.GetSnapshot()
-			uiData := convertToUIData(latest, history)
-			p.Update(uiData)
+			update()
+
+		case <-tabs.changed:
+			update()
 
 		case <-p.Done():
 			return nil
@@ -107,6 +304,60 @@ func monitorScheduler(ctx context.Context, c collector, p presenter) error {
 	}
 }
 
+// stopCollectors stops every collector of the sources.
+func stopCollectors(sources []source) {
+	for _, s := range sources {
+		if err := s.c.Stop(); err != nil {
+			log.Println("Failed to stop collector:", err)
+		}
+	}
+}
+
+// closeAlerts waits for running alert actions of every source.
+func closeAlerts(sources []source) {
+	for _, s := range sources {
+		if s.alerts != nil {
+			s.alerts.Close()
+		}
+	}
+}
+
+// tabSelector keeps the index of the source shown in the UI.
+type tabSelector struct {
+	mu      sync.Mutex
+	current int
+	count   int
+	changed chan struct{} // signals a switch, so the UI is updated right away
+}
+
+func newTabSelector(count int) *tabSelector {
+	return &tabSelector{count: count, changed: make(chan struct{}, 1)}
+}
+
+// selected returns the index of the shown source.
+func (t *tabSelector) selected() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current
+}
+
+// next switches to the following source, wrapping around.
+func (t *tabSelector) next() {
+	t.set(t.selected() + 1)
+}
+
+// set switches to the source with the index, wrapping around.
+func (t *tabSelector) set(i int) {
+	t.mu.Lock()
+	t.current = i % t.count
+	t.mu.Unlock()
+
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+}
+
 // convertToUIData converts domain data to UI-specific format
 func convertToUIData(latest domain.SchedulerSnapshot, history []domain.SchedulerSnapshot) ui.UIData {
 	// Calculate max values for gauges
@@ -127,14 +378,7 @@ func convertToUIData(latest domain.SchedulerSnapshot, history []domain.Scheduler
 			maxIdleProcs = h.IdleProcs
 		}
 
-		histValues[i] = ui.HistoricalValues{
-			TimeMs:     h.TimeMs,
-			GRQ:        h.RunQueue,
-			LRQSum:     h.LRQSum,
-			IdleProcs:  h.IdleProcs,
-			Threads:    h.Threads,
-			Goroutines: h.Goroutines,
-		}
+		histValues[i] = historicalValues(h)
 	}
 
 	// Ensure non-zero max values for gauges
@@ -165,6 +409,9 @@ func convertToUIData(latest domain.SchedulerSnapshot, history []domain.Scheduler
 			NumP:            len(latest.LRQ),
 			LRQ:             latest.LRQ,
 			Goroutines:      latest.Goroutines,
+			Received:        latest.Received,
+			Seq:             latest.Seq,
+			Source:          latest.Source,
 		},
 		Gauges: ui.GaugeValues{
 			GRQ: struct {
@@ -199,6 +446,271 @@ func convertToUIData(latest domain.SchedulerSnapshot, history []domain.Scheduler
 	}
 
 	result.History.Raw = histValues
+	result.Detail = convertDetail(latest.Detail)
+	result.GC = convertGC(history)
+	result.Latency = convertLatency(history)
+	result.Goroutines = convertGoroutines(history)
+	result.Leak = convertLeak(domain.DetectLeak(history))
+
+	return result
+}
 
+// historicalValues converts a history point to UI format
+func historicalValues(h domain.SchedulerSnapshot) ui.HistoricalValues {
+	return ui.HistoricalValues{
+		TimeMs:     h.TimeMs,
+		Time:       h.Received,
+		Seq:        h.Seq,
+		GRQ:        h.RunQueue,
+		LRQSum:     h.LRQSum,
+		IdleProcs:  h.IdleProcs,
+		Threads:    h.Threads,
+		Goroutines: h.Goroutines,
+		GCCycles:   domain.CountGC(h.GC),
+		Restarted:  h.Marker == domain.MarkerRestart,
+		Rebuilt:    h.Marker == domain.MarkerRebuild,
+		Metrics:    h.Metrics,
+	}
+}
+
+// convertGC summarizes GC cycles within the history window, nil if there were none
+func convertGC(history []domain.SchedulerSnapshot) *ui.GCValues {
+	var result *ui.GCValues
+	for _, h := range history {
+		for _, c := range h.GC {
+			if result == nil {
+				result = &ui.GCValues{}
+			}
+			result.Cycles += c.Count()
+			if c.Forced {
+				result.Forced++
+			}
+			if stw := durationMs(c.STW()); stw > result.MaxSTWMs {
+				result.MaxSTWMs = stw
+			}
+			result.Last = ui.GCCycleValues{
+				Number:      c.Number,
+				AtMs:        c.AtMs,
+				CPUPercent:  c.CPUPercent,
+				SweepTermMs: durationMs(c.SweepTermination),
+				MarkMs:      durationMs(c.Mark),
+				MarkTermMs:  durationMs(c.MarkTermination),
+				AssistCPUMs: durationMs(c.AssistCPU),
+				HeapStartMB: c.HeapStartMB,
+				HeapEndMB:   c.HeapEndMB,
+				HeapLiveMB:  c.HeapLiveMB,
+				HeapGoalMB:  c.HeapGoalMB,
+				Forced:      c.Forced,
+				Merged:      c.Merged,
+			}
+		}
+	}
 	return result
 }
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// seconds converts seconds of summaries and reports to a duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// convertExit converts the exit status of the program to UI format
+func convertExit(status domain.ExitStatus) *ui.ExitValues {
+	return &ui.ExitValues{
+		Code:       status.Code,
+		Signal:     status.Signal,
+		Runtime:    status.Runtime,
+		Restarts:   status.Restarts,
+		Restarting: status.Restarting,
+		Watching:   status.Watching,
+	}
+}
+
+// latencyMetric is the runtime/metrics histogram of scheduling latency.
+const latencyMetric = "/sched/latencies:seconds"
+
+// convertLatency converts scheduling latency histograms to UI format: a point per sample
+// with goroutines scheduled since the previous one. Snapshots that repeat the previous
+// sample are skipped, metrics are usually reported less often than schedtrace.
+func convertLatency(history []domain.SchedulerSnapshot) *ui.LatencyValues {
+	bounds := make([]float64, len(ui.LatencyBounds))
+	for i, b := range ui.LatencyBounds {
+		bounds[i] = b.Seconds()
+	}
+	seconds := func(v float64) time.Duration {
+		return time.Duration(v * float64(time.Second))
+	}
+
+	var result *ui.LatencyValues
+	var prev *domain.Histogram
+	for _, h := range history {
+		sample, ok := h.Histograms[latencyMetric]
+		if !ok {
+			continue
+		}
+		if prev == nil {
+			prev = &sample
+			continue
+		}
+		diff := sample.Since(*prev)
+		if diff.Total() == 0 {
+			continue
+		}
+		prev = &sample
+
+		if result == nil {
+			result = &ui.LatencyValues{}
+		}
+		result.Points = append(result.Points, ui.LatencyPoint{
+			TimeMs: h.TimeMs,
+			P50:    seconds(diff.Quantile(0.5)),
+			P90:    seconds(diff.Quantile(0.9)),
+			P99:    seconds(diff.Quantile(0.99)),
+			Bands:  diff.Bands(bounds),
+		})
+	}
+	return result
+}
+
+// convertGoroutines converts goroutine profile summaries to UI format: a sample per snapshot
+// that has one. Sites come from the latest summary.
+func convertGoroutines(history []domain.SchedulerSnapshot) *ui.GoroutineValues {
+	other := slices.Index(ui.GoroutineStates, "other")
+
+	var result *ui.GoroutineValues
+	for _, h := range history {
+		if h.GoroutineProfile == nil {
+			continue
+		}
+		if result == nil {
+			result = &ui.GoroutineValues{}
+		}
+
+		counts := make([]int, len(ui.GoroutineStates))
+		for state, n := range h.GoroutineProfile.States {
+			i := slices.Index(ui.GoroutineStates, state)
+			if i < 0 {
+				i = other
+			}
+			counts[i] += n
+		}
+		result.Samples = append(result.Samples, ui.GoroutineSample{TimeMs: h.TimeMs, Counts: counts})
+
+		result.Sites = result.Sites[:0]
+		for _, site := range h.GoroutineProfile.Sites {
+			result.Sites = append(result.Sites, ui.GoroutineSite{Function: site.Function, Count: site.Count})
+		}
+	}
+	return result
+}
+
+// convertLeak converts a leak report to UI format, nil if no leak is suspected
+func convertLeak(report domain.LeakReport) *ui.LeakValues {
+	if !report.Suspected {
+		return nil
+	}
+	return &ui.LeakValues{
+		Slope:      report.Slope,
+		Confidence: report.Confidence,
+		Growth:     report.Growth(),
+		Window:     seconds(report.Window),
+	}
+}
+
+// convertDiagnostics converts parse diagnostics to UI format
+func convertDiagnostics(report domain.ParseReport) *ui.DiagnosticsValues {
+	issues := make([]ui.ParseIssue, len(report.Recent))
+	for i, issue := range report.Recent {
+		issues[i] = ui.ParseIssue{
+			Time:   issue.Time,
+			Reason: issue.Reason.String(),
+			Detail: issue.Detail,
+			Line:   issue.Line,
+		}
+	}
+	return &ui.DiagnosticsValues{
+		Lines:      report.Lines,
+		Snapshots:  report.Snapshots,
+		Unmatched:  report.Unmatched,
+		Malformed:  report.Malformed,
+		Rejected:   report.Rejected,
+		Suspicious: report.Suspicious,
+		Recent:     issues,
+		FieldSet:   report.FieldSet,
+		Unknown:    report.Unknown,
+	}
+}
+
+// noTraceLines defines how many lines without a single snapshot
+// are enough to suspect the input has no scheduler trace at all.
+const noTraceLines = 20
+
+// diagnosticsHint returns a status message when trace lines are dropped
+// or the input doesn't look like trace output, empty otherwise.
+func diagnosticsHint(report domain.ParseReport) string {
+	switch {
+	case report.Dropped() > 0:
+		return fmt.Sprintf("Dropped trace lines: %d, press 'd' for details", report.Dropped())
+	case report.Suspicious > 0:
+		return fmt.Sprintf("Suspicious snapshots: %d, press 'd' for details", report.Suspicious)
+	case report.Snapshots == 0 && report.Lines >= noTraceLines:
+		return "No scheduler trace in input, press 'd' for details"
+	}
+	return ""
+}
+
+// joinStatus joins non-empty status messages.
+func joinStatus(messages ...string) string {
+	var parts []string
+	for _, m := range messages {
+		if m != "" {
+			parts = append(parts, m)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// convertLogs converts program output to UI format
+func convertLogs(entries []domain.LogEntry) []ui.LogLine {
+	lines := make([]ui.LogLine, len(entries))
+	for i, e := range entries {
+		lines[i] = ui.LogLine{
+			Seq:    e.Seq,
+			Time:   e.Time,
+			Stream: e.Stream,
+			Text:   e.Text,
+		}
+	}
+	return lines
+}
+
+// convertDetail converts scheddetail state to UI format, nil stays nil
+func convertDetail(detail *domain.SchedDetail) *ui.DetailValues {
+	if detail == nil {
+		return nil
+	}
+
+	procs := make([]ui.ProcValues, len(detail.Procs))
+	for i, p := range detail.Procs {
+		curG := domain.NoID
+		if m, ok := detail.Thread(p.M); ok {
+			curG = m.CurG
+		}
+		procs[i] = ui.ProcValues{
+			ID:       p.ID,
+			Status:   p.Status.String(),
+			M:        p.M,
+			CurG:     curG,
+			RunqSize: p.RunqSize,
+		}
+	}
+
+	return &ui.DetailValues{
+		Procs:   procs,
+		Waiting: detail.WaitReasons(),
+	}
+}
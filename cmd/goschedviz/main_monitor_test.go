@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/JustSkiv/goschedviz/internal/alert"
 	"github.com/JustSkiv/goschedviz/internal/domain"
 	"github.com/JustSkiv/goschedviz/internal/ui"
 )
@@ -16,6 +17,7 @@ import (
 type MockCollector struct {
 	snapshots  chan domain.SchedulerSnapshot
 	startError error
+	stopError  error
 	stopCalled bool
 }
 
@@ -28,7 +30,7 @@ func (m *MockCollector) Start(ctx context.Context) (<-chan domain.SchedulerSnaps
 
 func (m *MockCollector) Stop() error {
 	m.stopCalled = true
-	return nil
+	return m.stopError
 }
 
 type MockPresenter struct {
@@ -364,3 +366,235 @@ func TestMonitorScheduler_Metrics(t *testing.T) {
 		})
 	}
 }
+
+// statusCollector is a MockCollector that reports its state.
+type statusCollector struct {
+	MockCollector
+}
+
+func (s *statusCollector) Status() string { return "Replay 00:01/00:10 x1 playing" }
+
+func TestMonitorScheduler_Status(t *testing.T) {
+	mockCollector := &statusCollector{
+		MockCollector: MockCollector{snapshots: make(chan domain.SchedulerSnapshot)},
+	}
+
+	statuses := make(chan string, 10)
+	mockPresenter := &MockPresenter{
+		done: make(chan struct{}),
+		updateFunc: func(data ui.UIData) {
+			select {
+			case statuses <- data.Status:
+			default:
+			}
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 700*time.Millisecond)
+	defer cancel()
+
+	err := monitorScheduler(ctx, mockCollector, mockPresenter)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, statuses, "presenter should be updated at least once")
+	assert.Equal(t, "Replay 00:01/00:10 x1 playing", <-statuses)
+}
+
+// logCollector is a MockCollector that keeps program output.
+type logCollector struct {
+	MockCollector
+}
+
+func (l *logCollector) Logs() []domain.LogEntry {
+	return []domain.LogEntry{{
+		Seq:        7,
+		OutputLine: domain.OutputLine{Time: time.Unix(100, 0), Stream: "stdout", Text: "listening on :8080"},
+	}}
+}
+
+func TestMonitorScheduler_Logs(t *testing.T) {
+	mockCollector := &logCollector{
+		MockCollector: MockCollector{snapshots: make(chan domain.SchedulerSnapshot)},
+	}
+
+	logs := make(chan []ui.LogLine, 10)
+	mockPresenter := &MockPresenter{
+		done: make(chan struct{}),
+		updateFunc: func(data ui.UIData) {
+			select {
+			case logs <- data.Logs:
+			default:
+			}
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 700*time.Millisecond)
+	defer cancel()
+
+	err := monitorScheduler(ctx, mockCollector, mockPresenter)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, logs, "presenter should be updated at least once")
+	assert.Equal(t, []ui.LogLine{{
+		Seq: 7, Time: time.Unix(100, 0), Stream: "stdout", Text: "listening on :8080",
+	}}, <-logs)
+}
+
+// exitCollector is a MockCollector for a program that has exited.
+type exitCollector struct {
+	MockCollector
+}
+
+func (e *exitCollector) Exit() (domain.ExitStatus, bool) {
+	return domain.ExitStatus{Code: 2, Runtime: 3 * time.Second, Restarts: 1}, true
+}
+
+func TestMonitorScheduler_Exit(t *testing.T) {
+	mockCollector := &exitCollector{
+		MockCollector: MockCollector{snapshots: make(chan domain.SchedulerSnapshot)},
+	}
+	close(mockCollector.snapshots)
+
+	exits := make(chan *ui.ExitValues, 10)
+	mockPresenter := &MockPresenter{
+		done: make(chan struct{}),
+		updateFunc: func(data ui.UIData) {
+			select {
+			case exits <- data.Exit:
+			default:
+			}
+		},
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- monitorScheduler(context.Background(), mockCollector, mockPresenter)
+	}()
+
+	// UI stays open after the snapshots channel is closed
+	select {
+	case exit := <-exits:
+		assert.Equal(t, &ui.ExitValues{Code: 2, Runtime: 3 * time.Second, Restarts: 1}, exit)
+	case err := <-errChan:
+		t.Fatalf("monitorScheduler returned after program exit: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for update")
+	}
+
+	close(mockPresenter.done)
+	require.NoError(t, <-errChan)
+	assert.True(t, mockCollector.stopCalled)
+}
+
+func TestMonitorSources_Tabs(t *testing.T) {
+	producer := &MockCollector{snapshots: make(chan domain.SchedulerSnapshot)}
+	consumer := &MockCollector{snapshots: make(chan domain.SchedulerSnapshot)}
+	sources := []source{{name: "producer", c: producer}, {name: "consumer", c: consumer}}
+
+	updates := make(chan ui.UIData, 100)
+	mockPresenter := &MockPresenter{
+		done: make(chan struct{}),
+		updateFunc: func(data ui.UIData) {
+			select {
+			case updates <- data:
+			default:
+			}
+		},
+	}
+
+	tabs := newTabSelector(len(sources))
+	errChan := make(chan error)
+	go func() {
+		errChan <- monitorSources(context.Background(), sources, mockPresenter, tabs)
+	}()
+
+	producer.snapshots <- domain.SchedulerSnapshot{TimeMs: 100, Goroutines: 10}
+	consumer.snapshots <- domain.SchedulerSnapshot{TimeMs: 100, Goroutines: 20}
+
+	// Switching a tab updates the UI right away with the state of that source
+	tabs.set(1)
+	var shown ui.UIData
+	require.Eventually(t, func() bool {
+		select {
+		case shown = <-updates:
+			return shown.Tab == 1 && shown.Current.Goroutines == 20
+		default:
+			return false
+		}
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"producer", "consumer"}, shown.Tabs)
+	require.Len(t, shown.Split, 2)
+	assert.Equal(t, "consumer", shown.Split[1].Name)
+
+	// UI closes only when all sources are done
+	close(producer.snapshots)
+	select {
+	case err := <-errChan:
+		t.Fatalf("monitorSources returned with a source left: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(consumer.snapshots)
+	require.NoError(t, <-errChan)
+	assert.True(t, producer.stopCalled)
+	assert.True(t, consumer.stopCalled)
+}
+
+func TestMonitorSources_StartError(t *testing.T) {
+	started := &MockCollector{snapshots: make(chan domain.SchedulerSnapshot)}
+	failing := &MockCollector{startError: fmt.Errorf("connection refused")}
+	sources := []source{{name: "a", c: started}, {name: "b", c: failing}}
+
+	err := monitorSources(context.Background(), sources, &MockPresenter{done: make(chan struct{})}, newTabSelector(2))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "b")
+	assert.True(t, started.stopCalled, "Collectors started before the failure should be stopped")
+}
+
+func TestTabSelector(t *testing.T) {
+	tabs := newTabSelector(3)
+	assert.Equal(t, 0, tabs.selected())
+
+	tabs.next()
+	tabs.next()
+	assert.Equal(t, 2, tabs.selected())
+	tabs.next()
+	assert.Equal(t, 0, tabs.selected(), "Selection wraps around")
+
+	tabs.set(1)
+	assert.Equal(t, 1, tabs.selected())
+	assert.Len(t, tabs.changed, 1, "Pending switch notifications are merged")
+}
+
+func TestMonitorSources_Alerts(t *testing.T) {
+	rule, err := alert.ParseRule("busy: grq > 100 => highlight grq")
+	require.NoError(t, err)
+
+	mockCollector := &MockCollector{snapshots: make(chan domain.SchedulerSnapshot)}
+	sources := []source{{c: mockCollector, alerts: alert.NewEngine([]alert.Rule{rule})}}
+
+	updates := make(chan ui.UIData, 10)
+	mockPresenter := &MockPresenter{
+		done: make(chan struct{}),
+		updateFunc: func(data ui.UIData) {
+			select {
+			case updates <- data:
+			default:
+			}
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 700*time.Millisecond)
+	defer cancel()
+
+	go func() {
+		mockCollector.snapshots <- domain.SchedulerSnapshot{GoMaxProcs: 1, RunQueue: 150, LRQ: []int{0}}
+	}()
+	require.NoError(t, monitorSources(ctx, sources, mockPresenter, newTabSelector(1)))
+
+	require.NotEmpty(t, updates)
+	data := <-updates
+	require.Len(t, data.Alerts, 1)
+	assert.Equal(t, "busy", data.Alerts[0].Name)
+	assert.Equal(t, []string{"grq"}, data.Alerts[0].Widgets)
+	assert.Contains(t, data.Status, "ALERT: busy")
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/JustSkiv/goschedviz/internal/collector/godebug"
 	"github.com/JustSkiv/goschedviz/internal/domain"
 	"github.com/JustSkiv/goschedviz/internal/ui"
 )
@@ -379,3 +382,238 @@ func TestConvertToUIData_Complex(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvFlags_Set(t *testing.T) {
+	var env envFlags
+
+	assert.NoError(t, env.Set("APP_ENV=prod"))
+	assert.NoError(t, env.Set("GODEBUG=gctrace=1"))
+	assert.NoError(t, env.Set("EMPTY="))
+	assert.Error(t, env.Set("NOVALUE"))
+	assert.Error(t, env.Set("=value"))
+
+	assert.Equal(t, envFlags{"APP_ENV=prod", "GODEBUG=gctrace=1", "EMPTY="}, env)
+	assert.Equal(t, "APP_ENV=prod,GODEBUG=gctrace=1,EMPTY=", env.String())
+}
+
+func TestConvertToUIData_Detail(t *testing.T) {
+	got := convertToUIData(domain.SchedulerSnapshot{}, nil)
+	assert.Nil(t, got.Detail, "Snapshots without scheddetail should have no detail")
+
+	latest := domain.SchedulerSnapshot{
+		Detail: &domain.SchedDetail{
+			Procs: []domain.ProcState{
+				{ID: 0, Status: domain.ProcRunning, M: 2, RunqSize: 3},
+				{ID: 1, Status: domain.ProcIdle, M: domain.NoID},
+			},
+			Threads: []domain.ThreadState{
+				{ID: 2, P: 0, CurG: 17, LockedG: domain.NoID},
+			},
+			Goroutines: []domain.GoroutineState{
+				{ID: 17, Status: domain.GoroutineRunning, M: 2, LockedM: domain.NoID},
+				{ID: 18, Status: domain.GoroutineWaiting, WaitReason: "chan receive", M: domain.NoID, LockedM: domain.NoID},
+			},
+		},
+	}
+
+	got = convertToUIData(latest, []domain.SchedulerSnapshot{latest})
+	assert.Equal(t, &ui.DetailValues{
+		Procs: []ui.ProcValues{
+			{ID: 0, Status: "running", M: 2, CurG: 17, RunqSize: 3},
+			{ID: 1, Status: "idle", M: domain.NoID, CurG: domain.NoID},
+		},
+		Waiting: map[string]int{"chan receive": 1},
+	}, got.Detail)
+}
+
+func TestConvertToUIData_GC(t *testing.T) {
+	history := []domain.SchedulerSnapshot{
+		{GoMaxProcs: 1},
+		{GoMaxProcs: 1, GC: []domain.GCCycle{
+			{Number: 1, SweepTermination: 2 * time.Millisecond, MarkTermination: time.Millisecond},
+			{Number: 2, Forced: true, SweepTermination: time.Millisecond},
+		}},
+		{GoMaxProcs: 1, GC: []domain.GCCycle{
+			{Number: 5, AtMs: 1500, CPUPercent: 4, Mark: 5 * time.Millisecond, HeapStartMB: 8, HeapEndMB: 9, HeapLiveMB: 3, HeapGoalMB: 8, Merged: 2},
+		}},
+	}
+
+	got := convertToUIData(history[2], history)
+	assert.Equal(t, []int{0, 2, 3}, []int{got.History.Raw[0].GCCycles, got.History.Raw[1].GCCycles, got.History.Raw[2].GCCycles})
+	assert.Equal(t, &ui.GCValues{
+		Last: ui.GCCycleValues{
+			Number: 5, AtMs: 1500, CPUPercent: 4, MarkMs: 5,
+			HeapStartMB: 8, HeapEndMB: 9, HeapLiveMB: 3, HeapGoalMB: 8, Merged: 2,
+		},
+		Cycles:   5,
+		Forced:   1,
+		MaxSTWMs: 3,
+	}, got.GC)
+
+	assert.Nil(t, convertToUIData(history[0], history[:1]).GC)
+}
+
+func TestConvertToUIData_Restart(t *testing.T) {
+	history := []domain.SchedulerSnapshot{
+		{TimeMs: 5000},
+		{TimeMs: 100, Marker: domain.MarkerRestart},
+		{TimeMs: 200, Marker: domain.MarkerRebuild},
+	}
+
+	got := convertToUIData(history[2], history)
+	assert.Equal(t, []bool{false, true, false}, []bool{got.History.Raw[0].Restarted, got.History.Raw[1].Restarted, got.History.Raw[2].Restarted})
+	assert.Equal(t, []bool{false, false, true}, []bool{got.History.Raw[0].Rebuilt, got.History.Raw[1].Rebuilt, got.History.Raw[2].Rebuilt})
+}
+
+func TestMonitorOptions_MetricsSocket(t *testing.T) {
+	opts := monitorOptions{targets: targetFlags{"main.go"}, period: 1000, socket: "/tmp/goschedviz.sock"}
+	_, err := opts.newCollector(nil)
+	assert.Error(t, err, "Launched targets get their metrics channel automatically")
+
+	opts = monitorOptions{follow: "app.log", socket: "/tmp/goschedviz.sock"}
+	c, err := opts.newCollector(nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+}
+
+func TestMonitorOptions_Restart(t *testing.T) {
+	opts := monitorOptions{targets: targetFlags{"main.go"}, period: 1000, restart: "on-failure"}
+	c, err := opts.newCollector(nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+
+	opts = monitorOptions{follow: "app.log", restart: "always"}
+	_, err = opts.newCollector(nil)
+	assert.Error(t, err, "Only launched targets can be restarted")
+
+	opts = monitorOptions{targets: targetFlags{"main.go"}, restart: "sometimes"}
+	_, err = opts.newCollector(nil)
+	assert.Error(t, err)
+}
+
+func TestMonitorOptions_BuildFlags(t *testing.T) {
+	opts := monitorOptions{targets: targetFlags{"main.go"}, period: 1000, build: godebug.BuildOptions{Race: true}}
+	c, err := opts.newCollector(nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+
+	opts = monitorOptions{targets: targetFlags{"-"}, build: godebug.BuildOptions{Tags: "debug"}}
+	_, err = opts.newCollector(nil)
+	assert.Error(t, err, "Programs read from stdin are not built")
+
+	opts = monitorOptions{url: "http://localhost:6060/debug/goschedviz", watch: true}
+	_, err = opts.newCollector(nil)
+	assert.Error(t, err, "Only launched targets can be watched")
+}
+
+func TestMonitorOptions_Targets(t *testing.T) {
+	opts := monitorOptions{targets: targetFlags{"./cmd/producer", "./cmd/consumer"}, period: 1000, watch: true}
+	sources, err := opts.newSources(nil)
+	require.NoError(t, err)
+	require.Len(t, sources, 2)
+	assert.Equal(t, "./cmd/producer", sources[0].name)
+	assert.Equal(t, "./cmd/consumer", sources[1].name)
+
+	_, err = opts.newSources([]string{"-listen", ":8080"})
+	assert.Error(t, err, "Target arguments are ambiguous with several targets")
+
+	_, err = opts.newCollector(nil)
+	assert.Error(t, err, "Commands with a single collector accept one target")
+
+	opts = monitorOptions{targets: targetFlags{"./cmd/producer", "-"}}
+	_, err = opts.newSources(nil)
+	assert.Error(t, err)
+
+	opts = monitorOptions{targets: targetFlags{"main.go"}, period: 1000}
+	sources, err = opts.newSources(nil)
+	require.NoError(t, err)
+	assert.Len(t, sources, 1)
+}
+
+func TestMonitorOptions_Validation(t *testing.T) {
+	opts := monitorOptions{targets: targetFlags{"main.go"}, period: 1000}
+	opts.check.strict = true
+	_, err := opts.newCollector(nil)
+	assert.NoError(t, err)
+
+	opts.check.lenient = true
+	_, err = opts.newCollector(nil)
+	assert.Error(t, err, "-strict and -lenient are mutually exclusive")
+}
+
+func TestDiagnosticsHint(t *testing.T) {
+	assert.Empty(t, diagnosticsHint(domain.ParseReport{Lines: 100, Snapshots: 10, Unmatched: 90}))
+	assert.Empty(t, diagnosticsHint(domain.ParseReport{Lines: 5, Unmatched: 5}), "Too early to tell")
+	assert.Contains(t, diagnosticsHint(domain.ParseReport{Lines: 50, Unmatched: 50}), "No scheduler trace")
+	assert.Contains(t, diagnosticsHint(domain.ParseReport{Lines: 10, Snapshots: 5, Malformed: 2, Rejected: 1}), "Dropped trace lines: 3")
+
+	diag := convertDiagnostics(domain.ParseReport{
+		Lines:    2,
+		Rejected: 1,
+		Recent:   []domain.ParseIssue{{Reason: domain.ParseRejected, Detail: "gomaxprocs=0 is not positive"}},
+	})
+	require.Len(t, diag.Recent, 1)
+	assert.Equal(t, "rejected", diag.Recent[0].Reason)
+	assert.Equal(t, "gomaxprocs=0 is not positive", diag.Recent[0].Detail)
+}
+
+func TestConvertLatency(t *testing.T) {
+	sample := func(timeMs int, counts ...uint64) domain.SchedulerSnapshot {
+		return domain.SchedulerSnapshot{TimeMs: timeMs, Histograms: map[string]domain.Histogram{
+			latencyMetric: {Upper: []float64{1e-6, 1e-4, 1e-2}, Counts: counts},
+		}}
+	}
+
+	assert.Nil(t, convertLatency([]domain.SchedulerSnapshot{{TimeMs: 1000}}), "No histograms reported")
+
+	latency := convertLatency([]domain.SchedulerSnapshot{
+		sample(1000, 10, 0, 0),
+		sample(2000, 60, 40, 0),
+		sample(3000, 60, 40, 0), // Metrics were not reported again
+		sample(4000, 60, 90, 1),
+	})
+	require.NotNil(t, latency)
+	require.Len(t, latency.Points, 2, "First sample has nothing to compare with, repeated one is skipped")
+
+	first := latency.Points[0]
+	assert.Equal(t, 2000, first.TimeMs)
+	assert.Equal(t, time.Microsecond, first.P50)
+	assert.Equal(t, 100*time.Microsecond, first.P90)
+	assert.Equal(t, []uint64{50, 0, 40, 0, 0, 0, 0, 0}, first.Bands)
+
+	second := latency.Points[1]
+	assert.Equal(t, 100*time.Microsecond, second.P90)
+	assert.Equal(t, 10*time.Millisecond, second.P99)
+}
+
+func TestConvertGoroutines(t *testing.T) {
+	assert.Nil(t, convertGoroutines([]domain.SchedulerSnapshot{{TimeMs: 1000}}), "No profile reported")
+
+	goroutines := convertGoroutines([]domain.SchedulerSnapshot{
+		{TimeMs: 1000},
+		{TimeMs: 2000, GoroutineProfile: &domain.GoroutineProfile{
+			States: map[string]int{"running": 1, "chan_receive": 10},
+			Sites:  []domain.GoroutineSite{{Function: "main.worker", Count: 10}},
+		}},
+		{TimeMs: 3000, GoroutineProfile: &domain.GoroutineProfile{
+			States: map[string]int{"running": 2, "runnable": 5, "future_state": 3, "other": 1},
+			Sites:  []domain.GoroutineSite{{Function: "main.handler", Count: 7}},
+		}},
+	})
+	require.NotNil(t, goroutines)
+	require.Len(t, goroutines.Samples, 2)
+
+	assert.Equal(t, 2000, goroutines.Samples[0].TimeMs)
+	assert.Equal(t, []int{1, 0, 0, 10, 0, 0, 0, 0, 0, 0}, goroutines.Samples[0].Counts)
+	assert.Equal(t, []int{2, 5, 0, 0, 0, 0, 0, 0, 0, 4}, goroutines.Samples[1].Counts, "Unknown states are other")
+	assert.Equal(t, []ui.GoroutineSite{{Function: "main.handler", Count: 7}}, goroutines.Sites)
+}
+
+func TestConvertLeak(t *testing.T) {
+	assert.Nil(t, convertLeak(domain.LeakReport{Slope: 5, Confidence: 0.5}), "Not suspected")
+
+	leak := convertLeak(domain.LeakReport{
+		Suspected: true, Slope: 10, Confidence: 0.9, Baseline: 100, Latest: 210, Window: 11,
+	})
+	assert.Equal(t, &ui.LeakValues{Slope: 10, Confidence: 0.9, Growth: 110, Window: 11 * time.Second}, leak)
+}
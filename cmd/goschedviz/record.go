@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/collector/godebug"
+	"github.com/JustSkiv/goschedviz/internal/domain"
+	"github.com/JustSkiv/goschedviz/internal/recording"
+)
+
+// runRecord monitors the target like the default command and saves
+// every raw output line to a recording file.
+func runRecord(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	var opts monitorOptions
+	opts.register(fs)
+	output := fs.String("o", "", "Recording file (default goschedviz-<timestamp>.jsonl)")
+	var summary summaryOptions
+	summary.register(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goschedviz record [-o <file>] -target=<program> [flags] [-- <target args>]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+
+	if len(opts.targets) != 1 || opts.readsStdin(fs.Args()) || opts.follow != "" || opts.url != "" {
+		return fmt.Errorf("record requires a single target program, use -target")
+	}
+
+	started := time.Now()
+	path := *output
+	if path == "" {
+		path = "goschedviz-" + started.Format("20060102-150405") + ".jsonl"
+	}
+
+	hostname, _ := os.Hostname()
+	w, err := recording.Create(path, recording.Header{
+		Started:   started,
+		Target:    opts.targets[0],
+		Args:      fs.Args(),
+		Build:     opts.build.Args(),
+		Period:    opts.period,
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		GoVersion: runtime.Version(),
+		Hostname:  hostname,
+	})
+	if err != nil {
+		return err
+	}
+
+	rec := &recorder{w: w}
+	collector, err := opts.newCollector(fs.Args(), godebug.WithLineHook(rec.write))
+	if err != nil {
+		rec.close()
+		return err
+	}
+
+	sources := []source{{c: collector}}
+	summary.attach(sources)
+	err = runSources(sources, nil)
+	if cerr := rec.close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Session recorded to", path)
+
+	return summary.write(os.Stdout, sources)
+}
+
+// recorder writes output lines to a recording and keeps the first write error.
+type recorder struct {
+	mu  sync.Mutex
+	w   *recording.Writer
+	err error
+}
+
+// write appends the line to the recording. It is used as a collector line hook.
+func (r *recorder) write(line domain.OutputLine) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	if err := r.w.Write(line); err != nil {
+		r.err = fmt.Errorf("failed to write recording: %w", err)
+	}
+}
+
+// close finishes the recording and returns the first error that occurred.
+func (r *recorder) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.w.Close(); r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+	"github.com/JustSkiv/goschedviz/internal/recording"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	w, err := recording.Create(path, recording.Header{Target: "main.go", Period: 100})
+	require.NoError(t, err)
+
+	rec := &recorder{w: w}
+	rec.write(domain.OutputLine{Time: time.Now(), Stream: "stderr", Text: "SCHED 0ms: gomaxprocs=1"})
+	rec.write(domain.OutputLine{Time: time.Now(), Stream: "stderr", Text: "app log line"})
+	require.NoError(t, rec.close())
+
+	loaded, err := recording.Open(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Lines, 2)
+	assert.Equal(t, "app log line", loaded.Lines[1].Text)
+}
+
+func TestRunRecord_RequiresTarget(t *testing.T) {
+	assert.Error(t, runRecord([]string{"-o", filepath.Join(t.TempDir(), "x.jsonl")}))
+	assert.Error(t, runRecord([]string{"-follow", "app.log"}))
+}
+
+func TestRunReplay_Errors(t *testing.T) {
+	assert.Error(t, runReplay([]string{filepath.Join(t.TempDir(), "missing.jsonl")}))
+}
+
+func TestRunReport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.jsonl")
+	w, err := recording.Create(path, recording.Header{Target: "main.go", Period: 100})
+	require.NoError(t, err)
+	start := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		require.NoError(t, w.Write(domain.OutputLine{
+			Time:   start.Add(time.Duration(i) * 100 * time.Millisecond),
+			Stream: "stderr",
+			Text:   fmt.Sprintf("SCHED %dms: gomaxprocs=2 idleprocs=1 threads=4 spinningthreads=0 needspinning=0 idlethreads=1 runqueue=%d [1 0]", (i+1)*100, i),
+		}))
+	}
+	require.NoError(t, w.Close())
+
+	require.NoError(t, runReport([]string{"-summary-threshold", "runqueue=2", path}))
+	html, err := os.ReadFile(filepath.Join(dir, "session.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "<tr><td>runqueue</td><td>&gt; 2</td>")
+
+	output := filepath.Join(dir, "custom.html")
+	require.NoError(t, runReport([]string{"-o", output, path}))
+	assert.FileExists(t, output)
+}
+
+func TestRunReport_Errors(t *testing.T) {
+	assert.Error(t, runReport(nil))
+	assert.Error(t, runReport([]string{filepath.Join(t.TempDir(), "missing.jsonl")}))
+
+	// A recording without trace lines
+	path := filepath.Join(t.TempDir(), "empty.jsonl")
+	w, err := recording.Create(path, recording.Header{Target: "main.go"})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(domain.OutputLine{Time: time.Now(), Stream: "stderr", Text: "hello"}))
+	require.NoError(t, w.Close())
+	assert.ErrorContains(t, runReport([]string{path}), "no scheduler snapshots")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/collector/replay"
+	"github.com/JustSkiv/goschedviz/internal/recording"
+)
+
+// seekStep defines how far arrow keys move replay position.
+const seekStep = 10 * time.Second
+
+// runReplay plays back a recorded session in the terminal UI.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := fs.Float64("speed", 1, "Playback speed multiplier")
+	paused := fs.Bool("paused", false, "Start playback paused")
+	alerts := fs.String("alerts", "", "File with alert rules evaluated against every snapshot")
+	var check validationFlags
+	check.register(fs)
+	var summary summaryOptions
+	summary.register(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goschedviz replay [flags] <file>\n\n")
+		fmt.Fprintf(fs.Output(), "Controls: space - pause/resume, left/right - seek %s, +/- - speed, home - restart\n\nFlags:\n", seekStep)
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("replay requires exactly one recording file")
+	}
+
+	validation, err := check.validation()
+	if err != nil {
+		return err
+	}
+
+	rec, err := recording.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	player := replay.New(rec, *speed, replay.WithValidation(validation))
+	if *paused {
+		player.TogglePause()
+	}
+
+	sources := []source{{c: player}}
+	if err := attachAlerts(*alerts, sources); err != nil {
+		return err
+	}
+	summary.attach(sources)
+
+	if err := runSources(sources, replayBindings(player)); err != nil {
+		return err
+	}
+	return summary.write(os.Stdout, sources)
+}
+
+// replayBindings maps keys to playback controls.
+func replayBindings(p *replay.Player) map[string]func() {
+	faster := func() { p.SetSpeed(p.Speed() * 2) }
+	return map[string]func(){
+		"<Space>": p.TogglePause,
+		"<Right>": func() { p.Seek(seekStep) },
+		"<Left>":  func() { p.Seek(-seekStep) },
+		"<Home>":  p.SeekStart,
+		"+":       faster,
+		"=":       faster,
+		"-":       func() { p.SetSpeed(p.Speed() / 2) },
+	}
+}
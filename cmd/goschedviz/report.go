@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/collector/replay"
+	"github.com/JustSkiv/goschedviz/internal/domain"
+	"github.com/JustSkiv/goschedviz/internal/recording"
+	"github.com/JustSkiv/goschedviz/internal/report"
+)
+
+// runReport renders a recording as a self-contained HTML page.
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	output := fs.String("o", "", "HTML file to write (default the recording name with .html)")
+	warmup := fs.Duration("warmup", 0, "Leave snapshots of the first duration out of the summary tables")
+	var thresholds thresholdFlags
+	fs.Var(&thresholds, "summary-threshold", "Measure time a field stays above a value, e.g. runqueue=10 (repeatable, default runqueue=0 and idleprocs=0)")
+	var check validationFlags
+	check.register(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: goschedviz report [-o <file>] [flags] <recording>\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("report requires exactly one recording file")
+	}
+
+	validation, err := check.validation()
+	if err != nil {
+		return err
+	}
+
+	rec, err := recording.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	snapshots := replay.Snapshots(rec, replay.WithValidation(validation))
+	if len(snapshots) == 0 {
+		return fmt.Errorf("recording contains no scheduler snapshots")
+	}
+
+	summarizer := domain.NewSummarizer(*warmup, thresholds.orDefault())
+	for _, s := range snapshots {
+		summarizer.Add(s)
+	}
+
+	path := *output
+	if path == "" {
+		path = strings.TrimSuffix(fs.Arg(0), filepath.Ext(fs.Arg(0))) + ".html"
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	err = report.Write(f, report.Data{
+		Header:    rec.Header,
+		Snapshots: snapshots,
+		Summary:   summarizer.Summary(),
+		Generated: time.Now(),
+	})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	fmt.Println("Report written to", path)
+	return nil
+}
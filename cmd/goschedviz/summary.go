@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// thresholdFlags collects repeatable -summary-threshold field=value flags.
+type thresholdFlags []domain.Threshold
+
+func (t *thresholdFlags) String() string {
+	parts := make([]string, len(*t))
+	for i, threshold := range *t {
+		parts[i] = threshold.Field + "=" + formatValue(threshold.Value)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (t *thresholdFlags) Set(value string) error {
+	threshold, err := domain.ParseThreshold(value)
+	if err != nil {
+		return err
+	}
+	*t = append(*t, threshold)
+	return nil
+}
+
+// orDefault returns the thresholds, or domain.DefaultSummaryThresholds if none are set.
+func (t thresholdFlags) orDefault() []domain.Threshold {
+	if len(t) == 0 {
+		return domain.DefaultSummaryThresholds
+	}
+	return t
+}
+
+// summaryOptions holds flags of the end-of-session summary.
+type summaryOptions struct {
+	report     string
+	warmup     time.Duration
+	thresholds thresholdFlags
+}
+
+// register defines summary flags in the flag set.
+func (o *summaryOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.report, "report", "", "Write the session summary to a file instead of the terminal: .json for JSON, Markdown otherwise")
+	fs.DurationVar(&o.warmup, "warmup", 0, "Leave snapshots of the first duration out of the session summary")
+	fs.Var(&o.thresholds, "summary-threshold", "Measure time a field stays above a value in the summary, e.g. runqueue=10 (repeatable, default runqueue=0 and idleprocs=0)")
+}
+
+// attach gives every source its own summarizer.
+func (o *summaryOptions) attach(sources []source) {
+	for i := range sources {
+		sources[i].summary = domain.NewSummarizer(o.warmup, o.thresholds.orDefault())
+	}
+}
+
+// write prints summaries of sources that received snapshots to w,
+// or writes them to the report file.
+func (o *summaryOptions) write(w io.Writer, sources []source) error {
+	var summaries []sourceSummary
+	for i, s := range sources {
+		if s.summary == nil {
+			continue
+		}
+		summary := sourceSummary{Source: s.name, Summary: s.summary.Summary()}
+		if summary.Source == "" {
+			summary.Source = fmt.Sprintf("#%d", i+1)
+		}
+		if summary.Snapshots > 0 {
+			summaries = append(summaries, summary)
+		}
+	}
+	if o.report == "" {
+		if len(summaries) == 0 {
+			return nil
+		}
+		return writeSummaryMarkdown(w, summaries)
+	}
+
+	f, err := os.Create(o.report)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	if strings.EqualFold(filepath.Ext(o.report), ".json") {
+		err = writeSummaryJSON(f, summaries)
+	} else {
+		err = writeSummaryMarkdown(f, summaries)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	fmt.Fprintln(w, "Session summary written to", o.report)
+	return nil
+}
+
+// sourceSummary is the summary of a single source.
+type sourceSummary struct {
+	Source string `json:"source"`
+	domain.Summary
+}
+
+// writeSummaryJSON writes summaries as a JSON array.
+func writeSummaryJSON(w io.Writer, summaries []sourceSummary) error {
+	if summaries == nil {
+		summaries = []sourceSummary{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(summaries)
+}
+
+// writeSummaryMarkdown writes summaries as Markdown with aligned tables,
+// so they read well both in the terminal and pasted into documents.
+func writeSummaryMarkdown(w io.Writer, summaries []sourceSummary) error {
+	var b strings.Builder
+	for i, s := range summaries {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "## Session summary: %s\n\n", s.Source)
+		fmt.Fprintf(&b, "%d snapshots over %s", s.Snapshots, seconds(s.Duration).Round(time.Millisecond))
+		if s.Warmup > 0 {
+			fmt.Fprintf(&b, ", %d snapshots of %s warm-up excluded", s.Excluded, seconds(s.Warmup))
+		}
+		b.WriteString(".\n\n")
+
+		rows := [][]string{statsHeader("Field")}
+		for _, f := range s.Fields {
+			rows = append(rows, statsRow(f.Field, f.Stats))
+		}
+		writeTable(&b, rows)
+
+		if len(s.Thresholds) > 0 {
+			b.WriteString("\n### Time above thresholds\n\n")
+			rows := [][]string{{"Field", "Threshold", "Time", "Share"}}
+			for _, t := range s.Thresholds {
+				rows = append(rows, []string{
+					t.Field,
+					"> " + formatValue(t.Threshold),
+					seconds(t.Above).Round(time.Millisecond).String(),
+					fmt.Sprintf("%.1f%%", t.Share*100),
+				})
+			}
+			writeTable(&b, rows)
+		}
+
+		if s.Imbalance != nil {
+			b.WriteString("\n### Local run queue imbalance\n\n")
+			fmt.Fprintf(&b, "Spread between the longest and the shortest LRQ: mean %.2f, p95 %s, max %s. ",
+				s.Imbalance.Spread.Mean, formatValue(s.Imbalance.Spread.P95), formatValue(s.Imbalance.Spread.Max))
+			fmt.Fprintf(&b, "Spread of 2 or more in %.1f%% of snapshots.\n\n", s.Imbalance.Imbalanced*100)
+			rows := [][]string{statsHeader("P")}
+			for _, p := range s.Imbalance.Procs {
+				rows = append(rows, statsRow(strconv.Itoa(p.P), p.Stats))
+			}
+			writeTable(&b, rows)
+		}
+
+		if s.Leak.Suspected {
+			fmt.Fprintf(&b, "\n**Goroutine leak suspected:** +%d goroutines in %s (%.1f/s, %.0f%% confidence).\n",
+				s.Leak.Growth(), seconds(s.Leak.Window).Round(time.Second), s.Leak.Slope, s.Leak.Confidence*100)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// statsHeader returns the header of a statistics table.
+func statsHeader(name string) []string {
+	return []string{name, "Min", "Max", "Mean", "p50", "p95", "p99"}
+}
+
+// statsRow returns a row of a statistics table.
+func statsRow(name string, s domain.Stats) []string {
+	return []string{
+		name,
+		formatValue(s.Min),
+		formatValue(s.Max),
+		strconv.FormatFloat(s.Mean, 'f', 2, 64),
+		formatValue(s.P50),
+		formatValue(s.P95),
+		formatValue(s.P99),
+	}
+}
+
+// formatValue formats a value without trailing zeros.
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// writeTable writes a Markdown table with padded columns, the first row is the header.
+// Columns after the first one are right-aligned.
+func writeTable(b *strings.Builder, rows [][]string) {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell), 3)
+		}
+	}
+
+	line := func(row []string) {
+		b.WriteString("|")
+		for i, cell := range row {
+			if i == 0 {
+				fmt.Fprintf(b, " %-*s |", widths[i], cell)
+			} else {
+				fmt.Fprintf(b, " %*s |", widths[i], cell)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	line(rows[0])
+	b.WriteString("|")
+	for i, width := range widths {
+		if i == 0 {
+			b.WriteString(" " + strings.Repeat("-", width) + " |")
+		} else {
+			b.WriteString(" " + strings.Repeat("-", width-1) + ": |")
+		}
+	}
+	b.WriteString("\n")
+	for _, row := range rows[1:] {
+		line(row)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+func TestSummaryOptions_Flags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var opts summaryOptions
+	opts.register(fs)
+	require.NoError(t, fs.Parse([]string{"-warmup", "5s", "-summary-threshold", "runqueue=10", "-summary-threshold", "threads=20"}))
+	assert.Equal(t, 5*time.Second, opts.warmup)
+	assert.Equal(t, "runqueue=10,threads=20", opts.thresholds.String())
+
+	assert.Error(t, fs.Parse([]string{"-summary-threshold", "grq=10"}))
+}
+
+// summarizedSources returns two sources, the first one with a few snapshots summarized.
+func summarizedSources(opts *summaryOptions) []source {
+	sources := []source{{name: "main.go"}, {name: "idle.go"}}
+	opts.attach(sources)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, grq := range []int{0, 12, 4, 0} {
+		sources[0].summary.Add(domain.SchedulerSnapshot{
+			Received:   start.Add(time.Duration(i) * time.Second),
+			GoMaxProcs: 2,
+			IdleProcs:  1,
+			Threads:    5,
+			RunQueue:   grq,
+			LRQ:        []int{i, 0},
+		})
+	}
+	return sources
+}
+
+func TestSummaryOptions_Write(t *testing.T) {
+	var opts summaryOptions
+	var out bytes.Buffer
+	require.NoError(t, opts.write(&out, summarizedSources(&opts)))
+
+	text := out.String()
+	assert.Contains(t, text, "## Session summary: main.go\n\n4 snapshots over 3s.\n")
+	assert.NotContains(t, text, "idle.go", "Sources without snapshots are left out")
+	assert.Contains(t, text, "| Field           | Min | Max | Mean | p50 | p95 | p99 |\n")
+	assert.Contains(t, text, "| runqueue        |   0 |  12 | 4.00 |   0 |  12 |  12 |\n")
+	assert.Contains(t, text, "| runqueue  |       > 0 |   2s |  66.7% |\n", "Default thresholds are used")
+	assert.Contains(t, text, "| idleprocs |       > 0 |   3s | 100.0% |\n")
+	assert.Contains(t, text, "Spread of 2 or more in 50.0% of snapshots.")
+	assert.Contains(t, text, "| 0   |   0 |   3 | 1.50 |   1 |   3 |   3 |\n")
+
+	out.Reset()
+	require.NoError(t, opts.write(&out, []source{{c: &MockCollector{}}}))
+	assert.Empty(t, out.String(), "Nothing is printed without summaries")
+}
+
+func TestSummaryOptions_Report(t *testing.T) {
+	dir := t.TempDir()
+	opts := summaryOptions{
+		report:     filepath.Join(dir, "summary.json"),
+		warmup:     time.Second,
+		thresholds: thresholdFlags{{Field: "runqueue", Value: 10}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, opts.write(&out, summarizedSources(&opts)))
+	assert.Equal(t, "Session summary written to "+opts.report+"\n", out.String())
+
+	data, err := os.ReadFile(opts.report)
+	require.NoError(t, err)
+	var summaries []sourceSummary
+	require.NoError(t, json.Unmarshal(data, &summaries))
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "main.go", summaries[0].Source)
+	assert.Equal(t, 3, summaries[0].Snapshots)
+	assert.Equal(t, 1, summaries[0].Excluded)
+	require.Len(t, summaries[0].Thresholds, 1)
+	assert.Equal(t, 1.0, summaries[0].Thresholds[0].Above)
+	assert.Contains(t, string(data), `"warmup": 1,`, "Durations are written in seconds")
+
+	opts.report = filepath.Join(dir, "summary.md")
+	require.NoError(t, opts.write(&out, summarizedSources(&opts)))
+	data, err = os.ReadFile(opts.report)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "3 snapshots over 2s, 1 snapshots of 1s warm-up excluded.")
+}
+
+func TestMonitorSources_Summary(t *testing.T) {
+	mockCollector := &MockCollector{snapshots: make(chan domain.SchedulerSnapshot)}
+	sources := []source{{c: mockCollector}}
+	var opts summaryOptions
+	opts.attach(sources)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	go func() {
+		mockCollector.snapshots <- domain.SchedulerSnapshot{GoMaxProcs: 1, RunQueue: 3, LRQ: []int{0}}
+		mockCollector.snapshots <- domain.SchedulerSnapshot{TimeMs: 1000, GoMaxProcs: 1, RunQueue: 5, LRQ: []int{0}}
+	}()
+	mockPresenter := &MockPresenter{done: make(chan struct{})}
+	require.NoError(t, monitorSources(ctx, sources, mockPresenter, newTabSelector(1)))
+
+	summary := sources[0].summary.Summary()
+	assert.Equal(t, 2, summary.Snapshots)
+	assert.Equal(t, 1.0, summary.Duration)
+}
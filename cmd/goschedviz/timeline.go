@@ -0,0 +1,141 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// defaultSampleStep is assumed between snapshots when their times can't be compared,
+// e.g. when a source has a single snapshot after a restart.
+const defaultSampleStep = time.Second
+
+// sampleTimes returns the wall-clock time of every history point.
+// Snapshots without receive time are dated back from the next point by
+// the difference of their TimeMs, the latest one by end. A restarted program
+// starts its clock over, so the last known step is used across restarts.
+func sampleTimes(history []domain.SchedulerSnapshot, end time.Time) []time.Time {
+	times := make([]time.Time, len(history))
+	if len(history) == 0 {
+		return times
+	}
+
+	step := defaultSampleStep
+	for k := len(history) - 1; k > 0; k-- {
+		if d := sampleStep(history[k-1], history[k]); d > 0 {
+			step = d
+			break
+		}
+	}
+
+	times[len(times)-1] = history[len(history)-1].Received
+	if times[len(times)-1].IsZero() {
+		times[len(times)-1] = end
+	}
+	for k := len(history) - 2; k >= 0; k-- {
+		if d := sampleStep(history[k], history[k+1]); d > 0 {
+			step = d
+		}
+		times[k] = history[k].Received
+		if times[k].IsZero() {
+			times[k] = times[k+1].Add(-step)
+		}
+	}
+	return times
+}
+
+// sampleStep returns time between two consecutive snapshots of the same run, 0 if unknown.
+func sampleStep(prev, next domain.SchedulerSnapshot) time.Duration {
+	if next.Marker != domain.MarkerNone {
+		return 0
+	}
+	return time.Duration(next.TimeMs-prev.TimeMs) * time.Millisecond
+}
+
+// alignHistories resamples histories of several sources to the same number of points
+// covering the same time window, which ends at the latest received snapshot or at end
+// when snapshots carry no receive time. Every point holds the latest snapshot taken
+// by then, points before the first snapshot of a source are zero.
+// GC cycles and restarts are attributed to the nearest point.
+func alignHistories(histories [][]domain.SchedulerSnapshot, end time.Time, points int) ([][]ui.HistoricalValues, time.Duration) {
+	var latest time.Time
+	for _, history := range histories {
+		if len(history) > 0 && history[len(history)-1].Received.After(latest) {
+			latest = history[len(history)-1].Received
+		}
+	}
+	if !latest.IsZero() {
+		end = latest
+	}
+
+	times := make([][]time.Time, len(histories))
+	start := end
+	for i, history := range histories {
+		times[i] = sampleTimes(history, end)
+		if len(times[i]) > 0 && times[i][0].Before(start) {
+			start = times[i][0]
+		}
+	}
+	window := end.Sub(start)
+
+	aligned := make([][]ui.HistoricalValues, len(histories))
+	if window <= 0 || points < 2 {
+		return aligned, 0
+	}
+	width := window / time.Duration(points-1)
+
+	for i, history := range histories {
+		values := make([]ui.HistoricalValues, points)
+		k := -1 // latest snapshot taken by the current point
+		for j := range values {
+			at := start.Add(time.Duration(j) * width)
+			if j == points-1 {
+				at = end
+			}
+			for k+1 < len(history) && !times[i][k+1].After(at) {
+				k++
+			}
+			if k >= 0 {
+				values[j] = historicalValues(history[k])
+				values[j].GCCycles, values[j].Restarted, values[j].Rebuilt = 0, false, false
+			}
+		}
+
+		for k, h := range history {
+			j := int(math.Round(float64(times[i][k].Sub(start)) / float64(width)))
+			j = min(max(j, 0), points-1)
+			values[j].GCCycles += domain.CountGC(h.GC)
+			values[j].Restarted = values[j].Restarted || h.Marker == domain.MarkerRestart
+			values[j].Rebuilt = values[j].Rebuilt || h.Marker == domain.MarkerRebuild
+		}
+		aligned[i] = values
+	}
+
+	return aligned, window
+}
+
+// convertSplit converts states of all sources to UI format on a shared time axis.
+func convertSplit(sources []source, names []string, states []*domain.MonitorState, now time.Time) ([]ui.SourceHistory, time.Duration) {
+	latest := make([]domain.SchedulerSnapshot, len(states))
+	histories := make([][]domain.SchedulerSnapshot, len(states))
+	for i, s := range states {
+		latest[i], histories[i] = s.GetSnapshot()
+	}
+
+	aligned, window := alignHistories(histories, now, domain.MaxHistoryPoints)
+
+	result := make([]ui.SourceHistory, len(states))
+	for i := range states {
+		result[i] = ui.SourceHistory{
+			Name:    names[i],
+			Current: convertToUIData(latest[i], nil).Current,
+			History: aligned[i],
+		}
+		if er, ok := sources[i].c.(exitReporter); ok {
+			_, result[i].Exited = er.Exit()
+		}
+	}
+	return result, window
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+func TestSampleTimes(t *testing.T) {
+	end := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	// Without receive time points are stepped back by TimeMs
+	history := []domain.SchedulerSnapshot{
+		{TimeMs: 1000},
+		{TimeMs: 2000},
+		{TimeMs: 300, Marker: domain.MarkerRestart},
+		{TimeMs: 800},
+	}
+	assert.Equal(t, []time.Time{
+		end.Add(-2000 * time.Millisecond),
+		end.Add(-1000 * time.Millisecond), // Step across the restart is unknown, the previous one is used
+		end.Add(-500 * time.Millisecond),
+		end,
+	}, sampleTimes(history, end))
+
+	// Receive time is used as is
+	history = []domain.SchedulerSnapshot{
+		{TimeMs: 1000, Received: end.Add(-3 * time.Second)},
+		{TimeMs: 100, Received: end.Add(-time.Second), Marker: domain.MarkerRestart},
+	}
+	assert.Equal(t, []time.Time{end.Add(-3 * time.Second), end.Add(-time.Second)}, sampleTimes(history, end))
+
+	assert.Empty(t, sampleTimes(nil, end))
+}
+
+func TestAlignHistories(t *testing.T) {
+	start := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+
+	// Producer runs for 4 seconds, consumer was started 2 seconds later
+	producer := []domain.SchedulerSnapshot{
+		{TimeMs: 1000, Goroutines: 1, Received: at(0)},
+		{TimeMs: 2000, Goroutines: 2, Received: at(1)},
+		{TimeMs: 3000, Goroutines: 3, Received: at(2), GC: []domain.GCCycle{{Number: 1}}},
+		{TimeMs: 4000, Goroutines: 4, Received: at(3)},
+		{TimeMs: 5000, Goroutines: 5, Received: at(4)},
+	}
+	consumer := []domain.SchedulerSnapshot{
+		{TimeMs: 1000, Goroutines: 10, Received: at(2)},
+		{TimeMs: 2000, Goroutines: 20, Received: at(3)},
+		{TimeMs: 100, Goroutines: 30, Received: at(4), Marker: domain.MarkerRestart},
+	}
+
+	aligned, window := alignHistories([][]domain.SchedulerSnapshot{producer, consumer}, at(10), 5)
+	assert.Equal(t, 4*time.Second, window, "Window ends at the latest snapshot")
+	require.Len(t, aligned, 2)
+
+	goroutines := func(i int) []int {
+		var values []int
+		for _, v := range aligned[i] {
+			values = append(values, v.Goroutines)
+		}
+		return values
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, goroutines(0))
+	assert.Equal(t, []int{0, 0, 10, 20, 30}, goroutines(1), "Points before the first snapshot are zero")
+
+	assert.Equal(t, 1, aligned[0][2].GCCycles)
+	assert.Equal(t, 0, aligned[0][3].GCCycles, "GC cycle is attributed to a single point")
+	assert.True(t, aligned[1][4].Restarted)
+	assert.False(t, aligned[1][3].Restarted)
+}
+
+func TestAlignHistories_Stale(t *testing.T) {
+	start := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	// The exited program keeps its last value until the end
+	running := []domain.SchedulerSnapshot{
+		{Goroutines: 1, Received: start},
+		{Goroutines: 3, Received: start.Add(2 * time.Second)},
+	}
+	exited := []domain.SchedulerSnapshot{{Goroutines: 7, Received: start.Add(time.Second)}}
+
+	aligned, window := alignHistories([][]domain.SchedulerSnapshot{running, exited}, time.Time{}, 3)
+	assert.Equal(t, 2*time.Second, window)
+	assert.Equal(t, []int{1, 1, 3}, []int{aligned[0][0].Goroutines, aligned[0][1].Goroutines, aligned[0][2].Goroutines})
+	assert.Equal(t, []int{0, 7, 7}, []int{aligned[1][0].Goroutines, aligned[1][1].Goroutines, aligned[1][2].Goroutines})
+}
@@ -0,0 +1,253 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/collector"
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// clientBuffer is the number of live messages queued for a viewer.
+// Viewers that fall further behind are disconnected rather than slowing down the agent.
+const clientBuffer = 256
+
+// statePollInterval is how often collector state and output are checked for changes.
+const statePollInterval = 500 * time.Millisecond
+
+// statusReporter is implemented by collectors that can describe their own state.
+type statusReporter interface {
+	Status() string
+}
+
+// exitReporter is implemented by collectors that start the monitored program.
+type exitReporter interface {
+	Exit() (domain.ExitStatus, bool)
+}
+
+// logSource is implemented by collectors that keep output of the monitored program.
+type logSource interface {
+	Logs() []domain.LogEntry
+}
+
+// diagnosticsSource is implemented by collectors that parse trace output.
+type diagnosticsSource interface {
+	Diagnostics() domain.ParseReport
+}
+
+// Agent runs a collector and streams its snapshots to connected viewers.
+type Agent struct {
+	collector collector.Collector
+	hello     Hello
+	history   domain.MonitorState // replayed to new viewers
+
+	mu      sync.Mutex // guards fields below and message order
+	clients map[*client]struct{}
+	state   State
+	logSeq  uint64 // last output line sent to viewers
+}
+
+// client is a connected viewer.
+type client struct {
+	conn net.Conn
+	out  chan Message
+}
+
+// New creates an agent for the collector. Hello describes the monitored program,
+// its Version is filled in by the agent.
+func New(c collector.Collector, hello Hello) *Agent {
+	hello.Version = ProtocolVersion
+	return &Agent{
+		collector: c,
+		hello:     hello,
+		clients:   make(map[*client]struct{}),
+	}
+}
+
+// Serve starts the collector and streams its data to viewers connecting to ln
+// until ctx is cancelled. The listener is closed on return.
+func (a *Agent) Serve(ctx context.Context, ln net.Listener) error {
+	snapshots, err := a.collector.Start(ctx)
+	if err != nil {
+		ln.Close()
+		return err
+	}
+	defer a.collector.Stop()
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	go a.accept(ln)
+	defer a.disconnectAll()
+
+	ticker := time.NewTicker(statePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case snapshot, ok := <-snapshots:
+			if !ok {
+				// Viewers still get the final state and output of an exited program
+				snapshots = nil
+				continue
+			}
+			a.mu.Lock()
+			a.history.Update(snapshot)
+			a.send(Message{Type: TypeSnapshot, Snapshot: &snapshot})
+			a.mu.Unlock()
+		case <-ticker.C:
+			a.pollState()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// accept registers viewers until the listener is closed.
+func (a *Agent) accept(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		c := &client{conn: conn, out: make(chan Message, clientBuffer)}
+
+		// Catch-up messages are taken under the lock, so live updates follow without gaps
+		a.mu.Lock()
+		initial := a.catchUp()
+		a.clients[c] = struct{}{}
+		a.mu.Unlock()
+
+		go a.write(c, initial)
+	}
+}
+
+// catchUp returns messages that bring a new viewer up to date. Caller must hold a.mu.
+func (a *Agent) catchUp() []Message {
+	hello := a.hello
+	messages := []Message{{Type: TypeHello, Hello: &hello}}
+
+	_, history := a.history.GetSnapshot()
+	for i := range history {
+		messages = append(messages, Message{Type: TypeSnapshot, Snapshot: &history[i]})
+	}
+	if ls, ok := a.collector.(logSource); ok {
+		for _, entry := range ls.Logs() {
+			if entry.Seq > a.logSeq {
+				break
+			}
+			entry := entry
+			messages = append(messages, Message{Type: TypeLog, Log: &entry})
+		}
+	}
+	state := a.state
+	return append(messages, Message{Type: TypeState, State: &state})
+}
+
+// write sends initial messages and then live updates to the viewer until it disconnects.
+func (a *Agent) write(c *client, initial []Message) {
+	defer a.disconnect(c)
+
+	w := bufio.NewWriter(c.conn)
+	encoder := json.NewEncoder(w)
+	for _, m := range initial {
+		if err := encoder.Encode(m); err != nil {
+			return
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return
+	}
+
+	for m := range c.out {
+		if err := encoder.Encode(m); err != nil {
+			return
+		}
+		// Batch messages that are already queued
+		if len(c.out) == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// send queues the message for every viewer and drops viewers that can't keep up.
+// Caller must hold a.mu.
+func (a *Agent) send(m Message) {
+	for c := range a.clients {
+		select {
+		case c.out <- m:
+		default:
+			a.remove(c)
+		}
+	}
+}
+
+// pollState sends new output lines and changed collector state to viewers.
+func (a *Agent) pollState() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if ls, ok := a.collector.(logSource); ok {
+		for _, entry := range ls.Logs() {
+			if entry.Seq <= a.logSeq {
+				continue
+			}
+			a.logSeq = entry.Seq
+			entry := entry
+			a.send(Message{Type: TypeLog, Log: &entry})
+		}
+	}
+
+	var state State
+	if sr, ok := a.collector.(statusReporter); ok {
+		state.Status = sr.Status()
+	}
+	if er, ok := a.collector.(exitReporter); ok {
+		if status, exited := er.Exit(); exited {
+			state.Exit = &status
+		}
+	}
+	if ds, ok := a.collector.(diagnosticsSource); ok {
+		report := ds.Diagnostics()
+		state.Diagnostics = &report
+	}
+	if !reflect.DeepEqual(state, a.state) {
+		a.state = state
+		a.send(Message{Type: TypeState, State: &state})
+	}
+}
+
+// remove unregisters the viewer and stops its writer. Caller must hold a.mu.
+func (a *Agent) remove(c *client) {
+	if _, ok := a.clients[c]; !ok {
+		return
+	}
+	delete(a.clients, c)
+	close(c.out)
+	c.conn.Close()
+}
+
+// disconnect removes a viewer whose connection failed.
+func (a *Agent) disconnect(c *client) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.remove(c)
+}
+
+// disconnectAll closes all viewer connections.
+func (a *Agent) disconnectAll() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for c := range a.clients {
+		a.remove(c)
+	}
+}
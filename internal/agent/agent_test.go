@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// fakeCollector sends snapshots written to its channel and reports fixed state.
+type fakeCollector struct {
+	snapshots chan domain.SchedulerSnapshot
+
+	mu     sync.Mutex
+	logs   []domain.LogEntry
+	exited bool
+}
+
+func (f *fakeCollector) Start(ctx context.Context) (<-chan domain.SchedulerSnapshot, error) {
+	return f.snapshots, nil
+}
+
+func (f *fakeCollector) Stop() error { return nil }
+
+func (f *fakeCollector) Status() string { return "Restarts: 1" }
+
+func (f *fakeCollector) Logs() []domain.LogEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]domain.LogEntry(nil), f.logs...)
+}
+
+func (f *fakeCollector) Exit() (domain.ExitStatus, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return domain.ExitStatus{Code: 1}, f.exited
+}
+
+// startAgent serves the collector on a loopback port and returns its address.
+func startAgent(t *testing.T, c *fakeCollector) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		New(c, Hello{Target: "./cmd/api"}).Serve(ctx, ln)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return ln.Addr().String()
+}
+
+// viewer reads messages from an agent connection.
+type viewer struct {
+	t       *testing.T
+	scanner *bufio.Scanner
+}
+
+func connect(t *testing.T, addr string) *viewer {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	return &viewer{t: t, scanner: bufio.NewScanner(conn)}
+}
+
+func (v *viewer) next() Message {
+	v.t.Helper()
+
+	require.True(v.t, v.scanner.Scan(), "Connection closed: %v", v.scanner.Err())
+	var m Message
+	require.NoError(v.t, json.Unmarshal(v.scanner.Bytes(), &m))
+	return m
+}
+
+func TestAgent_Serve(t *testing.T) {
+	c := &fakeCollector{snapshots: make(chan domain.SchedulerSnapshot)}
+	addr := startAgent(t, c)
+
+	first := connect(t, addr)
+	m := first.next()
+	require.Equal(t, TypeHello, m.Type)
+	assert.Equal(t, Hello{Version: ProtocolVersion, Target: "./cmd/api"}, *m.Hello)
+	m = first.next()
+	require.Equal(t, TypeState, m.Type, "Empty history is followed by the current state")
+
+	c.snapshots <- domain.SchedulerSnapshot{TimeMs: 100, GoMaxProcs: 2, LRQ: []int{1, 0}}
+	m = first.next()
+	require.Equal(t, TypeSnapshot, m.Type)
+	assert.Equal(t, 100, m.Snapshot.TimeMs)
+	assert.Equal(t, []int{1, 0}, m.Snapshot.LRQ)
+
+	// State and output changes are sent when noticed
+	c.mu.Lock()
+	c.logs = []domain.LogEntry{{Seq: 1, OutputLine: domain.OutputLine{Stream: "stdout", Text: "started"}}}
+	c.exited = true
+	c.mu.Unlock()
+
+	m = first.next()
+	require.Equal(t, TypeLog, m.Type)
+	assert.Equal(t, "started", m.Log.Text)
+	m = first.next()
+	require.Equal(t, TypeState, m.Type)
+	assert.Equal(t, "Restarts: 1", m.State.Status)
+	assert.Equal(t, &domain.ExitStatus{Code: 1}, m.State.Exit)
+
+	// A late viewer catches up with history, output and state
+	second := connect(t, addr)
+	var types []string
+	for range 4 {
+		types = append(types, second.next().Type)
+	}
+	assert.Equal(t, []string{TypeHello, TypeSnapshot, TypeLog, TypeState}, types)
+}
+
+func TestAgent_SlowViewer(t *testing.T) {
+	c := &fakeCollector{snapshots: make(chan domain.SchedulerSnapshot)}
+	addr := startAgent(t, c)
+
+	// Viewer that never reads doesn't block the collector
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20000; i++ {
+			c.snapshots <- domain.SchedulerSnapshot{TimeMs: i, LRQ: make([]int, 64)}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Agent is blocked by a slow viewer")
+	}
+}
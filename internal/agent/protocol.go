@@ -0,0 +1,55 @@
+// Package agent serves scheduler snapshots of a monitored program over TCP,
+// so the terminal UI can run on another machine.
+//
+// The stream is JSON Lines, one Message per line. The agent starts every
+// connection with a hello, then replays recent history, program output and
+// state, and continues with live updates:
+//
+//	{"type":"hello","hello":{"version":1,"target":"./cmd/api","hostname":"load-1",...}}
+//	{"type":"snapshot","snapshot":{"TimeMs":1001,"GoMaxProcs":8,...,"Received":"2025-01-02T15:04:06.001Z","Seq":1,"Source":"./cmd/api"}}
+//	{"type":"log","log":{"Seq":1,"Time":"2025-01-02T15:04:06.12Z","Stream":"stdout","Text":"listening on :8080"}}
+//	{"type":"state","state":{"status":"Restarts: 1"}}
+package agent
+
+import (
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// ProtocolVersion is the current version of the stream format.
+const ProtocolVersion = 1
+
+// Message types.
+const (
+	TypeHello    = "hello"
+	TypeSnapshot = "snapshot"
+	TypeLog      = "log"
+	TypeState    = "state"
+)
+
+// Message is a single line of the stream. Only the field matching Type is set.
+type Message struct {
+	Type     string                    `json:"type"`
+	Hello    *Hello                    `json:"hello,omitempty"`
+	Snapshot *domain.SchedulerSnapshot `json:"snapshot,omitempty"`
+	Log      *domain.LogEntry          `json:"log,omitempty"`
+	State    *State                    `json:"state,omitempty"`
+}
+
+// Hello describes the agent and the monitored program.
+type Hello struct {
+	Version  int       `json:"version"`
+	Started  time.Time `json:"started"` // Agent start time, changes when the agent is restarted
+	Target   string    `json:"target"`
+	Hostname string    `json:"hostname,omitempty"`
+}
+
+// State is the state of the collector that isn't carried by snapshots.
+type State struct {
+	Status string             `json:"status,omitempty"` // Collector status line
+	Exit   *domain.ExitStatus `json:"exit,omitempty"`   // How the program ended, nil while it's running
+
+	// Diagnostics tells how well output of the program is parsed, nil if the collector doesn't parse it
+	Diagnostics *domain.ParseReport `json:"diagnostics,omitempty"`
+}
@@ -0,0 +1,124 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// actionTimeout limits how long an exec or webhook action may run.
+const actionTimeout = 10 * time.Second
+
+// run runs actions of the rule for the event. Exec and webhook actions run in the background.
+func (e *Engine) run(rule Rule, event Event) {
+	for _, a := range rule.Actions {
+		switch a.Kind {
+		case ActionBell:
+			if event.State == StateFiring {
+				_, err := e.bell.Write([]byte("\a"))
+				e.finish(err)
+			}
+		case ActionLog:
+			e.finish(appendLog(a.Target, event))
+		case ActionExec:
+			e.background(func() error { return runCommand(a.Target, event) })
+		case ActionWebhook:
+			e.background(func() error { return e.post(a.Target, event) })
+		}
+	}
+}
+
+// background runs an action in a separate goroutine.
+func (e *Engine) background(action func() error) {
+	e.actions.Add(1)
+	go func() {
+		defer e.actions.Done()
+		e.finish(action())
+	}()
+}
+
+// formatEvent formats an event as a single log line:
+//
+//	2025-01-02T15:04:05.000Z firing busy: grq > 100 (grq=120)
+func formatEvent(event Event) string {
+	names := make([]string, 0, len(event.Values))
+	for name := range event.Values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = name + "=" + strconv.FormatFloat(event.Values[name], 'g', -1, 64)
+	}
+
+	line := fmt.Sprintf("%s %s %s", event.Time.Format("2006-01-02T15:04:05.000Z07:00"), event.State, event.Rule)
+	if event.Rule != event.Condition {
+		line += ": " + event.Condition
+	}
+	if event.Source != "" {
+		line += " [" + event.Source + "]"
+	}
+	return line + " (" + strings.Join(values, " ") + ")"
+}
+
+// appendLog appends the event to the alert log file.
+func appendLog(path string, event Event) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("alert log: %w", err)
+	}
+	if _, err := fmt.Fprintln(f, formatEvent(event)); err != nil {
+		f.Close()
+		return fmt.Errorf("alert log: %w", err)
+	}
+	return f.Close()
+}
+
+// runCommand runs a shell command with the event in GOSCHEDVIZ_ALERT* environment variables.
+// Its output is discarded, it would break the terminal UI.
+func runCommand(command string, event Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(),
+		"GOSCHEDVIZ_ALERT="+event.Rule,
+		"GOSCHEDVIZ_ALERT_STATE="+string(event.State),
+		"GOSCHEDVIZ_ALERT_SOURCE="+event.Source,
+		"GOSCHEDVIZ_ALERT_EVENT="+formatEvent(event),
+	)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("alert command %q: %w", command, err)
+	}
+	return nil
+}
+
+// post sends the event to a webhook as JSON.
+func (e *Engine) post(url string, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("alert webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("alert webhook %s: %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package alert
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// State tells whether a rule started or stopped firing.
+type State string
+
+// Event states
+const (
+	StateFiring   State = "firing"
+	StateResolved State = "resolved"
+)
+
+// Event describes a rule starting or stopping to fire. It's posted to webhooks as JSON.
+type Event struct {
+	Rule      string    `json:"rule"`
+	Condition string    `json:"condition"`
+	State     State     `json:"state"`
+	Time      time.Time `json:"time"`
+	Source    string    `json:"source,omitempty"`
+	// Values holds variables of the condition at the time of the event
+	Values map[string]float64 `json:"values"`
+}
+
+// Firing is a rule that currently fires.
+type Firing struct {
+	Rule  Rule
+	Since time.Time // Time the condition started to hold
+}
+
+// ruleState tracks a single rule.
+type ruleState struct {
+	since  time.Time // Zero while the condition doesn't hold
+	firing bool
+}
+
+// Engine evaluates rules against snapshots of a single source and runs their actions.
+// It is safe for concurrent use.
+type Engine struct {
+	rules  []Rule
+	source string
+	bell   io.Writer
+	client *http.Client
+
+	mu      sync.Mutex
+	states  []ruleState
+	lastErr error
+
+	actions sync.WaitGroup // running exec and webhook actions
+}
+
+// Option configures an Engine.
+type Option func(*Engine)
+
+// WithSource names the source in events, e.g. the target path.
+func WithSource(source string) Option {
+	return func(e *Engine) {
+		e.source = source
+	}
+}
+
+// WithBell sets where the bell action writes the BEL character, os.Stdout by default.
+func WithBell(w io.Writer) Option {
+	return func(e *Engine) {
+		e.bell = w
+	}
+}
+
+// WithHTTPClient sets the client webhooks are posted with.
+func WithHTTPClient(c *http.Client) Option {
+	return func(e *Engine) {
+		e.client = c
+	}
+}
+
+// NewEngine creates an engine for the rules.
+func NewEngine(rules []Rule, opts ...Option) *Engine {
+	e := &Engine{
+		rules:  rules,
+		bell:   os.Stdout,
+		client: &http.Client{Timeout: actionTimeout},
+		states: make([]ruleState, len(rules)),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Evaluate checks every rule against the snapshot. A rule fires once its condition
+// has held for the rule's duration, measured by snapshot receive time, and is resolved
+// as soon as the condition doesn't hold. Actions run on both transitions,
+// bell and highlight only on firing. A reset snapshot, e.g. after seeking a replay,
+// starts over without resolving rules.
+func (e *Engine) Evaluate(s domain.SchedulerSnapshot) {
+	now := s.Received
+	if now.IsZero() {
+		now = time.Now()
+	}
+	values := snapshotValues(s)
+
+	var events []Event
+	var rules []Rule
+
+	e.mu.Lock()
+	if s.Marker == domain.MarkerReset {
+		clear(e.states)
+	}
+	for i, rule := range e.rules {
+		st := &e.states[i]
+		if !rule.expr.True(values) {
+			if st.firing {
+				events = append(events, e.event(rule, StateResolved, now, values))
+				rules = append(rules, rule)
+			}
+			*st = ruleState{}
+			continue
+		}
+
+		if st.since.IsZero() {
+			st.since = now
+		}
+		if !st.firing && now.Sub(st.since) >= rule.For {
+			st.firing = true
+			events = append(events, e.event(rule, StateFiring, now, values))
+			rules = append(rules, rule)
+		}
+	}
+	e.mu.Unlock()
+
+	for i, event := range events {
+		e.run(rules[i], event)
+	}
+}
+
+// event creates an event of the rule with values of its condition.
+func (e *Engine) event(rule Rule, state State, now time.Time, values Values) Event {
+	event := Event{
+		Rule:      rule.Name,
+		Condition: rule.Condition,
+		State:     state,
+		Time:      now,
+		Source:    e.source,
+		Values:    make(map[string]float64, len(rule.expr.Vars())),
+	}
+	for _, name := range rule.expr.Vars() {
+		event.Values[name] = values[name]
+	}
+	return event
+}
+
+// Firing returns rules that currently fire, in the order of the rules file.
+func (e *Engine) Firing() []Firing {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var firing []Firing
+	for i, st := range e.states {
+		if st.firing {
+			firing = append(firing, Firing{Rule: e.rules[i], Since: st.since})
+		}
+	}
+	return firing
+}
+
+// Err returns the error of the last finished action, nil if it succeeded.
+func (e *Engine) Err() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastErr
+}
+
+// Close waits for running exec and webhook actions.
+func (e *Engine) Close() {
+	e.actions.Wait()
+}
+
+// finish records the outcome of an action.
+func (e *Engine) finish(err error) {
+	e.mu.Lock()
+	e.lastErr = err
+	e.mu.Unlock()
+}
@@ -0,0 +1,145 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// grqSnapshot returns a snapshot with the GRQ length received at the given second.
+func grqSnapshot(start time.Time, second, grq int) domain.SchedulerSnapshot {
+	return domain.SchedulerSnapshot{
+		GoMaxProcs: 4,
+		RunQueue:   grq,
+		Received:   start.Add(time.Duration(second) * time.Second),
+	}
+}
+
+func mustParseRule(t *testing.T, line string) Rule {
+	t.Helper()
+	rule, err := ParseRule(line)
+	require.NoError(t, err)
+	return rule
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "alerts.log")
+	var bell bytes.Buffer
+	engine := NewEngine([]Rule{
+		mustParseRule(t, "busy: grq > 100 for 2s => highlight grq, bell, log "+logPath),
+		mustParseRule(t, "grq > 1000 => log "+logPath),
+	}, WithBell(&bell), WithSource("./app"))
+
+	start := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	engine.Evaluate(grqSnapshot(start, 0, 150))
+	engine.Evaluate(grqSnapshot(start, 1, 150))
+	assert.Empty(t, engine.Firing(), "Condition has held for 1s only")
+
+	engine.Evaluate(grqSnapshot(start, 2, 120))
+	firing := engine.Firing()
+	require.Len(t, firing, 1)
+	assert.Equal(t, "busy", firing[0].Rule.Name)
+	assert.Equal(t, start, firing[0].Since)
+	assert.Equal(t, "\a", bell.String())
+
+	engine.Evaluate(grqSnapshot(start, 3, 130))
+	assert.Equal(t, "\a", bell.String(), "Bell rings once per firing")
+
+	engine.Evaluate(grqSnapshot(start, 4, 10))
+	assert.Empty(t, engine.Firing())
+	engine.Evaluate(grqSnapshot(start, 5, 150))
+	assert.Empty(t, engine.Firing(), "Duration starts over")
+
+	engine.Close()
+	require.NoError(t, engine.Err())
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"2025-01-02T15:04:07.000Z firing busy: grq > 100 [./app] (grq=120)\n"+
+			"2025-01-02T15:04:09.000Z resolved busy: grq > 100 [./app] (grq=10)\n",
+		string(data))
+}
+
+func TestEngine_Reset(t *testing.T) {
+	engine := NewEngine([]Rule{mustParseRule(t, "grq > 100 for 1s")})
+
+	start := time.Now()
+	engine.Evaluate(grqSnapshot(start, 0, 150))
+	engine.Evaluate(grqSnapshot(start, 1, 150))
+	require.Len(t, engine.Firing(), 1)
+
+	reset := grqSnapshot(start, 2, 150)
+	reset.Marker = domain.MarkerReset
+	engine.Evaluate(reset)
+	assert.Empty(t, engine.Firing(), "Seeking a replay starts over")
+}
+
+func TestEngine_Webhook(t *testing.T) {
+	var mu sync.Mutex
+	var events []Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	engine := NewEngine([]Rule{mustParseRule(t, "threads > 4*gomaxprocs => webhook "+server.URL)})
+	engine.Evaluate(domain.SchedulerSnapshot{GoMaxProcs: 2, Threads: 9})
+	engine.Close()
+
+	require.NoError(t, engine.Err())
+	require.Len(t, events, 1)
+	assert.Equal(t, "threads > 4*gomaxprocs", events[0].Rule)
+	assert.Equal(t, StateFiring, events[0].State)
+	assert.Equal(t, map[string]float64{"threads": 9, "gomaxprocs": 2}, events[0].Values)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	engine = NewEngine([]Rule{mustParseRule(t, "grq > 1 => webhook "+failing.URL)})
+	engine.Evaluate(domain.SchedulerSnapshot{RunQueue: 2})
+	engine.Close()
+	assert.ErrorContains(t, engine.Err(), "500")
+}
+
+func TestEngine_Exec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+
+	out := filepath.Join(t.TempDir(), "out")
+	engine := NewEngine([]Rule{
+		mustParseRule(t, `busy: grq > 1 => exec echo "$GOSCHEDVIZ_ALERT $GOSCHEDVIZ_ALERT_STATE" >> `+out),
+	})
+	engine.Evaluate(domain.SchedulerSnapshot{RunQueue: 2})
+	engine.Close()
+	engine.Evaluate(domain.SchedulerSnapshot{RunQueue: 0})
+	engine.Close()
+
+	require.NoError(t, engine.Err())
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "busy firing\nbusy resolved\n", string(data))
+
+	engine = NewEngine([]Rule{mustParseRule(t, "grq > 1 => exec exit 3")})
+	engine.Evaluate(domain.SchedulerSnapshot{RunQueue: 2})
+	engine.Close()
+	assert.ErrorContains(t, engine.Err(), "exit status 3")
+}
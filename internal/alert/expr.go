@@ -0,0 +1,357 @@
+package alert
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// Variables lists names conditions can refer to, with the snapshot value each one stands for.
+// Names are case-insensitive, grq is an alias of runqueue.
+var Variables = []string{
+	"gomaxprocs",
+	"idleprocs",
+	"threads",
+	"spinningthreads",
+	"needspinning",
+	"idlethreads",
+	"runqueue",
+	"grq",
+	"lrqsum",
+	"lrqmax",
+	"goroutines",
+}
+
+// Values holds variables of a snapshot by name.
+type Values map[string]float64
+
+// snapshotValues returns all Variables of the snapshot.
+func snapshotValues(s domain.SchedulerSnapshot) Values {
+	lrqMax := 0
+	if len(s.LRQ) > 0 {
+		lrqMax = slices.Max(s.LRQ)
+	}
+	return Values{
+		"gomaxprocs":      float64(s.GoMaxProcs),
+		"idleprocs":       float64(s.IdleProcs),
+		"threads":         float64(s.Threads),
+		"spinningthreads": float64(s.SpinningThreads),
+		"needspinning":    float64(s.NeedSpinning),
+		"idlethreads":     float64(s.IdleThreads),
+		"runqueue":        float64(s.RunQueue),
+		"grq":             float64(s.RunQueue),
+		"lrqsum":          float64(s.LRQSum),
+		"lrqmax":          float64(lrqMax),
+		"goroutines":      float64(s.Goroutines),
+	}
+}
+
+// Expr is a parsed condition, e.g. "idleprocs == 0 and spinningthreads == 0".
+// Comparisons and logical operators give 1 for true and 0 for false.
+type Expr struct {
+	root node
+	vars []string // Referred variables in order of appearance
+}
+
+// ParseExpr parses a condition. It supports numbers, Variables, arithmetic (+ - * /),
+// comparisons (> >= < <= == !=), logical operators (and, or, not or &&, ||, !) and parentheses.
+func ParseExpr(s string) (*Expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return &Expr{root: root, vars: p.vars}, nil
+}
+
+// Eval evaluates the expression with the values.
+func (e *Expr) Eval(values Values) float64 {
+	return e.root.eval(values)
+}
+
+// True reports whether the condition holds for the values.
+func (e *Expr) True(values Values) bool {
+	return e.Eval(values) != 0
+}
+
+// Vars returns variables the expression refers to.
+func (e *Expr) Vars() []string {
+	return e.vars
+}
+
+// node is an expression tree node.
+type node interface {
+	eval(Values) float64
+}
+
+type number float64
+
+func (n number) eval(Values) float64 { return float64(n) }
+
+type variable string
+
+func (v variable) eval(values Values) float64 { return values[string(v)] }
+
+type unary struct {
+	op      string
+	operand node
+}
+
+func (u unary) eval(values Values) float64 {
+	v := u.operand.eval(values)
+	if u.op == "-" {
+		return -v
+	}
+	return boolValue(v == 0) // not
+}
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+func (b binary) eval(values Values) float64 {
+	l := b.left.eval(values)
+	// Logical operators short-circuit
+	switch b.op {
+	case "and":
+		return boolValue(l != 0 && b.right.eval(values) != 0)
+	case "or":
+		return boolValue(l != 0 || b.right.eval(values) != 0)
+	}
+
+	r := b.right.eval(values)
+	switch b.op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	case "/":
+		if r == 0 {
+			return 0
+		}
+		return l / r
+	case ">":
+		return boolValue(l > r)
+	case ">=":
+		return boolValue(l >= r)
+	case "<":
+		return boolValue(l < r)
+	case "<=":
+		return boolValue(l <= r)
+	case "==":
+		return boolValue(l == r)
+	case "!=":
+		return boolValue(l != r)
+	}
+	return 0
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// tokenize splits a condition into numbers, identifiers and operators.
+// Identifiers are lowercased, && and || become "and" and "or", ! becomes "not".
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		r := rune(s[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(s) && (unicode.IsDigit(rune(s[j])) || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j])) || s[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, strings.ToLower(s[i:j]))
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{">=", "<=", "==", "!=", "&&", "||", ">", "<", "+", "-", "*", "/", "(", ")", "!"} {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+			i += len(op)
+			switch op {
+			case "&&":
+				op = "and"
+			case "||":
+				op = "or"
+			case "!":
+				op = "not"
+			}
+			tokens = append(tokens, op)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	return tokens, nil
+}
+
+// exprParser is a recursive descent parser of conditions, from the lowest precedence:
+//
+//	or      = and { "or" and }
+//	and     = not { "and" not }
+//	not     = "not" not | compare
+//	compare = sum [ (">" | ">=" | "<" | "<=" | "==" | "!=") sum ]
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/") unary }
+//	unary   = "-" unary | number | variable | "(" or ")"
+type exprParser struct {
+	tokens []string
+	pos    int
+	vars   []string
+}
+
+// peek returns the current token, "" at the end.
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// accept consumes the current token if it's one of ops.
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	if t := p.peek(); slices.Contains(ops, t) {
+		p.pos++
+		return t, true
+	}
+	return "", false
+}
+
+// binaryLevel parses operands of next separated by ops, left-associative.
+func (p *exprParser) binaryLevel(next func() (node, error), ops ...string) (node, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) or() (node, error) {
+	return p.binaryLevel(p.and, "or")
+}
+
+func (p *exprParser) and() (node, error) {
+	return p.binaryLevel(p.not, "and")
+}
+
+func (p *exprParser) not() (node, error) {
+	if _, ok := p.accept("not"); ok {
+		operand, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return unary{op: "not", operand: operand}, nil
+	}
+	return p.compare()
+}
+
+func (p *exprParser) compare() (node, error) {
+	left, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept(">", ">=", "<", "<=", "==", "!=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	return binary{op: op, left: left, right: right}, nil
+}
+
+func (p *exprParser) sum() (node, error) {
+	return p.binaryLevel(p.product, "+", "-")
+}
+
+func (p *exprParser) product() (node, error) {
+	return p.binaryLevel(p.unary, "*", "/")
+}
+
+func (p *exprParser) unary() (node, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unary{op: "-", operand: operand}, nil
+	}
+
+	t := p.peek()
+	switch {
+	case t == "":
+		return nil, fmt.Errorf("unexpected end of condition")
+	case t == "(":
+		p.pos++
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return inner, nil
+	case unicode.IsDigit(rune(t[0])) || t[0] == '.':
+		p.pos++
+		v, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t)
+		}
+		return number(v), nil
+	case unicode.IsLetter(rune(t[0])) || t[0] == '_':
+		p.pos++
+		if !slices.Contains(Variables, t) {
+			return nil, fmt.Errorf("unknown variable %q, expected one of %s", t, strings.Join(Variables, ", "))
+		}
+		if !slices.Contains(p.vars, t) {
+			p.vars = append(p.vars, t)
+		}
+		return variable(t), nil
+	}
+	return nil, fmt.Errorf("unexpected %q", t)
+}
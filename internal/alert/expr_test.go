@@ -0,0 +1,71 @@
+package alert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+func TestParseExpr(t *testing.T) {
+	values := snapshotValues(domain.SchedulerSnapshot{
+		GoMaxProcs: 4,
+		IdleProcs:  0,
+		Threads:    20,
+		RunQueue:   120,
+		LRQ:        []int{1, 7, 3, 0},
+		LRQSum:     11,
+		Goroutines: 500,
+	})
+
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"GRQ > 100", 1},
+		{"grq > 100 and runqueue < 100", 0},
+		{"idleprocs == 0 && spinningthreads == 0", 1},
+		{"threads > 4*gomaxprocs", 1},
+		{"threads > 4 * (gomaxprocs + 2)", 0},
+		{"lrqmax - lrqsum / gomaxprocs", 7 - 11.0/4},
+		{"not idleprocs", 1},
+		{"!(goroutines >= 500) || lrqmax != 7", 0},
+		{"-grq + 1.5", -118.5},
+		{"1 + 2 * 3 - 4", 3},
+		{"grq / 0", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := ParseExpr(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, expr.Eval(values))
+		})
+	}
+
+	expr, err := ParseExpr("idleprocs == 0 and spinningthreads == 0 or IDLEPROCS > 8")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"idleprocs", "spinningthreads"}, expr.Vars())
+}
+
+func TestParseExpr_Errors(t *testing.T) {
+	for expr, message := range map[string]string{
+		"":               "empty condition",
+		"grq >":          "unexpected end",
+		"queue > 1":      "unknown variable \"queue\"",
+		"(grq > 1":       "missing closing parenthesis",
+		"grq > 1 2":      "unexpected \"2\"",
+		"grq ? 1":        "unexpected character",
+		"grq > 1..2":     "invalid number",
+		"grq > 1 and )":  "unexpected \")\"",
+		"threads = 4":    "unexpected character",
+		"grq > 1 > 2":    "unexpected \">\"",
+		"goroutines > x": "unknown variable \"x\"",
+	} {
+		_, err := ParseExpr(expr)
+		if assert.Error(t, err, expr) {
+			assert.Contains(t, err.Error(), message, expr)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Package alert evaluates user-defined rules against scheduler snapshots
+// and runs actions when a rule starts or stops firing.
+//
+// Rules are read from a text file, one rule per line:
+//
+//	# name: condition [for duration] [=> action, action, ...]
+//	busy: GRQ > 100 for 5s => highlight grq, bell, log alerts.log
+//	stalled: idleprocs == 0 and spinningthreads == 0 for 10s => exec notify-send "scheduler stalled"
+//	threads > 4*gomaxprocs => webhook https://hooks.example.com/goschedviz
+//
+// The name is optional, the condition is used instead. Actions are:
+//
+//   - highlight <widget>...: paints borders of widgets red while the rule fires
+//   - bell: rings the terminal bell
+//   - log <file>: appends firing and resolved events to the file
+//   - exec <command>: runs a shell command, it takes the rest of the line, so it goes last
+//   - webhook <url>: posts the event as JSON, the URL ends at whitespace and may contain commas
+//
+// Every firing rule is also shown in the status line.
+package alert
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Widgets lists names of widgets the highlight action can refer to.
+var Widgets = []string{"grq", "goroutines", "threads", "idleprocs", "lrq", "plots", "info"}
+
+// ActionKind is the kind of an action run by a rule.
+type ActionKind string
+
+// Action kinds
+const (
+	ActionHighlight ActionKind = "highlight"
+	ActionBell      ActionKind = "bell"
+	ActionLog       ActionKind = "log"
+	ActionExec      ActionKind = "exec"
+	ActionWebhook   ActionKind = "webhook"
+)
+
+// Action is something a rule does when it starts or stops firing.
+type Action struct {
+	Kind    ActionKind
+	Widgets []string // Widgets to highlight
+	Target  string   // Log file path, shell command or webhook URL
+}
+
+// Rule is a condition that fires when it holds for a while.
+type Rule struct {
+	Name      string
+	Condition string // Condition as written in the rules file
+	For       time.Duration
+	Actions   []Action
+
+	expr *Expr
+}
+
+// Highlights returns widgets the rule highlights while firing.
+func (r Rule) Highlights() []string {
+	var widgets []string
+	for _, a := range r.Actions {
+		if a.Kind == ActionHighlight {
+			widgets = append(widgets, a.Widgets...)
+		}
+	}
+	return widgets
+}
+
+var (
+	// ruleName matches an optional "name:" prefix of a rule
+	ruleName = regexp.MustCompile(`^([A-Za-z0-9_.-]+):\s+`)
+	// ruleFor matches a trailing "for <duration>" of a condition
+	ruleFor = regexp.MustCompile(`\s+for\s+(\S+)$`)
+)
+
+// ParseRule parses a single rule line.
+func ParseRule(line string) (Rule, error) {
+	var r Rule
+	line = strings.TrimSpace(line)
+
+	if m := ruleName.FindStringSubmatch(line); m != nil {
+		r.Name = m[1]
+		line = line[len(m[0]):]
+	}
+
+	condition, actions, hasActions := strings.Cut(line, "=>")
+	condition = strings.TrimSpace(condition)
+	if m := ruleFor.FindStringSubmatchIndex(condition); m != nil {
+		d, err := time.ParseDuration(condition[m[2]:m[3]])
+		if err != nil || d < 0 {
+			return Rule{}, fmt.Errorf("invalid duration %q", condition[m[2]:m[3]])
+		}
+		r.For = d
+		condition = condition[:m[0]]
+	}
+
+	expr, err := ParseExpr(condition)
+	if err != nil {
+		return Rule{}, err
+	}
+	r.expr = expr
+	r.Condition = condition
+	if r.Name == "" {
+		r.Name = condition
+	}
+
+	if hasActions {
+		r.Actions, err = parseActions(actions)
+		if err != nil {
+			return Rule{}, err
+		}
+	}
+	return r, nil
+}
+
+// parseActions parses a comma-separated list of actions. An exec action takes the rest of the list,
+// a webhook URL ends at whitespace, so it may contain commas.
+func parseActions(s string) ([]Action, error) {
+	var actions []Action
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		var item string
+		switch {
+		case strings.HasPrefix(s, string(ActionExec)+" "):
+			item, s = s, ""
+		case strings.HasPrefix(s, string(ActionWebhook)+" "):
+			rest := strings.TrimSpace(strings.TrimPrefix(s, string(ActionWebhook)))
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			url, next := rest[:end], strings.TrimSpace(rest[end:])
+			// The separator is either the last character of the URL or follows it
+			if trimmed, ok := strings.CutSuffix(url, ","); ok {
+				url = trimmed
+			} else if next != "" {
+				var found bool
+				if next, found = strings.CutPrefix(next, ","); !found {
+					return nil, fmt.Errorf("expected a comma after webhook URL, got %q", next)
+				}
+			}
+			item, s = string(ActionWebhook)+" "+url, next
+		default:
+			item, s, _ = strings.Cut(s, ",")
+		}
+
+		kind, arg, _ := strings.Cut(strings.TrimSpace(item), " ")
+		arg = strings.TrimSpace(arg)
+		a := Action{Kind: ActionKind(kind)}
+		switch a.Kind {
+		case ActionHighlight:
+			a.Widgets = strings.Fields(arg)
+			if len(a.Widgets) == 0 {
+				return nil, fmt.Errorf("highlight needs a widget: %s", strings.Join(Widgets, ", "))
+			}
+			for _, w := range a.Widgets {
+				if !slices.Contains(Widgets, w) {
+					return nil, fmt.Errorf("unknown widget %q, expected one of %s", w, strings.Join(Widgets, ", "))
+				}
+			}
+		case ActionBell:
+			if arg != "" {
+				return nil, fmt.Errorf("bell takes no arguments")
+			}
+		case ActionLog, ActionExec, ActionWebhook:
+			if arg == "" {
+				return nil, fmt.Errorf("%s needs an argument", kind)
+			}
+			a.Target = arg
+		default:
+			return nil, fmt.Errorf("unknown action %q, expected highlight, bell, log, exec or webhook", kind)
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
+
+// ParseRules reads rules, one per line. Empty lines and lines starting with # are skipped.
+func ParseRules(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := ParseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Load reads rules from a file.
+func Load(path string) ([]Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rules, err := ParseRules(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,94 @@
+package alert
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule("busy: GRQ > 100 for 5s => highlight grq lrq, bell, log alerts.log")
+	require.NoError(t, err)
+	assert.Equal(t, "busy", rule.Name)
+	assert.Equal(t, "GRQ > 100", rule.Condition)
+	assert.Equal(t, 5*time.Second, rule.For)
+	assert.Equal(t, []Action{
+		{Kind: ActionHighlight, Widgets: []string{"grq", "lrq"}},
+		{Kind: ActionBell},
+		{Kind: ActionLog, Target: "alerts.log"},
+	}, rule.Actions)
+	assert.Equal(t, []string{"grq", "lrq"}, rule.Highlights())
+
+	rule, err = ParseRule("threads > 4*gomaxprocs")
+	require.NoError(t, err)
+	assert.Equal(t, "threads > 4*gomaxprocs", rule.Name, "Condition names a rule without a name")
+	assert.Zero(t, rule.For)
+	assert.Empty(t, rule.Actions)
+
+	rule, err = ParseRule(`stalled: idleprocs == 0 and spinningthreads == 0 for 10s => webhook http://localhost/hook, exec notify-send "stalled, again"`)
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, rule.For)
+	assert.Equal(t, []Action{
+		{Kind: ActionWebhook, Target: "http://localhost/hook"},
+		{Kind: ActionExec, Target: `notify-send "stalled, again"`},
+	}, rule.Actions, "Exec takes the rest of the line")
+
+	rule, err = ParseRule("grq > 1 => webhook https://hooks.example.com/alert?tags=a,b, bell, webhook http://localhost/x,y")
+	require.NoError(t, err)
+	assert.Equal(t, []Action{
+		{Kind: ActionWebhook, Target: "https://hooks.example.com/alert?tags=a,b"},
+		{Kind: ActionBell},
+		{Kind: ActionWebhook, Target: "http://localhost/x,y"},
+	}, rule.Actions, "Webhook URLs may contain commas")
+}
+
+func TestParseRule_Errors(t *testing.T) {
+	for line, message := range map[string]string{
+		"grq > 1 for soon":               "invalid duration",
+		"grq > 1 for -5s":                "invalid duration",
+		"grq > 1 => highlight":           "highlight needs a widget",
+		"grq > 1 => highlight gauges":    "unknown widget \"gauges\"",
+		"grq > 1 => bell loudly":         "bell takes no arguments",
+		"grq > 1 => log":                 "log needs an argument",
+		"grq > 1 => email ops@localhost": "unknown action \"email\"",
+		"grq > 1 => webhook http://a b":  "expected a comma after webhook URL",
+		"busy: for 5s":                   "unknown variable \"for\"",
+	} {
+		_, err := ParseRule(line)
+		if assert.Error(t, err, line) {
+			assert.Contains(t, err.Error(), message, line)
+		}
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(strings.NewReader(`
+# Scheduler alerts
+busy: grq > 100 for 5s => bell
+
+threads > 4*gomaxprocs
+`))
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "busy", rules[0].Name)
+	assert.Equal(t, "threads > 4*gomaxprocs", rules[1].Name)
+
+	_, err = ParseRules(strings.NewReader("grq > 1\n\ngrq >"))
+	assert.ErrorContains(t, err, "line 3: ")
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.rules")
+	require.NoError(t, os.WriteFile(path, []byte("busy: grq > 100\nbroken: grq >\n"), 0o644))
+
+	_, err := Load(path)
+	assert.ErrorContains(t, err, path+": line 2: ")
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.rules"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,89 @@
+package godebug
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// BuildOptions are "go build" flags used to compile the target.
+// They have no effect on prebuilt executables.
+type BuildOptions struct {
+	Race     bool   // Enable the race detector
+	Tags     string // Comma-separated build tags
+	GCFlags  string // Arguments passed to the compiler, e.g. "all=-N -l"
+	LDFlags  string // Arguments passed to the linker, e.g. "-s -w"
+	TrimPath bool   // Remove file system paths from the binary
+}
+
+// IsZero reports whether no build flags are set.
+func (b BuildOptions) IsZero() bool {
+	return b == BuildOptions{}
+}
+
+// Args returns the options as "go build" arguments.
+func (b BuildOptions) Args() []string {
+	var args []string
+	if b.Race {
+		args = append(args, "-race")
+	}
+	if b.Tags != "" {
+		args = append(args, "-tags="+b.Tags)
+	}
+	if b.GCFlags != "" {
+		args = append(args, "-gcflags="+b.GCFlags)
+	}
+	if b.LDFlags != "" {
+		args = append(args, "-ldflags="+b.LDFlags)
+	}
+	if b.TrimPath {
+		args = append(args, "-trimpath")
+	}
+	return args
+}
+
+// tagArgs returns build tags as "go" command arguments, so tools like "go list"
+// see the same files as the build.
+func (b BuildOptions) tagArgs() []string {
+	if b.Tags == "" {
+		return nil
+	}
+	return []string{"-tags=" + b.Tags}
+}
+
+// binaryName returns the file name of the compiled target, so the process
+// is easy to recognize in ps and top.
+func (c *Collector) binaryName() string {
+	var name string
+	switch {
+	case c.kind == targetFile:
+		name = strings.TrimSuffix(filepath.Base(c.path), ".go")
+	case isImportPath(c.path):
+		name = path.Base(c.path)
+	default:
+		if abs, err := filepath.Abs(c.path); err == nil {
+			name = filepath.Base(abs)
+		}
+	}
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = "program"
+	}
+	if c.test {
+		name += ".test"
+	}
+
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// removeBinary deletes the private build directory of the compiled binary.
+// Prebuilt executables are never removed.
+func (c *Collector) removeBinary(binary string) {
+	if c.kind != targetBinary {
+		os.RemoveAll(filepath.Dir(binary))
+	}
+}
@@ -0,0 +1,48 @@
+package godebug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildOptions_Args(t *testing.T) {
+	assert.True(t, BuildOptions{}.IsZero())
+	assert.Empty(t, BuildOptions{}.Args())
+
+	build := BuildOptions{
+		Race:     true,
+		Tags:     "integration,debug",
+		GCFlags:  "all=-N -l",
+		LDFlags:  "-s -w",
+		TrimPath: true,
+	}
+	assert.False(t, build.IsZero())
+	assert.Equal(t, []string{
+		"-race",
+		"-tags=integration,debug",
+		"-gcflags=all=-N -l",
+		"-ldflags=-s -w",
+		"-trimpath",
+	}, build.Args())
+}
+
+func TestCollector_BinaryName(t *testing.T) {
+	tests := []struct {
+		path string
+		kind targetKind
+		want string
+	}{
+		{"examples/simple/main.go", targetFile, "main"},
+		{"example.com/app/cmd/api", targetPackage, "api"},
+		{"./cmd/worker", targetPackage, "worker"},
+		{".", targetPackage, "godebug"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			c := &Collector{path: tt.path, kind: tt.kind}
+			assert.Equal(t, tt.want, c.binaryName())
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package godebug
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/JustSkiv/goschedviz/pkg/metrics"
+)
+
+// metricsFd is the descriptor number of the metrics pipe in the target process.
+// ExtraFiles start right after stdin, stdout and stderr.
+const metricsFd = 3
+
+// attachMetricsChannel passes a pipe to the target as an inherited descriptor
+// and announces it in metrics.ChannelEnv. It returns the read end, or nil where
+// descriptors can't be inherited and metrics stay on stderr.
+func attachMetricsChannel(cmd *exec.Cmd) (*os.File, error) {
+	if runtime.GOOS == "windows" {
+		return nil, nil
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metrics channel: %w", err)
+	}
+
+	cmd.ExtraFiles = append(cmd.ExtraFiles, w)
+	cmd.Env = mergeEnv(cmd.Env, fmt.Sprintf("%s=fd:%d", metrics.ChannelEnv, metricsFd+len(cmd.ExtraFiles)-1))
+	return r, nil
+}
+
+// closeAfterStart closes the parent copies of descriptors passed to the started process.
+func closeAfterStart(cmd *exec.Cmd) {
+	for _, f := range cmd.ExtraFiles {
+		f.Close()
+	}
+}
@@ -3,30 +3,172 @@ package godebug
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/JustSkiv/goschedviz/internal/domain"
 )
 
+// targetKind describes how the monitored program is turned into a runnable binary.
+type targetKind int
+
+const (
+	// targetFile is a single .go source file built with "go build".
+	targetFile targetKind = iota
+	// targetPackage is a package directory, module directory or import path built with "go build".
+	targetPackage
+	// targetBinary is a prebuilt executable that is run without building.
+	targetBinary
+)
+
 // Collector implements collector.Collector interface for GODEBUG schedtrace output.
 type Collector struct {
-	cmd    *exec.Cmd
-	done   chan struct{}
-	path   string
-	period int // schedtrace period in milliseconds
+	cmd     *exec.Cmd
+	done    chan struct{}
+	path    string
+	period  int // schedtrace period in milliseconds
+	kind    targetKind
+	args    []string // command-line arguments of the target program
+	env     []string // extra environment variables in "KEY=VALUE" form
+	detail  bool     // run with scheddetail=1
+	gcTrace bool     // run with gctrace=1
+
+	lineHook func(domain.OutputLine) // called for every raw output line
+	logs     domain.LogBuffer        // recent output of the program itself
+	seq      domain.Sequencer        // stamps snapshots of all runs
+
+	validation  Validation
+	diagnostics domain.ParseDiagnostics // parse outcomes of all runs
+
+	build        BuildOptions
+	restart      RestartPolicy
+	restartDelay time.Duration // pause before a restart
+	stopOnce     sync.Once
+
+	test bool // build the target with "go test -c" and run its tests
+
+	watch         bool          // rebuild and restart the target when its sources change
+	watchInterval time.Duration // how often sources are checked for changes
+
+	mu       sync.Mutex // guards cmd start and the fields below
+	restarts int
+	rebuilds int
+	buildErr error             // last failed rebuild in watch mode
+	exit     domain.ExitStatus // how the last run ended
+	exited   bool              // the target is not running
+}
+
+// Option configures optional Collector behavior.
+type Option func(*Collector)
+
+// WithArgs sets command-line arguments passed to the target program.
+func WithArgs(args ...string) Option {
+	return func(c *Collector) {
+		c.args = append(c.args, args...)
+	}
+}
+
+// WithEnv adds environment variables in "KEY=VALUE" form to the target program.
+// They override variables inherited from the current environment.
+// A GODEBUG value is merged with the settings required by the collector
+// instead of being replaced.
+func WithEnv(env ...string) Option {
+	return func(c *Collector) {
+		c.env = append(c.env, env...)
+	}
+}
+
+// WithSchedDetail enables GODEBUG scheddetail=1, so snapshots carry
+// per-P, per-M and per-G state in SchedulerSnapshot.Detail.
+// Detailed snapshots are delivered one trace period late, because a detail
+// block is complete only when the next one starts.
+func WithSchedDetail() Option {
+	return func(c *Collector) {
+		c.detail = true
+	}
+}
+
+// WithGCTrace enables GODEBUG gctrace=1, so snapshots carry GC cycles
+// completed since the previous snapshot in SchedulerSnapshot.GC.
+func WithGCTrace() Option {
+	return func(c *Collector) {
+		c.gcTrace = true
+	}
+}
+
+// WithLineHook registers a function called for every raw output line of the target,
+// including lines that are not scheduler traces. The hook is called from the
+// collector goroutine and should return quickly.
+func WithLineHook(hook func(domain.OutputLine)) Option {
+	return func(c *Collector) {
+		c.lineHook = hook
+	}
+}
+
+// WithValidation sets how strictly parsed snapshots are checked.
+func WithValidation(v Validation) Option {
+	return func(c *Collector) {
+		c.validation = v
+	}
+}
+
+// WithBuildOptions sets "go build" flags used to compile the target.
+// Prebuilt executables can't be combined with build flags.
+func WithBuildOptions(build BuildOptions) Option {
+	return func(c *Collector) {
+		c.build = build
+	}
+}
+
+// WithWatch makes the collector watch sources of the target, rebuild it when they change
+// and replace the running process. Snapshots of a rebuilt target are marked
+// with domain.MarkerRebuild. A program that exited is started again after the next change.
+func WithWatch() Option {
+	return func(c *Collector) {
+		c.watch = true
+	}
+}
+
+// WithTest builds the target package with "go test -c" and runs its tests instead of the program.
+// Arguments are passed to the test binary, e.g. "-test.run=TestServer" or "-test.bench=.".
+// Tests run in the package directory like with "go test".
+func WithTest() Option {
+	return func(c *Collector) {
+		c.test = true
+	}
+}
+
+// WithRestart sets when the target is started again after it exits.
+// Snapshots of a restarted target are marked with domain.MarkerRestart.
+func WithRestart(policy RestartPolicy) Option {
+	return func(c *Collector) {
+		c.restart = policy
+	}
 }
 
 // New creates a new GODEBUG collector that will monitor the specified program.
-func New(programPath string, tracePeriod int) *Collector {
-	return &Collector{
-		path:   programPath,
-		period: tracePeriod,
-		done:   make(chan struct{}),
+func New(programPath string, tracePeriod int, opts ...Option) *Collector {
+	c := &Collector{
+		path:          programPath,
+		period:        tracePeriod,
+		done:          make(chan struct{}),
+		seq:           domain.Sequencer{Source: programPath},
+		restartDelay:  defaultRestartDelay,
+		watchInterval: defaultWatchInterval,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
 // validateConfig checks if the collector configuration is valid
@@ -41,19 +183,106 @@ func (c *Collector) validateConfig() error {
 		return fmt.Errorf("program path cannot be empty")
 	}
 
-	// Check if path exists and is a file
-	info, err := os.Stat(c.path)
+	kind, err := detectTarget(c.path)
+	if err != nil {
+		return err
+	}
+	if kind == targetBinary && !c.build.IsZero() {
+		return fmt.Errorf("build flags can't be applied to a prebuilt executable: %s", c.path)
+	}
+	if kind == targetBinary && c.watch {
+		return fmt.Errorf("watch mode requires Go sources, got a prebuilt executable: %s", c.path)
+	}
+	if c.test && kind != targetPackage {
+		return fmt.Errorf("tests can only be run for a package, got: %s", c.path)
+	}
+	if c.test && c.watch {
+		return fmt.Errorf("watch mode can't be used with tests")
+	}
+	c.kind = kind
+
+	return nil
+}
+
+// detectTarget determines what kind of program the path points to.
+// Supported targets are a single .go file, a package or module directory,
+// an import path and a prebuilt executable.
+func detectTarget(path string) (targetKind, error) {
+	info, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("invalid program path: %w", err)
+		// Paths that don't exist on disk may still be import paths like "example.com/app/cmd/api"
+		if errors.Is(err, fs.ErrNotExist) && isImportPath(path) {
+			return targetPackage, nil
+		}
+		return 0, fmt.Errorf("invalid program path: %w", err)
 	}
+
 	if info.IsDir() {
-		return fmt.Errorf("program path must be a file, got directory: %s", c.path)
+		matches, err := filepath.Glob(filepath.Join(path, "*.go"))
+		if err != nil || len(matches) == 0 {
+			return 0, fmt.Errorf("directory contains no Go files: %s", path)
+		}
+		return targetPackage, nil
+	}
+
+	if filepath.Ext(path) == ".go" {
+		return targetFile, nil
+	}
+
+	if isExecutable(info) {
+		return targetBinary, nil
+	}
+
+	return 0, fmt.Errorf("program must be a .go file, a Go package or an executable, got: %s", path)
+}
+
+// isImportPath reports whether path looks like a Go import path rather than a file system path.
+func isImportPath(path string) bool {
+	if filepath.IsAbs(path) || strings.HasPrefix(path, ".") || filepath.Ext(path) == ".go" {
+		return false
+	}
+	return strings.Contains(path, "/")
+}
+
+// isExecutable reports whether the file can be run directly.
+func isExecutable(info fs.FileInfo) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(info.Name()), ".exe")
 	}
+	return info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+}
 
-	// Verify it's a .go file
-	ext := filepath.Ext(c.path)
-	if ext != ".go" {
-		return fmt.Errorf("program must be a .go file, got: %s", c.path)
+// buildArgs returns the working directory and package argument for "go build".
+// On-disk targets are built from their own directory, so packages that belong
+// to a different module than the current working directory build correctly.
+func (c *Collector) buildArgs() (dir, pkg string) {
+	if c.kind == targetFile {
+		return filepath.Dir(c.path), filepath.Base(c.path)
+	}
+	if _, err := os.Stat(c.path); err != nil {
+		// Import paths are resolved by the go tool relative to the current module
+		return "", c.path
+	}
+	return c.path, "."
+}
+
+// compile builds the target into the binary at output path.
+func (c *Collector) compile(output string) error {
+	dir, pkg := c.buildArgs()
+
+	args := append([]string{"build", "-o", output}, c.build.Args()...)
+	if c.test {
+		args = append([]string{"test", "-c", "-o", output}, c.build.Args()...)
+	}
+	buildCmd := exec.Command("go", append(args, pkg)...)
+	buildCmd.Dir = dir
+	if out, err := buildCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to build program: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+
+	// "go test -c" succeeds without writing anything for a package without tests
+	if _, err := os.Stat(output); c.test && err != nil {
+		return fmt.Errorf("package has no test files: %s", c.path)
 	}
 
 	return nil
@@ -68,80 +297,437 @@ func (c *Collector) Start(ctx context.Context) (_ <-chan domain.SchedulerSnapsho
 
 	snapshots := make(chan domain.SchedulerSnapshot)
 
-	// Get temporary binary path
-	tmpBinary := "tmp_program"
-	if runtime.GOOS == "windows" {
-		tmpBinary += ".exe"
+	// Prebuilt executables are run as is, everything else is compiled
+	// in a private temporary directory first, so concurrent sessions
+	// don't share the binary
+	var binary string
+	if c.kind == targetBinary {
+		binary, err = filepath.Abs(c.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve binary path: %w", err)
+		}
+	} else {
+		dir, err := os.MkdirTemp("", "goschedviz-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create build directory: %w", err)
+		}
+		binary = filepath.Join(dir, c.binaryName())
 	}
 
 	// Ensure cleanup in case of errors during setup
 	defer func() {
 		if err != nil {
-			os.Remove(tmpBinary)
+			c.removeBinary(binary)
 		}
 	}()
 
-	// First compile the program
-	buildCmd := exec.Command("go", "build", "-o", tmpBinary, c.path)
-	if err := buildCmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to build program: %w", err)
+	// Sources are looked at before the build, so changes made during it aren't missed
+	var watched sourceSet
+	var stamps map[string]fileStamp
+	if c.watch {
+		if watched, err = c.sources(); err != nil {
+			return nil, err
+		}
+		stamps = watched.stamps()
 	}
 
-	// Then run the compiled binary
-	c.cmd = exec.Command("./" + tmpBinary)
-	c.cmd.Env = append(os.Environ(), fmt.Sprintf("GODEBUG=schedtrace=%d", c.period))
-	c.cmd.Stdin = os.Stdin
+	if c.kind != targetBinary {
+		if err := c.compile(binary); err != nil {
+			return nil, err
+		}
+	}
 
-	stderr, err := c.cmd.StderrPipe()
+	// Then run the binary
+	p, err := c.launch(binary)
+	if err != nil {
+		return nil, err
+	}
+
+	var rebuilt chan string
+	if c.watch {
+		rebuilt = make(chan string)
+		go c.watchSources(ctx, binary, watched, stamps, rebuilt)
+	}
+
+	go c.supervise(ctx, binary, p, rebuilt, snapshots)
+
+	return snapshots, nil
+}
+
+// process is a single run of the target binary.
+type process struct {
+	cmd        *exec.Cmd
+	started    time.Time
+	stderr     chan string
+	stdout     chan string
+	metrics    chan string // nil without a metrics side channel
+	metricsOut *os.File
+	finished   chan struct{} // stops output readers
+}
+
+// close stops output readers and releases the metrics channel.
+func (p *process) close() {
+	close(p.finished)
+	if p.metricsOut != nil {
+		p.metricsOut.Close()
+	}
+}
+
+// kill stops the process and waits for it to exit.
+func (p *process) kill() {
+	p.cmd.Process.Kill()
+	p.cmd.Wait()
+	p.close()
+}
+
+// launch starts the binary and begins reading its output.
+func (c *Collector) launch(binary string) (*process, error) {
+	cmd := exec.Command(binary, c.args...)
+	cmd.Env = c.environ()
+	cmd.Stdin = os.Stdin
+	if c.test {
+		// Like "go test", so tests find their testdata
+		if dir, _ := c.buildArgs(); dir != "" {
+			cmd.Dir = dir
+		}
+	}
+
+	stderr, err := cmd.StderrPipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get stderr pipe: %w", err)
 	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+
+	// Metrics reporter output goes through a separate pipe to keep stderr clean
+	metricsOut, err := attachMetricsChannel(cmd)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := c.cmd.Start(); err != nil {
+	c.mu.Lock()
+	err = cmd.Start()
+	if err == nil {
+		c.cmd = cmd
+	}
+	c.mu.Unlock()
+	// Only the child writes to the channel now
+	closeAfterStart(cmd)
+	if err != nil {
+		if metricsOut != nil {
+			metricsOut.Close()
+		}
 		return nil, fmt.Errorf("failed to start process: %w", err)
 	}
 
-	go func() {
-		defer close(snapshots)
-		defer c.cmd.Process.Kill()
-		defer os.Remove(tmpBinary)
+	p := &process{
+		cmd:        cmd,
+		started:    time.Now(),
+		stderr:     make(chan string),
+		stdout:     make(chan string),
+		metricsOut: metricsOut,
+		finished:   make(chan struct{}),
+	}
+	go scanLines(stderr, p.stderr, p.finished)
+	go scanLines(stdout, p.stdout, p.finished)
+	if metricsOut != nil {
+		p.metrics = make(chan string)
+		go scanLines(metricsOut, p.metrics, p.finished)
+	}
+
+	return p, nil
+}
+
+// supervise monitors runs of the target until it exits for good or the collector is stopped,
+// restarting it according to the restart policy and after rebuilds in watch mode.
+// Rebuilt binaries arrive on rebuilt, which is nil without watch mode.
+func (c *Collector) supervise(ctx context.Context, binary string, p *process, rebuilt <-chan string, snapshots chan<- domain.SchedulerSnapshot) {
+	defer close(snapshots)
+	defer c.removeBinary(binary)
 
-		scanner := bufio.NewScanner(stderr)
-		parser := NewParser()
+	current := binary
+	marker := domain.MarkerNone
+	for {
+		end, next := c.monitor(ctx, p, marker, rebuilt, snapshots)
+		switch end {
+		case runCancelled:
+			p.kill()
+			return
+		case runRebuilt:
+			p.kill()
+			marker = domain.MarkerRebuild
+		case runExited:
+			err := p.cmd.Wait()
+			p.close()
+			status := exitStatus(p.cmd.ProcessState, time.Since(p.started))
+			if err != nil && p.cmd.ProcessState == nil {
+				c.logs.Add(domain.OutputLine{Time: time.Now(), Stream: "stderr", Text: "failed to wait for process: " + err.Error()})
+			}
+
+			// Exits caused by Stop are not restarted
+			restart := c.restart.shouldRestart(status) && !c.stopping(ctx)
+			c.setExit(status, restart)
 
-		for scanner.Scan() {
+			var delay <-chan time.Time
+			if restart {
+				delay = time.After(c.restartDelay)
+			} else if rebuilt == nil {
+				return
+			}
+
+			// In watch mode a stopped program waits for the next change of its sources
+			marker = domain.MarkerRestart
 			select {
+			case <-delay:
+			case next = <-rebuilt:
+				marker = domain.MarkerRebuild
 			case <-ctx.Done():
 				return
 			case <-c.done:
 				return
-			default:
-				line := scanner.Text()
-				if snapshot, ok := parser.Parse(line); ok {
-					select {
-					case snapshots <- snapshot:
-					case <-ctx.Done():
-						return
-					case <-c.done:
-						return
-					}
+			}
+		}
+
+		if marker == domain.MarkerRebuild {
+			// Previous rebuilt binary isn't needed anymore, the original one is removed on exit
+			if current != binary {
+				os.RemoveAll(filepath.Dir(current))
+			}
+			current = next
+		}
+
+		var err error
+		p, err = c.launch(current)
+		if err != nil {
+			c.logs.Add(domain.OutputLine{Time: time.Now(), Stream: "stderr", Text: "failed to restart: " + err.Error()})
+			c.mu.Lock()
+			c.exit.Restarting = false
+			c.exited = true
+			c.mu.Unlock()
+			return
+		}
+
+		c.mu.Lock()
+		if marker == domain.MarkerRebuild {
+			c.rebuilds++
+		} else {
+			c.restarts++
+		}
+		c.exited = false
+		c.mu.Unlock()
+	}
+}
+
+// runEnd tells why monitoring of a single run stopped.
+type runEnd int
+
+const (
+	// runExited means the process closed its output and is exiting.
+	runExited runEnd = iota
+	// runCancelled means the collector was stopped.
+	runCancelled
+	// runRebuilt means a new binary was built and the process has to be replaced.
+	runRebuilt
+)
+
+// monitor parses output of a single run and sends snapshots, the first one with the marker.
+// It returns why monitoring stopped and, for runRebuilt, the path of the new binary.
+func (c *Collector) monitor(ctx context.Context, p *process, marker domain.Marker, rebuilt <-chan string, snapshots chan<- domain.SchedulerSnapshot) (runEnd, string) {
+	parser := NewParser(ParserValidation(c.validation), ParserDiagnostics(&c.diagnostics))
+	send := func(snapshot domain.SchedulerSnapshot, received time.Time) bool {
+		snapshot = c.seq.Stamp(snapshot, received)
+		if marker != domain.MarkerNone {
+			snapshot.Marker = marker
+			marker = domain.MarkerNone
+		}
+		select {
+		case snapshots <- snapshot:
+			return true
+		case <-ctx.Done():
+			return false
+		case <-c.done:
+			return false
+		}
+	}
+
+	stderrLines, stdoutLines, metricsLines := p.stderr, p.stdout, p.metrics
+	for stderrLines != nil || stdoutLines != nil {
+		var line, stream string
+		select {
+		case l, ok := <-stderrLines:
+			if !ok {
+				// Last scheddetail block is completed only by the end of output
+				if snapshot, ok := parser.Flush(); ok && !send(snapshot, time.Now()) {
+					return runCancelled, ""
 				}
+				stderrLines = nil
+				continue
+			}
+			line, stream = l, "stderr"
+		case l, ok := <-stdoutLines:
+			if !ok {
+				stdoutLines = nil
+				continue
 			}
+			line, stream = l, "stdout"
+		case l, ok := <-metricsLines:
+			if !ok {
+				metricsLines = nil
+				continue
+			}
+			line, stream = l, "metrics"
+		case next := <-rebuilt:
+			return runRebuilt, next
+		case <-ctx.Done():
+			return runCancelled, ""
+		case <-c.done:
+			return runCancelled, ""
 		}
 
-		if err := scanner.Err(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading stderr: %v\n", err)
+		output := domain.OutputLine{Time: time.Now(), Stream: stream, Text: line}
+		if c.lineHook != nil {
+			c.lineHook(output)
 		}
-	}()
+		if stream == "stdout" {
+			c.logs.Add(output)
+			continue
+		}
+		if stream == "stderr" && !IsTrace(line) {
+			c.logs.Add(output)
+		}
+		if snapshot, ok := parser.Parse(line); ok {
+			if !send(snapshot, output.Time) {
+				return runCancelled, ""
+			}
+		}
+	}
 
-	return snapshots, nil
+	return runExited, ""
+}
+
+// stopping reports whether the collector is being stopped.
+func (c *Collector) stopping(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// setExit records how the last run of the process ended.
+func (c *Collector) setExit(status domain.ExitStatus, restarting bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status.Restarts = c.restarts
+	status.Restarting = restarting
+	status.Watching = c.watch
+	c.exit = status
+	c.exited = true
+}
+
+// Diagnostics returns parse outcomes of the target output.
+func (c *Collector) Diagnostics() domain.ParseReport {
+	return c.diagnostics.Report()
+}
+
+// Exit returns how the last run of the target ended.
+// It reports false while the target is running.
+func (c *Collector) Exit() (domain.ExitStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.exit, c.exited
+}
+
+// Status returns the number of restarts and rebuilds and reports a failed rebuild.
+func (c *Collector) Status() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var lines []string
+	if c.restarts > 0 && !c.exited {
+		lines = append(lines, fmt.Sprintf("Restarts: %d", c.restarts))
+	}
+	if c.rebuilds > 0 {
+		lines = append(lines, fmt.Sprintf("Rebuilds: %d", c.rebuilds))
+	}
+	if c.buildErr != nil {
+		lines = append(lines, "Build failed, see output")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maxLineSize limits a single output line, the rest of a longer line is dropped.
+const maxLineSize = 1 << 20
+
+// scanLines sends lines read from r until EOF or until finished is closed.
+// It closes lines when done. Long lines are truncated rather than stopping the reader,
+// so the program never blocks on a full pipe.
+func scanLines(r io.Reader, lines chan<- string, finished <-chan struct{}) {
+	defer close(lines)
+
+	reader := bufio.NewReader(r)
+	var line []byte
+	for {
+		chunk, more, err := reader.ReadLine()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, os.ErrClosed) {
+				fmt.Fprintf(os.Stderr, "Error reading output: %v\n", err)
+			}
+			return
+		}
+		line = append(line, chunk[:min(len(chunk), maxLineSize-len(line))]...)
+		if more {
+			continue
+		}
+
+		select {
+		case lines <- string(line):
+		case <-finished:
+			return
+		}
+		line = line[:0]
+	}
+}
+
+// Logs returns recent stdout and stderr lines of the program, excluding trace output.
+func (c *Collector) Logs() []domain.LogEntry {
+	return c.logs.Entries()
+}
+
+// environ builds the environment of the target program.
+// GODEBUG settings of the user are kept, only settings required by the collector are set.
+func (c *Collector) environ() []string {
+	settings := []string{fmt.Sprintf("schedtrace=%d", c.period)}
+	if c.detail {
+		settings = append(settings, "scheddetail=1")
+	}
+	if c.gcTrace {
+		settings = append(settings, "gctrace=1")
+	}
+
+	env := mergeEnv(os.Environ(), c.env...)
+	value, _ := lookupEnv(env, "GODEBUG")
+	return mergeEnv(env, "GODEBUG="+mergeGodebug(value, settings...))
 }
 
 // Stop implements collector.Collector interface.
 func (c *Collector) Stop() error {
-	close(c.done)
+	c.stopOnce.Do(func() { close(c.done) })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if c.cmd != nil && c.cmd.Process != nil {
-		return c.cmd.Process.Kill()
+		if err := c.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return err
+		}
 	}
 	return nil
 }
@@ -3,8 +3,11 @@ package godebug
 import (
 	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -148,6 +151,114 @@ readLoop:
 	assert.False(t, ok, "Channel should be closed after Stop")
 }
 
+// setupTestPackage writes the test program as a standalone module and returns its directory.
+func setupTestPackage(t *testing.T) string {
+	t.Helper()
+
+	programPath := setupTestProgram(t)
+	dir := filepath.Dir(programPath)
+	err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/testprog\n\ngo 1.23\n"), 0666)
+	require.NoError(t, err, "failed to write go.mod")
+
+	return dir
+}
+
+// setupTestBinary builds the test program and returns path to the executable.
+func setupTestBinary(t *testing.T) string {
+	t.Helper()
+
+	programPath := setupTestProgram(t)
+	binary := filepath.Join(filepath.Dir(programPath), "testprog")
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	out, err := exec.Command("go", "build", "-o", binary, programPath).CombinedOutput()
+	require.NoError(t, err, "failed to build test binary: %s", out)
+
+	return binary
+}
+
+func TestCollector_Targets(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) string
+	}{
+		{"source file", setupTestProgram},
+		{"package directory", setupTestPackage},
+		{"prebuilt binary", setupTestBinary},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := tt.setup(t)
+			collector := New(target, 100)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+
+			snapshots, err := collector.Start(ctx)
+			require.NoError(t, err, "Start should not return error")
+
+			select {
+			case snapshot, ok := <-snapshots:
+				require.True(t, ok, "Should receive a snapshot before channel is closed")
+				assert.GreaterOrEqual(t, snapshot.GoMaxProcs, 1)
+			case <-time.After(2 * time.Second):
+				t.Fatal("Timed out waiting for snapshot")
+			}
+
+			assert.NoError(t, collector.Stop())
+
+			if tt.name == "prebuilt binary" {
+				assert.FileExists(t, target, "Prebuilt binary must not be removed")
+			}
+		})
+	}
+}
+
+func TestDetectTarget(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	dir := t.TempDir()
+	textFile := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(textFile, []byte("text"), 0644))
+	emptyDir := filepath.Join(dir, "empty")
+	require.NoError(t, os.Mkdir(emptyDir, 0755))
+
+	tests := []struct {
+		name    string
+		path    string
+		want    targetKind
+		wantErr bool
+	}{
+		{name: "source file", path: setupTestProgram(t), want: targetFile},
+		{name: "package directory", path: setupTestPackage(t), want: targetPackage},
+		{name: "executable", path: setupTestBinary(t), want: targetBinary},
+		{name: "import path", path: "example.com/app/cmd/api", want: targetPackage},
+		{name: "directory without Go files", path: emptyDir, wantErr: true},
+		{name: "non-executable file", path: textFile, wantErr: true},
+		{name: "missing relative path", path: "./no/such/dir", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectTarget(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestCollector_InvalidProgram(t *testing.T) {
 	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
 		t.Skip("Skipping test on non-Unix platform")
@@ -262,3 +373,523 @@ func TestCollector_PeriodValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestCollector_Environ(t *testing.T) {
+	t.Setenv("GODEBUG", "madvdontneed=1,schedtrace=5")
+	t.Setenv("APP_MODE", "inherited")
+
+	collector := New("main.go", 250, WithEnv("APP_MODE=override", "APP_CONFIG=/etc/app.yaml"))
+	env := collector.environ()
+
+	godebug, ok := lookupEnv(env, "GODEBUG")
+	require.True(t, ok)
+	assert.Equal(t, "madvdontneed=1,schedtrace=250", godebug)
+
+	mode, _ := lookupEnv(env, "APP_MODE")
+	assert.Equal(t, "override", mode)
+
+	config, _ := lookupEnv(env, "APP_CONFIG")
+	assert.Equal(t, "/etc/app.yaml", config)
+}
+
+func TestCollector_ArgsAndEnv(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	// Program refuses to start without expected arguments and environment
+	const program = `package main
+
+import (
+	"os"
+	"time"
+)
+
+func main() {
+	if len(os.Args) != 3 || os.Args[1] != "-mode" || os.Args[2] != "fast" {
+		os.Exit(2)
+	}
+	if os.Getenv("APP_TOKEN") != "secret" {
+		os.Exit(3)
+	}
+	time.Sleep(2 * time.Second)
+}`
+
+	dir := t.TempDir()
+	programPath := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(programPath, []byte(program), 0666))
+
+	collector := New(programPath, 100, WithArgs("-mode", "fast"), WithEnv("APP_TOKEN=secret"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	snapshots, err := collector.Start(ctx)
+	require.NoError(t, err)
+	defer collector.Stop()
+
+	// SCHED lines appear only after the program passed its checks and keeps running
+	var received int
+	timeout := time.After(2 * time.Second)
+	for received < 3 {
+		select {
+		case _, ok := <-snapshots:
+			require.True(t, ok, "Target exited early, arguments or environment were not passed")
+			received++
+		case <-timeout:
+			t.Fatal("Timed out waiting for snapshots")
+		}
+	}
+}
+
+func TestCollector_LineHook(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	programPath := setupTestProgram(t)
+
+	lines := make(chan domain.OutputLine, 100)
+	collector := New(programPath, 100, WithLineHook(func(line domain.OutputLine) {
+		select {
+		case lines <- line:
+		default:
+		}
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	snapshots, err := collector.Start(ctx)
+	require.NoError(t, err)
+	defer collector.Stop()
+
+	select {
+	case <-snapshots:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for snapshot")
+	}
+
+	// Every parsed snapshot must have passed through the hook first
+	select {
+	case line := <-lines:
+		assert.Equal(t, "stderr", line.Stream)
+		assert.Contains(t, line.Text, "SCHED")
+		assert.False(t, line.Time.IsZero(), "Line should be timestamped")
+	default:
+		t.Fatal("Line hook was not called")
+	}
+}
+
+func TestCollector_SchedDetail(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	programPath := setupTestProgram(t)
+	collector := New(programPath, 100, WithSchedDetail())
+
+	godebug, _ := lookupEnv(collector.environ(), "GODEBUG")
+	assert.Contains(t, godebug, "scheddetail=1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	snapshots, err := collector.Start(ctx)
+	require.NoError(t, err)
+	defer collector.Stop()
+
+	select {
+	case snapshot, ok := <-snapshots:
+		require.True(t, ok, "Should receive a detailed snapshot")
+		require.NotNil(t, snapshot.Detail, "Snapshot should carry scheduler detail")
+		assert.Len(t, snapshot.Detail.Procs, snapshot.GoMaxProcs)
+		assert.NotEmpty(t, snapshot.Detail.Threads)
+		assert.NotEmpty(t, snapshot.Detail.Goroutines)
+		assert.Len(t, snapshot.LRQ, snapshot.GoMaxProcs)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for snapshot")
+	}
+}
+
+func TestCollector_GCTrace(t *testing.T) {
+	t.Setenv("GODEBUG", "")
+
+	godebug, _ := lookupEnv(New("main.go", 100, WithGCTrace()).environ(), "GODEBUG")
+	assert.Equal(t, "schedtrace=100,gctrace=1", godebug)
+
+	godebug, _ = lookupEnv(New("main.go", 100).environ(), "GODEBUG")
+	assert.Equal(t, "schedtrace=100", godebug)
+}
+
+func TestCollector_MetricsChannel(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	// Program reports metrics like metrics.Reporter does when a channel is passed
+	const program = `package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	spec := os.Getenv("GOSCHEDVIZ_METRICS")
+	fd, err := strconv.Atoi(strings.TrimPrefix(spec, "fd:"))
+	if err != nil {
+		os.Exit(2)
+	}
+	out := os.NewFile(uintptr(fd), "metrics")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintln(out, "PROCMETR num_goroutines=77")
+		time.Sleep(50 * time.Millisecond)
+	}
+}`
+
+	dir := t.TempDir()
+	programPath := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(programPath, []byte(program), 0666))
+
+	var mu sync.Mutex
+	streams := make(map[string][]string)
+	collector := New(programPath, 100, WithLineHook(func(line domain.OutputLine) {
+		mu.Lock()
+		defer mu.Unlock()
+		streams[line.Stream] = append(streams[line.Stream], line.Text)
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	snapshots, err := collector.Start(ctx)
+	require.NoError(t, err)
+	defer collector.Stop()
+
+	var got int
+	timeout := time.After(2 * time.Second)
+	for got != 77 {
+		select {
+		case s, ok := <-snapshots:
+			require.True(t, ok, "Target exited early, metrics channel was not passed")
+			got = s.Goroutines
+		case <-timeout:
+			t.Fatal("Timed out waiting for metrics from the channel")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotEmpty(t, streams["metrics"], "Metrics lines should be read from the channel")
+	for _, line := range streams["stderr"] {
+		assert.NotContains(t, line, "PROCMETR", "Stderr must stay clean")
+	}
+}
+
+func TestCollector_Logs(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	const program = `package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+func main() {
+	fmt.Println("hello from stdout")
+	fmt.Fprintln(os.Stderr, "hello from stderr")
+	time.Sleep(2 * time.Second)
+}`
+
+	dir := t.TempDir()
+	programPath := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(programPath, []byte(program), 0666))
+
+	collector := New(programPath, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	snapshots, err := collector.Start(ctx)
+	require.NoError(t, err)
+	defer collector.Stop()
+
+	// Output printed before the first trace line is already collected by then
+	select {
+	case <-snapshots:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for snapshot")
+	}
+
+	require.Eventually(t, func() bool {
+		return len(collector.Logs()) == 2
+	}, time.Second, 10*time.Millisecond, "Both output streams should be collected")
+
+	texts := make(map[string]string)
+	for _, entry := range collector.Logs() {
+		texts[entry.Stream] = entry.Text
+		assert.False(t, entry.Time.IsZero())
+	}
+	assert.Equal(t, map[string]string{
+		"stdout": "hello from stdout",
+		"stderr": "hello from stderr",
+	}, texts, "Trace lines must not be kept as logs")
+}
+
+func TestScanLines_LongLine(t *testing.T) {
+	long := strings.Repeat("x", maxLineSize+100<<10)
+	trace := "SCHED 100ms: gomaxprocs=2 idleprocs=1 threads=4 spinningthreads=0 needspinning=0 idlethreads=1 runqueue=0 [0 0]"
+	lines := make(chan string)
+	go scanLines(strings.NewReader("before\n"+long+"\n"+trace+"\n"), lines, make(chan struct{}))
+
+	var got []string
+	for line := range lines {
+		got = append(got, line)
+	}
+	require.Len(t, got, 3, "Reading goes on after a long line")
+	assert.Equal(t, "before", got[0])
+	assert.Equal(t, long[:maxLineSize], got[1], "Long lines are truncated")
+	assert.Equal(t, trace, got[2])
+}
+
+// exitingProgram prints a few trace periods and exits with code 3.
+const exitingProgram = `package main
+
+import (
+	"os"
+	"time"
+)
+
+func main() {
+	time.Sleep(300 * time.Millisecond)
+	os.Exit(3)
+}`
+
+func TestCollector_Exit(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	dir := t.TempDir()
+	programPath := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(programPath, []byte(exitingProgram), 0666))
+
+	collector := New(programPath, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	snapshots, err := collector.Start(ctx)
+	require.NoError(t, err)
+	defer collector.Stop()
+
+	_, exited := collector.Exit()
+	assert.False(t, exited, "Target is still running")
+
+	for range snapshots {
+	}
+
+	status, exited := collector.Exit()
+	require.True(t, exited)
+	assert.Equal(t, 3, status.Code)
+	assert.Empty(t, status.Signal)
+	assert.False(t, status.Restarting)
+	assert.GreaterOrEqual(t, status.Runtime, 300*time.Millisecond)
+	assert.NoError(t, collector.Stop(), "Stopping an exited target is not an error")
+}
+
+func TestCollector_Restart(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	dir := t.TempDir()
+	programPath := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(programPath, []byte(exitingProgram), 0666))
+
+	collector := New(programPath, 100, WithRestart(RestartOnFailure))
+	collector.restartDelay = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snapshots, err := collector.Start(ctx)
+	require.NoError(t, err)
+	defer collector.Stop()
+
+	// Snapshots keep coming across restarts, the first one of each run is marked
+	restarts := 0
+	var seq uint64
+	for restarts < 2 {
+		select {
+		case snapshot, ok := <-snapshots:
+			require.True(t, ok, "Restarted target should keep the channel open")
+			assert.Equal(t, seq+1, snapshot.Seq, "Sequence continues across restarts")
+			assert.Equal(t, programPath, snapshot.Source)
+			assert.False(t, snapshot.Received.IsZero())
+			seq = snapshot.Seq
+			if snapshot.Marker == domain.MarkerRestart {
+				restarts++
+			}
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for restarts")
+		}
+	}
+	assert.Equal(t, "Restarts: 2", collector.Status())
+
+	require.NoError(t, collector.Stop())
+	for range snapshots {
+	}
+	status, exited := collector.Exit()
+	if exited {
+		assert.False(t, status.Restarting, "Stopped target must not be restarted")
+	}
+}
+
+func TestCollector_BuildDirectory(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	// Nothing is written to the working directory
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	before, err := os.ReadDir(wd)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	programPath := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(programPath, []byte(exitingProgram), 0666))
+
+	collector := New(programPath, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	snapshots, err := collector.Start(ctx)
+	require.NoError(t, err)
+	defer collector.Stop()
+
+	collector.mu.Lock()
+	binary := collector.cmd.Path
+	collector.mu.Unlock()
+	assert.NotEqual(t, wd, filepath.Dir(binary), "Binary should be built in a private directory")
+	assert.Equal(t, "main", filepath.Base(binary))
+
+	after, err := os.ReadDir(wd)
+	require.NoError(t, err)
+	assert.Equal(t, len(before), len(after))
+
+	for range snapshots {
+	}
+	assert.NoDirExists(t, filepath.Dir(binary), "Build directory should be removed after exit")
+}
+
+func TestCollector_BuildOptions(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	// The program compiles only with the "special" build tag
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/tagged\n\ngo 1.23\n"), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(`package main
+
+import "time"
+
+func main() {
+	special()
+	time.Sleep(2 * time.Second)
+}`), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "special.go"), []byte(`//go:build special
+
+package main
+
+func special() {}`), 0666))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := New(dir, 100).Start(ctx)
+	assert.Error(t, err, "Build without the tag should fail")
+
+	collector := New(dir, 100, WithBuildOptions(BuildOptions{Tags: "special", TrimPath: true}))
+	snapshots, err := collector.Start(ctx)
+	require.NoError(t, err)
+	defer collector.Stop()
+
+	select {
+	case _, ok := <-snapshots:
+		assert.True(t, ok)
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for snapshot")
+	}
+
+	// Build flags make no sense for prebuilt executables
+	_, err = New(setupTestBinary(t), 100, WithBuildOptions(BuildOptions{Race: true})).Start(ctx)
+	assert.Error(t, err)
+}
+
+func TestCollector_Test(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	// The test reads testdata relative to the package directory, like with "go test"
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/tested\n\ngo 1.23\n"), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib.go"), []byte("package tested\n"), 0666))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "testdata"), 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "testdata", "input.txt"), []byte("ok"), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib_test.go"), []byte(`package tested
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSlow(t *testing.T) {
+	if _, err := os.Stat("testdata/input.txt"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+}
+
+func TestFails(t *testing.T) {
+	t.Fatal("selected with -test.run only")
+}`), 0666))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	collector := New(dir, 100, WithTest(), WithArgs("-test.run=TestSlow"))
+	snapshots, err := collector.Start(ctx)
+	require.NoError(t, err)
+	defer collector.Stop()
+
+	count := 0
+	for range snapshots {
+		count++
+	}
+	assert.Positive(t, count)
+	status, exited := collector.Exit()
+	require.True(t, exited)
+	assert.False(t, status.Failed(), status.String())
+
+	// Tests can only be built for packages
+	_, err = New(setupTestProgram(t), 100, WithTest()).Start(ctx)
+	assert.ErrorContains(t, err, "only be run for a package")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "lib_test.go")))
+	_, err = New(dir, 100, WithTest()).Start(ctx)
+	assert.ErrorContains(t, err, "no test files")
+}
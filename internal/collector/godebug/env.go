@@ -0,0 +1,66 @@
+package godebug
+
+import (
+	"strings"
+)
+
+// mergeEnv applies overrides in "KEY=VALUE" form on top of base environment.
+// Later values win; the relative order of existing keys is preserved.
+func mergeEnv(base []string, overrides ...string) []string {
+	result := make([]string, 0, len(base)+len(overrides))
+	index := make(map[string]int, len(base)+len(overrides))
+
+	for _, kv := range append(append([]string(nil), base...), overrides...) {
+		key, _, _ := strings.Cut(kv, "=")
+		if i, ok := index[key]; ok {
+			result[i] = kv
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, kv)
+	}
+
+	return result
+}
+
+// lookupEnv returns the value of key in env and whether it was found.
+func lookupEnv(env []string, key string) (string, bool) {
+	for i := len(env) - 1; i >= 0; i-- {
+		if k, v, ok := strings.Cut(env[i], "="); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// mergeGodebug merges settings in "key=value" form into a comma-separated
+// GODEBUG value. Existing keys are replaced in place, new keys are appended.
+//
+// Example:
+//
+//	mergeGodebug("madvdontneed=1,schedtrace=10", "schedtrace=1000")
+//	// "madvdontneed=1,schedtrace=1000"
+func mergeGodebug(value string, settings ...string) string {
+	var pairs []string
+	for _, kv := range strings.Split(value, ",") {
+		if kv = strings.TrimSpace(kv); kv != "" {
+			pairs = append(pairs, kv)
+		}
+	}
+
+	for _, setting := range settings {
+		key, _, _ := strings.Cut(setting, "=")
+		replaced := false
+		for i, kv := range pairs {
+			if k, _, _ := strings.Cut(kv, "="); k == key {
+				pairs[i] = setting
+				replaced = true
+			}
+		}
+		if !replaced {
+			pairs = append(pairs, setting)
+		}
+	}
+
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,95 @@
+package godebug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      []string
+		overrides []string
+		want      []string
+	}{
+		{
+			name: "no overrides",
+			base: []string{"HOME=/root", "PATH=/bin"},
+			want: []string{"HOME=/root", "PATH=/bin"},
+		},
+		{
+			name:      "override keeps position",
+			base:      []string{"HOME=/root", "PATH=/bin"},
+			overrides: []string{"HOME=/home/app"},
+			want:      []string{"HOME=/home/app", "PATH=/bin"},
+		},
+		{
+			name:      "new keys appended, last value wins",
+			base:      []string{"PATH=/bin"},
+			overrides: []string{"APP_ENV=dev", "APP_ENV=prod"},
+			want:      []string{"PATH=/bin", "APP_ENV=prod"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mergeEnv(tt.base, tt.overrides...))
+		})
+	}
+}
+
+func TestLookupEnv(t *testing.T) {
+	env := []string{"A=1", "GODEBUG=gctrace=1", "A=2"}
+
+	v, ok := lookupEnv(env, "A")
+	assert.True(t, ok)
+	assert.Equal(t, "2", v, "last value should win")
+
+	v, ok = lookupEnv(env, "GODEBUG")
+	assert.True(t, ok)
+	assert.Equal(t, "gctrace=1", v)
+
+	_, ok = lookupEnv(env, "MISSING")
+	assert.False(t, ok)
+}
+
+func TestMergeGodebug(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		settings []string
+		want     string
+	}{
+		{
+			name:     "empty value",
+			value:    "",
+			settings: []string{"schedtrace=1000"},
+			want:     "schedtrace=1000",
+		},
+		{
+			name:     "user settings preserved",
+			value:    "gctrace=1,madvdontneed=1",
+			settings: []string{"schedtrace=1000"},
+			want:     "gctrace=1,madvdontneed=1,schedtrace=1000",
+		},
+		{
+			name:     "existing key replaced in place",
+			value:    "schedtrace=10,gctrace=1",
+			settings: []string{"schedtrace=500"},
+			want:     "schedtrace=500,gctrace=1",
+		},
+		{
+			name:     "whitespace and empty items dropped",
+			value:    " gctrace=1 ,, ",
+			settings: []string{"schedtrace=1000"},
+			want:     "gctrace=1,schedtrace=1000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mergeGodebug(tt.value, tt.settings...))
+		})
+	}
+}
@@ -1,188 +1,403 @@
 package godebug
 
 import (
-	"regexp"
+	"errors"
+	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/JustSkiv/goschedviz/internal/domain"
 )
 
+// Validation selects how strictly parsed snapshots are checked.
+type Validation int
+
+const (
+	// ValidationDefault rejects snapshots with inconsistent values.
+	ValidationDefault Validation = iota
+	// ValidationStrict also rejects snapshots where all run queues, idle and spinning
+	// counters are zero. That's legitimate for a busy program, but it's also
+	// what broken trace output looks like.
+	ValidationStrict
+	// ValidationLenient keeps every snapshot that could be parsed and reports
+	// inconsistent ones as suspicious.
+	ValidationLenient
+)
+
+// ParseError explains why a line didn't produce a snapshot as is.
+type ParseError struct {
+	Reason domain.ParseReason
+	Detail string
+}
+
+func (e *ParseError) Error() string {
+	return e.Reason.String() + ": " + e.Detail
+}
+
+// ParserOption configures a Parser.
+type ParserOption func(*Parser)
+
+// ParserValidation sets how strictly parsed snapshots are checked.
+func ParserValidation(v Validation) ParserOption {
+	return func(p *Parser) {
+		p.validation = v
+	}
+}
+
+// ParserDiagnostics counts outcomes of parsed lines in d.
+func ParserDiagnostics(d *domain.ParseDiagnostics) ParserOption {
+	return func(p *Parser) {
+		p.diagnostics = d
+	}
+}
+
 // Parser handles parsing of GODEBUG schedtrace output.
 type Parser struct {
-	// Example of schedtrace output:
-	// SCHED 2013ms: gomaxprocs=14 idleprocs=14 threads=22 spinningthreads=0 needspinning=0 idlethreads=17 runqueue=0 [0 0 0 0 0 0 0 0 0 0 0 0 0 0]
-	regex *regexp.Regexp
 	// lastGoroutines holds the last seen goroutines count from metrics
 	lastGoroutines int
+	// lastMetrics holds the last seen runtime/metrics values, replaced by every metrics line
+	lastMetrics map[string]float64
+	// lastHistograms holds the last seen runtime/metrics histograms
+	lastHistograms map[string]domain.Histogram
+	// lastProfile holds the last seen goroutine profile summary
+	lastProfile *domain.GoroutineProfile
+	// pending is a scheddetail snapshot waiting for the rest of its block
+	pending *domain.SchedulerSnapshot
+	// gcCycles holds GC cycles seen since the last returned snapshot
+	gcCycles []domain.GCCycle
+
+	validation  Validation
+	diagnostics *domain.ParseDiagnostics // nil if outcomes are not counted
 }
 
-// parseMetrics attempts to parse process metrics line.
-// Returns number of goroutines if successful, or -1 if parsing failed.
-func (p *Parser) parseMetrics(line string) int {
-	if !strings.HasPrefix(line, "PROCMETR") {
-		return -1
+// goroutinesMetric is the runtime/metrics name of the goroutines count.
+const goroutinesMetric = "/sched/goroutines:goroutines"
+
+// processMetrics holds values of a process metrics line.
+type processMetrics struct {
+	goroutines int // -1 if the line has none
+	values     map[string]float64
+	histograms map[string]domain.Histogram
+	profile    *domain.GoroutineProfile // nil if the line has no goroutine profile summary
+}
+
+// parseMetrics parses a process metrics line of metrics.Reporter:
+//
+//	PROCMETR num_goroutines=1234 /gc/cycles/total:gc-cycles=12 /sched/latencies:seconds=1e-06:15,inf:2
+//	PROCMETR num_goroutines=1004 goroutine_states=running:1,chan_receive:1003 goroutine_sites=main.worker:1000
+//
+// ok is false if any field is malformed.
+func (p *Parser) parseMetrics(line string) (m processMetrics, ok bool) {
+	fields := strings.Fields(strings.TrimPrefix(line, "PROCMETR"))
+	if !strings.HasPrefix(line, "PROCMETR") || len(fields) == 0 {
+		return processMetrics{}, false
 	}
 
-	// Extract goroutines count from format "PROCMETR num_goroutines=1234"
-	parts := strings.Split(line, "=")
-	if len(parts) != 2 {
-		return -1
+	m.goroutines = -1
+	for _, field := range fields {
+		name, value, found := strings.Cut(field, "=")
+		if !found {
+			return processMetrics{}, false
+		}
+		switch {
+		case name == "num_goroutines":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return processMetrics{}, false
+			}
+			m.goroutines = n
+		case name == "goroutine_states" || name == "goroutine_sites":
+			counts, ok := parseCounts(value)
+			if !ok {
+				return processMetrics{}, false
+			}
+			if m.profile == nil {
+				m.profile = &domain.GoroutineProfile{}
+			}
+			if name == "goroutine_states" {
+				m.profile.States = make(map[string]int, len(counts))
+				for _, c := range counts {
+					m.profile.States[c.Function] = c.Count
+				}
+			} else {
+				m.profile.Sites = counts
+			}
+		case strings.Contains(value, ":"):
+			h, ok := parseHistogram(value)
+			if !ok {
+				return processMetrics{}, false
+			}
+			if m.histograms == nil {
+				m.histograms = make(map[string]domain.Histogram)
+			}
+			m.histograms[name] = h
+		default:
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return processMetrics{}, false
+			}
+			if m.values == nil {
+				m.values = make(map[string]float64)
+			}
+			m.values[name] = v
+		}
 	}
 
-	count, err := strconv.Atoi(strings.TrimSpace(parts[1]))
-	if err != nil {
-		return -1
+	// Reporters configured without the legacy field still have the runtime metric
+	if v, found := m.values[goroutinesMetric]; found && m.goroutines < 0 {
+		m.goroutines = int(v)
+	}
+	return m, true
+}
+
+// parseCounts parses comma-separated name:count pairs of a goroutine profile summary.
+// Names may contain colons, the count follows the last one.
+func parseCounts(value string) ([]domain.GoroutineSite, bool) {
+	var counts []domain.GoroutineSite
+	for _, pair := range strings.Split(value, ",") {
+		i := strings.LastIndex(pair, ":")
+		if i <= 0 {
+			return nil, false
+		}
+		n, err := strconv.Atoi(pair[i+1:])
+		if err != nil {
+			return nil, false
+		}
+		counts = append(counts, domain.GoroutineSite{Function: pair[:i], Count: n})
+	}
+	return counts, true
+}
+
+// parseHistogram parses comma-separated upper bound:count pairs, "inf" is the unbounded bucket.
+func parseHistogram(value string) (domain.Histogram, bool) {
+	var h domain.Histogram
+	for _, pair := range strings.Split(value, ",") {
+		bound, count, found := strings.Cut(pair, ":")
+		if !found {
+			return domain.Histogram{}, false
+		}
+		upper := math.MaxFloat64
+		if bound != "inf" {
+			v, err := strconv.ParseFloat(bound, 64)
+			if err != nil {
+				return domain.Histogram{}, false
+			}
+			upper = v
+		}
+		n, err := strconv.ParseUint(count, 10, 64)
+		if err != nil {
+			return domain.Histogram{}, false
+		}
+		h.Upper = append(h.Upper, upper)
+		h.Counts = append(h.Counts, n)
 	}
+	return h, true
+}
 
-	return count
+// IsTrace reports whether the line is runtime trace or process metrics output
+// rather than output of the monitored program itself.
+func IsTrace(line string) bool {
+	return strings.HasPrefix(line, "SCHED ") ||
+		strings.HasPrefix(line, "PROCMETR") ||
+		strings.HasPrefix(line, "scvg") ||
+		gcRegex.MatchString(line) ||
+		detailLineRegex.MatchString(line)
 }
 
 // NewParser creates a new GODEBUG output parser.
-func NewParser() *Parser {
-	return &Parser{
-		regex: regexp.MustCompile(
-			// Separate into capturing groups carefully
-			`^SCHED\s+` + // Prefix
-				`(\d+)ms:\s+` + // TimeMs (group 1)
-				`gomaxprocs=(\d+)\s+` + // GoMaxProcs (group 2)
-				`idleprocs=(\d+)\s+` + // IdleProcs (group 3)
-				`threads=(\d+)\s+` + // Threads (group 4)
-				`spinningthreads=(\d+)\s+` + // SpinningThreads (group 5)
-				`needspinning=(\d+)\s+` + // NeedSpinning (group 6)
-				`idlethreads=(\d+)\s+` + // IdleThreads (group 7)
-				`runqueue=(\d+)\s+` + // RunQueue (group 8)
-				`\[([\d\s]+)\]`, // LRQ values (group 9)
-		),
-	}
-}
-
-// isValidSnapshot performs additional validation of the scheduler snapshot data.
-// Returns false if any of the validation rules fail.
-func (p *Parser) isValidSnapshot(s domain.SchedulerSnapshot) bool {
+func NewParser(opts ...ParserOption) *Parser {
+	p := &Parser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// validateSnapshot performs additional validation of the scheduler snapshot data.
+// Returns a description of the first failed rule, empty if the snapshot is valid.
+func (p *Parser) validateSnapshot(s domain.SchedulerSnapshot) string {
 	// Check for valid GOMAXPROCS value
 	if s.GoMaxProcs <= 0 {
-		return false
+		return fmt.Sprintf("gomaxprocs=%d is not positive", s.GoMaxProcs)
 	}
 
 	// Verify that idle processors count doesn't exceed total processors
 	if s.IdleProcs > s.GoMaxProcs {
-		return false
+		return fmt.Sprintf("idleprocs=%d exceeds gomaxprocs=%d", s.IdleProcs, s.GoMaxProcs)
 	}
 
 	// Verify that LRQ length matches GOMAXPROCS
 	if len(s.LRQ) != s.GoMaxProcs {
-		return false
+		return fmt.Sprintf("%d local run queues for gomaxprocs=%d", len(s.LRQ), s.GoMaxProcs)
 	}
 
 	// Validate thread counts consistency
 	if s.Threads < s.SpinningThreads || s.Threads < s.IdleThreads {
-		return false
+		return fmt.Sprintf("threads=%d is less than spinning or idle threads", s.Threads)
 	}
 
 	// Calculate and validate queue totals
 	calculatedSum := s.RunQueue
 	for _, v := range s.LRQ {
 		if v < 0 { // Queue lengths cannot be negative
-			return false
+			return "negative local run queue length"
 		}
 		calculatedSum += v
 	}
 
-	// Suspect data detection: all zeros might indicate invalid trace output
-	if calculatedSum == 0 && s.IdleProcs == 0 && s.SpinningThreads == 0 {
-		return false
+	// All Ps busy with empty queues is legitimate for a busy program,
+	// but it's also what broken trace output looks like
+	if p.validation == ValidationStrict && calculatedSum == 0 && s.IdleProcs == 0 && s.SpinningThreads == 0 {
+		return "all run queues, idle and spinning counters are zero"
 	}
 
-	return true
+	return ""
+}
+
+// check validates a parsed snapshot according to the validation mode.
+// In lenient mode invalid snapshots are kept and reported as suspicious.
+func (p *Parser) check(s domain.SchedulerSnapshot) (domain.SchedulerSnapshot, bool, error) {
+	problem := p.validateSnapshot(s)
+	if problem == "" {
+		p.attachGC(&s)
+		return s, true, nil
+	}
+	if p.validation == ValidationLenient {
+		p.attachGC(&s)
+		return s, true, &ParseError{Reason: domain.ParseSuspicious, Detail: problem}
+	}
+	return domain.SchedulerSnapshot{}, false, &ParseError{Reason: domain.ParseRejected, Detail: problem}
 }
 
 // Parse attempts to parse a single line of schedtrace output.
-// Returns the parsed snapshot and true if successful, or zero value and false if parsing failed.
+// Returns the parsed snapshot and true if successful, or zero value and false otherwise.
+// The outcome is counted in diagnostics, if any.
 func (p *Parser) Parse(line string) (domain.SchedulerSnapshot, bool) {
+	snapshot, ok, err := p.ParseLine(line)
+	p.record(true, line, snapshot, ok, err)
+	return snapshot, ok
+}
+
+// ParseLine parses a single line of schedtrace output and explains the outcome.
+// Lines that don't produce a snapshot by themselves, e.g. PROCMETR, gc and
+// scheddetail lines, return false and no error. Otherwise the error is a *ParseError.
+// A snapshot kept in lenient mode is returned along with a ParseSuspicious error.
+func (p *Parser) ParseLine(line string) (domain.SchedulerSnapshot, bool, error) {
 	// Try to parse metrics line first
-	if goroutines := p.parseMetrics(line); goroutines >= 0 {
-		p.lastGoroutines = goroutines
-		return domain.SchedulerSnapshot{}, false
+	if strings.HasPrefix(line, "PROCMETR") {
+		m, ok := p.parseMetrics(line)
+		if !ok {
+			return domain.SchedulerSnapshot{}, false, &ParseError{Reason: domain.ParseMalformed, Detail: "expected \"PROCMETR name=<n> ...\""}
+		}
+		if m.goroutines >= 0 {
+			p.lastGoroutines = m.goroutines
+		}
+		p.lastMetrics, p.lastHistograms, p.lastProfile = m.values, m.histograms, m.profile
+		return domain.SchedulerSnapshot{}, false, nil
 	}
 
-	matches := p.regex.FindStringSubmatch(line)
-	if len(matches) != 10 { // 1 full match + 9 groups
-		return domain.SchedulerSnapshot{}, false
+	// GC cycles are attached to the next snapshot
+	if cycle, ok := parseGCLine(line); ok {
+		p.gcCycles = append(p.gcCycles, cycle)
+		return domain.SchedulerSnapshot{}, false, nil
 	}
 
-	// Parse all integer values
-	timeMs, err := strconv.Atoi(matches[1])
-	if err != nil {
-		return domain.SchedulerSnapshot{}, false
+	// P, M and G lines belong to the pending scheddetail block
+	if p.pending != nil && parseDetailLine(p.pending.Detail, line) {
+		return domain.SchedulerSnapshot{}, false, nil
 	}
 
-	gmp, err := strconv.Atoi(matches[2])
-	if err != nil {
-		return domain.SchedulerSnapshot{}, false
+	if !strings.HasPrefix(line, "SCHED") {
+		return domain.SchedulerSnapshot{}, false, &ParseError{Reason: domain.ParseUnmatched, Detail: "not scheduler trace output"}
 	}
 
-	idleProcs, err := strconv.Atoi(matches[3])
+	sched, err := parseSchedLine(line)
 	if err != nil {
-		return domain.SchedulerSnapshot{}, false
+		return domain.SchedulerSnapshot{}, false, err
 	}
-
-	threads, err := strconv.Atoi(matches[4])
+	snapshot, err := sched.snapshot()
 	if err != nil {
-		return domain.SchedulerSnapshot{}, false
+		return domain.SchedulerSnapshot{}, false, err
 	}
 
-	spinTh, err := strconv.Atoi(matches[5])
-	if err != nil {
-		return domain.SchedulerSnapshot{}, false
+	// Detailed header completes the previous block and starts a new one
+	if sched.detailed() {
+		previous, ok, err := p.flush()
+		p.pending = &snapshot
+		return previous, ok, err
 	}
 
-	needSpin, err := strconv.Atoi(matches[6])
-	if err != nil {
-		return domain.SchedulerSnapshot{}, false
+	// Parse LRQ values
+	fields := strings.Fields(sched.lrq)
+	snapshot.LRQ = make([]int, len(fields))
+	for i, s := range fields {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return domain.SchedulerSnapshot{}, false, &ParseError{Reason: domain.ParseMalformed, Detail: "invalid local run queue length"}
+		}
+		snapshot.LRQ[i] = n
+		snapshot.LRQSum += n
 	}
+	snapshot.Goroutines = p.lastGoroutines
+	snapshot.Metrics = p.lastMetrics
+	snapshot.Histograms = p.lastHistograms
+	snapshot.GoroutineProfile = p.lastProfile
 
-	idleThreads, err := strconv.Atoi(matches[7])
-	if err != nil {
-		return domain.SchedulerSnapshot{}, false
-	}
+	return p.check(snapshot)
+}
 
-	runQ, err := strconv.Atoi(matches[8])
-	if err != nil {
-		return domain.SchedulerSnapshot{}, false
+// Flush returns the pending scheddetail snapshot, if any.
+// Detail blocks are completed by the next SCHED line, so collectors call Flush
+// at the end of the stream to get the last one.
+func (p *Parser) Flush() (domain.SchedulerSnapshot, bool) {
+	snapshot, ok, err := p.flush()
+	p.record(false, "", snapshot, ok, err)
+	return snapshot, ok
+}
+
+// flush completes the pending scheddetail snapshot.
+func (p *Parser) flush() (domain.SchedulerSnapshot, bool, error) {
+	if p.pending == nil {
+		return domain.SchedulerSnapshot{}, false, nil
 	}
 
-	// Parse LRQ values
-	fields := strings.Fields(matches[9])
-	lrqVals := make([]int, len(fields))
-	sumLRQ := 0
-	for i, s := range fields {
-		if n, err := strconv.Atoi(s); err == nil {
-			lrqVals[i] = n
-			sumLRQ += n
-		} else {
-			return domain.SchedulerSnapshot{}, false
-		}
+	snapshot := *p.pending
+	p.pending = nil
+
+	completeDetail(&snapshot)
+	snapshot.Goroutines = p.lastGoroutines
+	snapshot.Metrics = p.lastMetrics
+	snapshot.Histograms = p.lastHistograms
+	snapshot.GoroutineProfile = p.lastProfile
+	if snapshot.Goroutines == 0 {
+		// Without process metrics the G lines still tell how many goroutines exist
+		snapshot.Goroutines = liveGoroutines(snapshot.Detail)
 	}
 
-	snapshot := domain.SchedulerSnapshot{
-		TimeMs:          timeMs,
-		GoMaxProcs:      gmp,
-		IdleProcs:       idleProcs,
-		Threads:         threads,
-		SpinningThreads: spinTh,
-		NeedSpinning:    needSpin,
-		IdleThreads:     idleThreads,
-		RunQueue:        runQ,
-		LRQSum:          sumLRQ,
-		LRQ:             lrqVals,
-		Goroutines:      p.lastGoroutines,
+	return p.check(snapshot)
+}
+
+// record counts the outcome of a parsed line in diagnostics.
+func (p *Parser) record(consumed bool, line string, snapshot domain.SchedulerSnapshot, ok bool, err error) {
+	if p.diagnostics == nil {
+		return
 	}
 
-	// Perform additional validation of the snapshot
-	if !p.isValidSnapshot(snapshot) {
-		return domain.SchedulerSnapshot{}, false
+	var issue *domain.ParseIssue
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		issue = &domain.ParseIssue{Time: time.Now(), Reason: pe.Reason, Detail: pe.Detail, Line: line}
 	}
+	p.diagnostics.Record(consumed, ok, issue)
+	if ok {
+		p.diagnostics.Detect(snapshot.FieldSet, snapshot.Extra)
+	}
+}
 
-	return snapshot, true
+// attachGC moves GC cycles seen since the previous snapshot to the snapshot.
+func (p *Parser) attachGC(s *domain.SchedulerSnapshot) {
+	s.GC = p.gcCycles
+	p.gcCycles = nil
 }
@@ -0,0 +1,137 @@
+package godebug
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// With GODEBUG scheddetail=1 the runtime prints a block per trace period:
+//
+//	SCHED 107ms: gomaxprocs=2 idleprocs=0 threads=5 spinningthreads=0 needspinning=1 idlethreads=1 runqueue=1 gcwaiting=false nmidlelocked=0 stopwait=0 sysmonwait=false
+//	  P0: status=1 schedtick=92802 syscalltick=0 m=2 runqsize=0 gfreecnt=0 timerslen=1
+//	  M2: p=0 curg=3 mallocing=0 throwing=0 preemptoff= locks=16 dying=0 spinning=false blocked=false lockedg=nil
+//	  G3: status=4(chan receive) m=nil lockedm=nil
+//
+// The header has no per-P run queue list, run queues come with P lines instead.
+// A block is complete only when the next header arrives, so detailed snapshots
+// are returned one trace period late, or by Flush at the end of the stream.
+
+// detailLineRegex matches indented P, M and G lines of a detail block.
+var detailLineRegex = regexp.MustCompile(`^\s+([PMG])(\d+):\s*(.*)$`)
+
+// parseDetailLine adds a P, M or G line to the detail block.
+// Returns false if the line is not part of a detail block.
+func parseDetailLine(detail *domain.SchedDetail, line string) bool {
+	m := detailLineRegex.FindStringSubmatch(line)
+	if len(m) != 4 {
+		return false
+	}
+
+	id, err := strconv.Atoi(m[2])
+	if err != nil {
+		return false
+	}
+	fields := splitFields(m[3])
+
+	switch m[1] {
+	case "P":
+		detail.Procs = append(detail.Procs, domain.ProcState{
+			ID:          id,
+			Status:      domain.ProcStatus(parseInt(fields["status"])),
+			SchedTick:   parseUint(fields["schedtick"]),
+			SyscallTick: parseUint(fields["syscalltick"]),
+			M:           parseRef(fields["m"]),
+			RunqSize:    parseInt(fields["runqsize"]),
+			GFreeCnt:    parseInt(fields["gfreecnt"]),
+			TimersLen:   parseInt(fields["timerslen"]),
+		})
+	case "M":
+		detail.Threads = append(detail.Threads, domain.ThreadState{
+			ID:       id,
+			P:        parseRef(fields["p"]),
+			CurG:     parseRef(fields["curg"]),
+			Spinning: parseFlag(fields["spinning"]),
+			Blocked:  parseFlag(fields["blocked"]),
+			LockedG:  parseRef(fields["lockedg"]),
+		})
+	case "G":
+		status, reason := parseGoroutineStatus(fields["status"])
+		detail.Goroutines = append(detail.Goroutines, domain.GoroutineState{
+			ID:         id,
+			Status:     status,
+			WaitReason: reason,
+			M:          parseRef(fields["m"]),
+			LockedM:    parseRef(fields["lockedm"]),
+		})
+	}
+
+	return true
+}
+
+// completeDetail fills summary fields of a detailed snapshot from its P and G lines.
+func completeDetail(s *domain.SchedulerSnapshot) {
+	s.LRQ = make([]int, len(s.Detail.Procs))
+	s.LRQSum = 0
+	for i, p := range s.Detail.Procs {
+		s.LRQ[i] = p.RunqSize
+		s.LRQSum += p.RunqSize
+	}
+}
+
+// liveGoroutines counts goroutines that are not dead.
+func liveGoroutines(d *domain.SchedDetail) int {
+	n := 0
+	for _, g := range d.Goroutines {
+		if g.Status != domain.GoroutineDead {
+			n++
+		}
+	}
+	return n
+}
+
+// splitFields splits "key=value" pairs separated by spaces.
+// Spaces inside parentheses belong to the value: "status=4(chan receive)".
+func splitFields(s string) map[string]string {
+	fields := make(map[string]string)
+	for _, token := range splitTokens(s) {
+		if key, value, ok := strings.Cut(token, "="); ok {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// parseGoroutineStatus splits "4(chan receive)" into status code and wait reason.
+func parseGoroutineStatus(v string) (domain.GoroutineStatus, string) {
+	code, reason, _ := strings.Cut(v, "(")
+	return domain.GoroutineStatus(parseInt(code)), strings.TrimSuffix(reason, ")")
+}
+
+// parseRef parses an ID reference where "nil" means no reference.
+func parseRef(v string) int {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return domain.NoID
+	}
+	return n
+}
+
+// parseFlag parses boolean values printed either as true/false or as 1/0.
+func parseFlag(v string) bool {
+	return v == "true" || v == "1"
+}
+
+// parseInt parses an integer, returning 0 for malformed values.
+func parseInt(v string) int {
+	n, _ := strconv.Atoi(v)
+	return n
+}
+
+// parseUint parses an unsigned integer, returning 0 for malformed values.
+func parseUint(v string) uint64 {
+	n, _ := strconv.ParseUint(v, 10, 64)
+	return n
+}
@@ -0,0 +1,123 @@
+package godebug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+var detailBlock = []string{
+	"SCHED 107ms: gomaxprocs=2 idleprocs=0 threads=5 spinningthreads=0 needspinning=1 idlethreads=1 runqueue=1 gcwaiting=false nmidlelocked=0 stopwait=0 sysmonwait=false",
+	"  P0: status=1 schedtick=92802 syscalltick=0 m=2 runqsize=3 gfreecnt=0 timerslen=1",
+	"  P1: status=1 schedtick=129029 syscalltick=4 m=0 runqsize=1 gfreecnt=2 timerslen=0",
+	"  M4: p=nil curg=nil mallocing=0 throwing=0 preemptoff= locks=0 dying=0 spinning=false blocked=true lockedg=nil",
+	"  M2: p=0 curg=3 mallocing=0 throwing=0 preemptoff= locks=16 dying=0 spinning=false blocked=false lockedg=nil",
+	"  M0: p=1 curg=1 mallocing=0 throwing=0 preemptoff= locks=0 dying=0 spinning=true blocked=false lockedg=1",
+	"  G1: status=2(sleep) m=0 lockedm=0",
+	"  G2: status=4(force gc (idle)) m=nil lockedm=nil",
+	"  G3: status=2() m=2 lockedm=nil",
+	"  G6: status=4(chan receive) m=nil lockedm=nil",
+	"  G7: status=4(chan receive) m=nil lockedm=nil",
+	"  G8: status=6() m=nil lockedm=nil",
+}
+
+const nextDetailHeader = "SCHED 207ms: gomaxprocs=2 idleprocs=2 threads=5 spinningthreads=0 needspinning=0 idlethreads=3 runqueue=0 gcwaiting=false nmidlelocked=0 stopwait=0 sysmonwait=false"
+
+func TestParser_DetailBlock(t *testing.T) {
+	parser := NewParser()
+
+	for _, line := range detailBlock {
+		_, ok := parser.Parse(line)
+		assert.False(t, ok, "block must not be emitted before it is complete: %q", line)
+	}
+
+	// Next header completes the block
+	s, ok := parser.Parse(nextDetailHeader)
+	require.True(t, ok)
+
+	assert.Equal(t, 107, s.TimeMs)
+	assert.Equal(t, 2, s.GoMaxProcs)
+	assert.Equal(t, 1, s.RunQueue)
+	assert.Equal(t, []int{3, 1}, s.LRQ, "LRQ should come from P runqsize")
+	assert.Equal(t, 4, s.LRQSum)
+	assert.Equal(t, 5, s.Goroutines, "without metrics goroutines are counted from G lines")
+
+	require.NotNil(t, s.Detail)
+	d := s.Detail
+	assert.False(t, d.GCWaiting)
+	assert.False(t, d.SysmonWait)
+
+	require.Len(t, d.Procs, 2)
+	assert.Equal(t, domain.ProcState{
+		ID: 1, Status: domain.ProcRunning, SchedTick: 129029, SyscallTick: 4,
+		M: 0, RunqSize: 1, GFreeCnt: 2, TimersLen: 0,
+	}, d.Procs[1])
+
+	require.Len(t, d.Threads, 3)
+	assert.Equal(t, domain.ThreadState{ID: 4, P: domain.NoID, CurG: domain.NoID, Blocked: true, LockedG: domain.NoID}, d.Threads[0])
+	m0, ok := d.Thread(0)
+	require.True(t, ok)
+	assert.Equal(t, 1, m0.P)
+	assert.Equal(t, 1, m0.LockedG)
+	assert.True(t, m0.Spinning)
+
+	require.Len(t, d.Goroutines, 6)
+	assert.Equal(t, domain.GoroutineState{
+		ID: 2, Status: domain.GoroutineWaiting, WaitReason: "force gc (idle)", M: domain.NoID, LockedM: domain.NoID,
+	}, d.Goroutines[1])
+	assert.Equal(t, map[string]int{"force gc (idle)": 1, "chan receive": 2}, d.WaitReasons())
+}
+
+func TestParser_DetailFlush(t *testing.T) {
+	parser := NewParser()
+
+	_, ok := parser.Flush()
+	assert.False(t, ok, "nothing to flush on a fresh parser")
+
+	parser.Parse("PROCMETR num_goroutines=42")
+	for _, line := range detailBlock {
+		parser.Parse(line)
+	}
+
+	s, ok := parser.Flush()
+	require.True(t, ok)
+	assert.Equal(t, 107, s.TimeMs)
+	assert.Equal(t, 42, s.Goroutines, "process metrics take precedence over G lines")
+
+	_, ok = parser.Flush()
+	assert.False(t, ok, "block must be flushed only once")
+}
+
+func TestParser_DetailLinesWithoutHeader(t *testing.T) {
+	parser := NewParser()
+
+	// Stream started in the middle of a block
+	for _, line := range detailBlock[1:] {
+		_, ok := parser.Parse(line)
+		assert.False(t, ok)
+	}
+	_, ok := parser.Flush()
+	assert.False(t, ok)
+}
+
+func TestSplitFields(t *testing.T) {
+	tests := []struct {
+		input string
+		want  map[string]string
+	}{
+		{"", map[string]string{}},
+		{"status=1 m=nil", map[string]string{"status": "1", "m": "nil"}},
+		{"status=4(chan receive) m=nil", map[string]string{"status": "4(chan receive)", "m": "nil"}},
+		{"status=4(force gc (idle)) lockedm=0", map[string]string{"status": "4(force gc (idle))", "lockedm": "0"}},
+		{"preemptoff= locks=0 junk", map[string]string{"preemptoff": "", "locks": "0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, splitFields(tt.input))
+		})
+	}
+}
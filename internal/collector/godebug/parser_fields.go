@@ -0,0 +1,226 @@
+package godebug
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// A SCHED line is a list of key=value fields followed by per-P run queue lengths:
+//
+//	SCHED 2013ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1 0]
+//
+// Go releases add fields from time to time, so fields are looked up by name
+// rather than position. Fields goschedviz doesn't know are kept as is.
+
+// FieldSet is the set of SCHED line fields printed by a range of Go releases.
+type FieldSet struct {
+	Name   string // Go releases, e.g. "go1.21+"
+	Fields []string
+}
+
+// FieldSets lists known SCHED field sets, newest first.
+var FieldSets = []FieldSet{
+	{Name: "go1.21+", Fields: []string{"gomaxprocs", "idleprocs", "threads", "spinningthreads", "needspinning", "idlethreads", "runqueue"}},
+	{Name: "go1.5-go1.20", Fields: []string{"gomaxprocs", "idleprocs", "threads", "spinningthreads", "idlethreads", "runqueue"}},
+	{Name: "go1.1-go1.4", Fields: []string{"gomaxprocs", "idleprocs", "threads", "idlethreads", "runqueue"}},
+}
+
+// detailFields are SCHED line fields added by GODEBUG scheddetail=1.
+var detailFields = []string{"gcwaiting", "nmidlelocked", "stopwait", "sysmonwait"}
+
+// schedLine is a tokenized SCHED line.
+type schedLine struct {
+	timeMs int
+	fields map[string]string
+	lrq    string // Per-P run queue lengths without brackets
+	hasLRQ bool
+}
+
+// detailed reports whether the line is the header of a scheddetail block.
+func (l schedLine) detailed() bool {
+	_, ok := l.fields["gcwaiting"]
+	return ok && !l.hasLRQ
+}
+
+// fieldSet returns the name of the newest field set the line has all fields of.
+func (l schedLine) fieldSet() string {
+	for _, set := range FieldSets {
+		if l.has(set.Fields) {
+			return set.Name
+		}
+	}
+	return "unknown"
+}
+
+// has reports whether the line has all the fields.
+func (l schedLine) has(fields []string) bool {
+	for _, f := range fields {
+		if _, ok := l.fields[f]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// extra returns fields that don't belong to any known field set, nil if there are none.
+func (l schedLine) extra() map[string]string {
+	var extra map[string]string
+	for key, value := range l.fields {
+		if knownField(key) {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]string)
+		}
+		extra[key] = value
+	}
+	return extra
+}
+
+// knownField reports whether the field is part of any known field set.
+func knownField(name string) bool {
+	if slices.Contains(detailFields, name) {
+		return true
+	}
+	for _, set := range FieldSets {
+		if slices.Contains(set.Fields, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// int returns the value of a numeric field, 0 if the line doesn't have it.
+func (l schedLine) int(name string) (int, error) {
+	v, ok := l.fields[name]
+	if !ok {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, &ParseError{Reason: domain.ParseMalformed, Detail: "invalid " + name + " value"}
+	}
+	return n, nil
+}
+
+// snapshot converts the line to a snapshot without per-P run queues.
+// The header of a scheddetail block gets an empty Detail to fill with P, M and G lines.
+func (l schedLine) snapshot() (domain.SchedulerSnapshot, error) {
+	s := domain.SchedulerSnapshot{
+		TimeMs:   l.timeMs,
+		FieldSet: l.fieldSet(),
+		Extra:    l.extra(),
+	}
+	for _, f := range []struct {
+		name  string
+		value *int
+	}{
+		{"gomaxprocs", &s.GoMaxProcs},
+		{"idleprocs", &s.IdleProcs},
+		{"threads", &s.Threads},
+		{"spinningthreads", &s.SpinningThreads},
+		{"needspinning", &s.NeedSpinning},
+		{"idlethreads", &s.IdleThreads},
+		{"runqueue", &s.RunQueue},
+	} {
+		n, err := l.int(f.name)
+		if err != nil {
+			return domain.SchedulerSnapshot{}, err
+		}
+		*f.value = n
+	}
+
+	if l.detailed() {
+		s.Detail = &domain.SchedDetail{
+			GCWaiting:    parseFlag(l.fields["gcwaiting"]),
+			NMIdleLocked: parseInt(l.fields["nmidlelocked"]),
+			StopWait:     parseInt(l.fields["stopwait"]),
+			SysmonWait:   parseFlag(l.fields["sysmonwait"]),
+		}
+	}
+	return s, nil
+}
+
+// parseSchedLine tokenizes a SCHED line. Fields of the oldest known set are required,
+// other fields are optional and may come in any order.
+func parseSchedLine(line string) (schedLine, error) {
+	malformed := func(detail string) (schedLine, error) {
+		return schedLine{}, &ParseError{Reason: domain.ParseMalformed, Detail: detail}
+	}
+
+	tokens := splitTokens(line)
+	if len(tokens) == 0 || tokens[0] != "SCHED" {
+		return malformed("unexpected prefix, expected \"SCHED <n>ms:\"")
+	}
+	if len(tokens) < 2 || !strings.HasSuffix(tokens[1], "ms:") {
+		return malformed("missing time since start, expected \"SCHED <n>ms:\"")
+	}
+	timeMs, err := strconv.Atoi(strings.TrimSuffix(tokens[1], "ms:"))
+	if err != nil {
+		return malformed("invalid time value")
+	}
+
+	l := schedLine{timeMs: timeMs, fields: make(map[string]string)}
+	for _, token := range tokens[2:] {
+		if key, value, ok := strings.Cut(token, "="); ok {
+			l.fields[key] = value
+			continue
+		}
+		if strings.HasPrefix(token, "[") {
+			if !strings.HasSuffix(token, "]") {
+				return malformed("unterminated per-P run queue list")
+			}
+			l.lrq = strings.TrimSuffix(strings.TrimPrefix(token, "["), "]")
+			l.hasLRQ = true
+		}
+		// Other tokens are ignored, newer releases may print anything
+	}
+
+	oldest := FieldSets[len(FieldSets)-1]
+	for _, f := range oldest.Fields {
+		if _, ok := l.fields[f]; !ok {
+			return malformed("missing field " + f)
+		}
+	}
+	if !l.hasLRQ && !l.detailed() {
+		return malformed("missing per-P run queue list")
+	}
+	return l, nil
+}
+
+// splitTokens splits a line by whitespace.
+// Spaces inside parentheses and brackets belong to the token: "status=4(chan receive)", "[1 2 3]".
+func splitTokens(s string) []string {
+	var tokens []string
+
+	depth, start := 0, -1
+	for i, r := range s {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			if depth > 0 {
+				depth--
+			}
+		case ' ', '\t', '\r', '\n':
+			if depth == 0 {
+				if start >= 0 {
+					tokens = append(tokens, s[start:i])
+				}
+				start = -1
+				continue
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, s[start:])
+	}
+
+	return tokens
+}
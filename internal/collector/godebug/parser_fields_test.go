@@ -0,0 +1,102 @@
+package godebug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+func TestParser_FieldSets(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		fieldSet string
+		extra    map[string]string
+	}{
+		{
+			name:     "go1.21+",
+			input:    "SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1 0]",
+			fieldSet: "go1.21+",
+		},
+		{
+			name:     "go1.5-go1.20",
+			input:    "SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 idlethreads=3 runqueue=5 [1 2 1 0]",
+			fieldSet: "go1.5-go1.20",
+		},
+		{
+			name:     "go1.1-go1.4",
+			input:    "SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 idlethreads=3 runqueue=5 [1 2 1 0]",
+			fieldSet: "go1.1-go1.4",
+		},
+		{
+			name:     "reordered fields",
+			input:    "SCHED 1000ms: runqueue=5 gomaxprocs=4 threads=8 idleprocs=2 idlethreads=3 needspinning=0 spinningthreads=1 [1 2 1 0]",
+			fieldSet: "go1.21+",
+		},
+		{
+			name:     "unknown fields",
+			input:    "SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1 0] schedticks=[10 20 30 40] newfield=7",
+			fieldSet: "go1.21+",
+			extra:    map[string]string{"schedticks": "[10 20 30 40]", "newfield": "7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot, ok, err := NewParser().ParseLine(tt.input)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			assert.Equal(t, 1000, snapshot.TimeMs)
+			assert.Equal(t, 4, snapshot.GoMaxProcs)
+			assert.Equal(t, 2, snapshot.IdleProcs)
+			assert.Equal(t, 8, snapshot.Threads)
+			assert.Equal(t, 3, snapshot.IdleThreads)
+			assert.Equal(t, 5, snapshot.RunQueue)
+			assert.Equal(t, []int{1, 2, 1, 0}, snapshot.LRQ)
+			assert.Equal(t, 4, snapshot.LRQSum)
+			assert.Equal(t, tt.fieldSet, snapshot.FieldSet)
+			assert.Equal(t, tt.extra, snapshot.Extra)
+		})
+	}
+}
+
+func TestParseSchedLine_Malformed(t *testing.T) {
+	tests := []struct {
+		input  string
+		detail string
+	}{
+		{"SCHEDx 1000ms: gomaxprocs=4", "unexpected prefix, expected \"SCHED <n>ms:\""},
+		{"SCHED gomaxprocs=4 idleprocs=2", "missing time since start, expected \"SCHED <n>ms:\""},
+		{"SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 idlethreads=3 runqueue=5 [1 2 1 0", "unterminated per-P run queue list"},
+		{"SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 idlethreads=3 [1 2 1 0]", "missing field runqueue"},
+	}
+
+	for _, tt := range tests {
+		_, err := parseSchedLine(tt.input)
+		var parseErr *ParseError
+		require.ErrorAs(t, err, &parseErr, tt.input)
+		assert.Equal(t, tt.detail, parseErr.Detail, tt.input)
+	}
+}
+
+func TestSplitTokens(t *testing.T) {
+	assert.Equal(t,
+		[]string{"SCHED", "1ms:", "a=1", "[1 2 3]", "status=4(chan receive)", "b="},
+		splitTokens("SCHED  1ms: a=1\t[1 2 3] status=4(chan receive) b=\r"))
+	assert.Empty(t, splitTokens("   "))
+}
+
+func TestParser_DetectFields(t *testing.T) {
+	var diagnostics domain.ParseDiagnostics
+	parser := NewParser(ParserDiagnostics(&diagnostics))
+
+	parser.Parse("SCHED 1000ms: gomaxprocs=2 idleprocs=1 threads=4 spinningthreads=0 idlethreads=1 runqueue=0 [0 1] newfield=1")
+
+	report := diagnostics.Report()
+	assert.Equal(t, "go1.5-go1.20", report.FieldSet)
+	assert.Equal(t, []string{"newfield"}, report.Unknown)
+}
@@ -0,0 +1,70 @@
+package godebug
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// Example of gctrace output:
+// gc 7 @1.025s 2%: 0.022+1.3+0.005 ms clock, 0.26+0.45/2.1/0.91+0.066 ms cpu, 4->5->2 MB, 5 MB goal, 0 MB stacks, 0 MB globals, 12 P (forced)
+// Stacks and globals are reported since Go 1.18.
+var gcRegex = regexp.MustCompile(
+	`^gc\s+(\d+)\s+` + // Cycle number (group 1)
+		`@([\d.]+)s\s+` + // Time since start in seconds (group 2)
+		`(\d+)%:\s+` + // GC CPU percent (group 3)
+		`([\d.]+)\+([\d.]+)\+([\d.]+)\s+ms clock,\s+` + // Wall-clock phases (groups 4-6)
+		`([\d.]+)\+([\d.]+)/([\d.]+)/([\d.]+)\+([\d.]+)\s+ms cpu,\s+` + // CPU phases (groups 7-11)
+		`(\d+)->(\d+)->(\d+)\s+MB,\s+` + // Heap start, end, live (groups 12-14)
+		`(\d+)\s+MB goal,\s+` + // Heap goal (group 15)
+		`(?:(\d+)\s+MB stacks,\s+)?` + // Stacks (group 16)
+		`(?:(\d+)\s+MB globals,\s+)?` + // Globals (group 17)
+		`(\d+)\s+P` + // Procs (group 18)
+		`(\s+\(forced\))?`, // Forced flag (group 19)
+)
+
+// parseGCLine parses a single gctrace line.
+func parseGCLine(line string) (domain.GCCycle, bool) {
+	m := gcRegex.FindStringSubmatch(line)
+	if m == nil {
+		return domain.GCCycle{}, false
+	}
+
+	at, err := strconv.ParseFloat(m[2], 64)
+	if err != nil {
+		return domain.GCCycle{}, false
+	}
+
+	return domain.GCCycle{
+		Number:     parseInt(m[1]),
+		AtMs:       int(at * 1000),
+		CPUPercent: parseInt(m[3]),
+
+		SweepTermination: parseMillis(m[4]),
+		Mark:             parseMillis(m[5]),
+		MarkTermination:  parseMillis(m[6]),
+
+		SweepTerminationCPU: parseMillis(m[7]),
+		AssistCPU:           parseMillis(m[8]),
+		BackgroundCPU:       parseMillis(m[9]),
+		IdleCPU:             parseMillis(m[10]),
+		MarkTerminationCPU:  parseMillis(m[11]),
+
+		HeapStartMB: parseInt(m[12]),
+		HeapEndMB:   parseInt(m[13]),
+		HeapLiveMB:  parseInt(m[14]),
+		HeapGoalMB:  parseInt(m[15]),
+		StacksMB:    parseInt(m[16]),
+		GlobalsMB:   parseInt(m[17]),
+		Procs:       parseInt(m[18]),
+		Forced:      m[19] != "",
+	}, true
+}
+
+// parseMillis parses a duration in fractional milliseconds, returning 0 for malformed values.
+func parseMillis(v string) time.Duration {
+	ms, _ := strconv.ParseFloat(v, 64)
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,116 @@
+package godebug
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+func TestParseGCLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want domain.GCCycle
+		ok   bool
+	}{
+		{
+			name: "modern format",
+			line: "gc 7 @1.025s 2%: 0.022+1.3+0.005 ms clock, 0.26+0.45/2.1/0.91+0.066 ms cpu, 4->5->2 MB, 5 MB goal, 1 MB stacks, 0 MB globals, 12 P",
+			want: domain.GCCycle{
+				Number:              7,
+				AtMs:                1025,
+				CPUPercent:          2,
+				SweepTermination:    22 * time.Microsecond,
+				Mark:                1300 * time.Microsecond,
+				MarkTermination:     5 * time.Microsecond,
+				SweepTerminationCPU: 260 * time.Microsecond,
+				AssistCPU:           450 * time.Microsecond,
+				BackgroundCPU:       2100 * time.Microsecond,
+				IdleCPU:             910 * time.Microsecond,
+				MarkTerminationCPU:  66 * time.Microsecond,
+				HeapStartMB:         4,
+				HeapEndMB:           5,
+				HeapLiveMB:          2,
+				HeapGoalMB:          5,
+				StacksMB:            1,
+				Procs:               12,
+			},
+			ok: true,
+		},
+		{
+			name: "forced without stacks and globals",
+			line: "gc 12 @30.5s 0%: 0.011+0.20+0.002 ms clock, 0.044+0/0.15/0.21+0.008 ms cpu, 0->0->0 MB, 4 MB goal, 4 P (forced)",
+			want: domain.GCCycle{
+				Number:              12,
+				AtMs:                30500,
+				SweepTermination:    11 * time.Microsecond,
+				Mark:                200 * time.Microsecond,
+				MarkTermination:     2 * time.Microsecond,
+				SweepTerminationCPU: 44 * time.Microsecond,
+				BackgroundCPU:       150 * time.Microsecond,
+				IdleCPU:             210 * time.Microsecond,
+				MarkTerminationCPU:  8 * time.Microsecond,
+				HeapGoalMB:          4,
+				Procs:               4,
+				Forced:              true,
+			},
+			ok: true,
+		},
+		{
+			name: "scavenger line",
+			line: "scvg: 0 MB released",
+		},
+		{
+			name: "schedtrace line",
+			line: "SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 3 4]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseGCLine(tt.line)
+			require.Equal(t, tt.ok, ok)
+			if !tt.ok {
+				return
+			}
+
+			// Float milliseconds are not exact, compare with microsecond precision
+			for _, d := range []*time.Duration{
+				&got.SweepTermination, &got.Mark, &got.MarkTermination,
+				&got.SweepTerminationCPU, &got.AssistCPU, &got.BackgroundCPU, &got.IdleCPU, &got.MarkTerminationCPU,
+			} {
+				*d = d.Round(time.Microsecond)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want.SweepTermination+tt.want.MarkTermination, got.STW())
+		})
+	}
+}
+
+func TestParser_GCAttachedToNextSnapshot(t *testing.T) {
+	parser := NewParser()
+
+	lines := []string{
+		"gc 1 @0.012s 1%: 0.010+0.30+0.002 ms clock, 0.040+0.1/0.2/0.3+0.008 ms cpu, 4->4->0 MB, 4 MB goal, 0 MB stacks, 0 MB globals, 4 P",
+		"gc 2 @0.020s 1%: 0.010+0.30+0.002 ms clock, 0.040+0.1/0.2/0.3+0.008 ms cpu, 4->4->1 MB, 4 MB goal, 0 MB stacks, 0 MB globals, 4 P (forced)",
+	}
+	for _, line := range lines {
+		_, ok := parser.Parse(line)
+		assert.False(t, ok, "GC lines alone must not produce snapshots")
+	}
+
+	sched := "SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 3 4]"
+	s, ok := parser.Parse(sched)
+	require.True(t, ok)
+	require.Len(t, s.GC, 2)
+	assert.Equal(t, 1, s.GC[0].Number)
+	assert.True(t, s.GC[1].Forced)
+
+	s, ok = parser.Parse(sched)
+	require.True(t, ok)
+	assert.Empty(t, s.GC, "GC cycles must be attached only once")
+}
@@ -1,10 +1,13 @@
 package godebug
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
 )
 
 func TestParser_Parse(t *testing.T) {
@@ -162,8 +165,11 @@ func TestParser_ParseMetrics(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := parser.parseMetrics(tt.input)
-			assert.Equal(t, tt.expected, result)
+			result, ok := parser.parseMetrics(tt.input)
+			assert.Equal(t, tt.expected >= 0, ok)
+			if ok {
+				assert.Equal(t, tt.expected, result.goroutines)
+			}
 		})
 	}
 }
@@ -194,3 +200,201 @@ func TestParser_GoroutinesUpdate(t *testing.T) {
 	assert.True(t, ok, "Should parse sched line")
 	assert.Equal(t, 5678, snapshot.Goroutines, "Should update goroutines count")
 }
+
+func TestParser_RuntimeMetrics(t *testing.T) {
+	parser := NewParser()
+
+	_, ok := parser.Parse("PROCMETR num_goroutines=12 /gc/cycles/total:gc-cycles=3 /cpu/classes/gc/total:cpu-seconds=0.25")
+	assert.False(t, ok)
+
+	snapshot, ok := parser.Parse("SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1 0]")
+	require.True(t, ok)
+	assert.Equal(t, 12, snapshot.Goroutines)
+	assert.Equal(t, map[string]float64{
+		"/gc/cycles/total:gc-cycles":        3,
+		"/cpu/classes/gc/total:cpu-seconds": 0.25,
+	}, snapshot.Metrics)
+
+	// Goroutines count also comes from the runtime metric alone
+	_, ok = parser.Parse("PROCMETR /sched/goroutines:goroutines=40")
+	assert.False(t, ok)
+	snapshot, ok = parser.Parse("SCHED 2000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1 0]")
+	require.True(t, ok)
+	assert.Equal(t, 40, snapshot.Goroutines)
+	assert.Equal(t, map[string]float64{"/sched/goroutines:goroutines": 40}, snapshot.Metrics)
+
+	// Histograms come as upper bound:count pairs
+	_, ok = parser.Parse("PROCMETR num_goroutines=12 /sched/latencies:seconds=1e-06:15,inf:2")
+	assert.False(t, ok)
+	snapshot, ok = parser.Parse("SCHED 3000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1 0]")
+	require.True(t, ok)
+	assert.Equal(t, domain.Histogram{Upper: []float64{1e-6, math.MaxFloat64}, Counts: []uint64{15, 2}},
+		snapshot.Histograms["/sched/latencies:seconds"])
+
+	_, _, err := parser.ParseLine("PROCMETR /sched/latencies:seconds=1e-06:x")
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+
+	_, _, err = parser.ParseLine("PROCMETR num_goroutines=12 /gc/cycles/total:gc-cycles=x")
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, domain.ParseMalformed, parseErr.Reason)
+}
+
+func TestParser_GoroutineProfile(t *testing.T) {
+	parser := NewParser()
+
+	_, ok := parser.Parse("PROCMETR num_goroutines=1004 goroutine_states=running:1,chan_receive:1003 " +
+		"goroutine_sites=main.worker:1000,net/http.(*Server).Serve:3")
+	assert.False(t, ok)
+	snapshot, ok := parser.Parse("SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1 0]")
+	require.True(t, ok)
+	assert.Equal(t, 1004, snapshot.Goroutines)
+	assert.Empty(t, snapshot.Histograms, "Counts are not histograms")
+	assert.Equal(t, &domain.GoroutineProfile{
+		States: map[string]int{"running": 1, "chan_receive": 1003},
+		Sites: []domain.GoroutineSite{
+			{Function: "main.worker", Count: 1000},
+			{Function: "net/http.(*Server).Serve", Count: 3},
+		},
+	}, snapshot.GoroutineProfile)
+
+	// Every metrics line replaces the summary
+	_, ok = parser.Parse("PROCMETR num_goroutines=1004")
+	assert.False(t, ok)
+	snapshot, ok = parser.Parse("SCHED 2000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1 0]")
+	require.True(t, ok)
+	assert.Nil(t, snapshot.GoroutineProfile)
+
+	_, _, err := parser.ParseLine("PROCMETR goroutine_states=running")
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, domain.ParseMalformed, parseErr.Reason)
+}
+
+func TestIsTrace(t *testing.T) {
+	trace := []string{
+		"SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 3 4]",
+		"PROCMETR num_goroutines=42",
+		"gc 1 @0.012s 1%: 0.010+0.30+0.002 ms clock, 0.040+0.1/0.2/0.3+0.008 ms cpu, 4->4->0 MB, 4 MB goal, 0 MB stacks, 0 MB globals, 4 P",
+		"  P0: status=1 schedtick=92802 syscalltick=0 m=2 runqsize=3 gfreecnt=0 timerslen=1",
+		"  G1: status=2(sleep) m=0 lockedm=0",
+	}
+	for _, line := range trace {
+		assert.True(t, IsTrace(line), "Should be trace: %q", line)
+	}
+
+	output := []string{
+		"",
+		"2024/01/02 15:04:05 listening on :8080",
+		`{"level":"info","msg":"SCHED job started"}`,
+		"gc is disabled",
+	}
+	for _, line := range output {
+		assert.False(t, IsTrace(line), "Should be program output: %q", line)
+	}
+}
+
+func TestParser_ParseLine_Reasons(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		reason domain.ParseReason
+		detail string
+	}{
+		{
+			name:   "program output",
+			input:  "listening on :8080",
+			reason: domain.ParseUnmatched,
+		},
+		{
+			name:   "missing field",
+			input:  "SCHED 1000ms: gomaxprocs=4 idleprocs=2 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1 0]",
+			reason: domain.ParseMalformed,
+			detail: "missing field threads",
+		},
+		{
+			name:   "missing run queues",
+			input:  "SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5",
+			reason: domain.ParseMalformed,
+			detail: "missing per-P run queue list",
+		},
+		{
+			name:   "malformed metrics",
+			input:  "PROCMETR bad_format",
+			reason: domain.ParseMalformed,
+		},
+		{
+			name:   "inconsistent values",
+			input:  "SCHED 1000ms: gomaxprocs=4 idleprocs=6 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1 0]",
+			reason: domain.ParseRejected,
+			detail: "idleprocs=6 exceeds gomaxprocs=4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok, err := NewParser().ParseLine(tt.input)
+			assert.False(t, ok)
+
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, tt.reason, parseErr.Reason)
+			if tt.detail != "" {
+				assert.Equal(t, tt.detail, parseErr.Detail)
+			}
+		})
+	}
+}
+
+func TestParser_Validation(t *testing.T) {
+	allZero := "SCHED 1000ms: gomaxprocs=4 idleprocs=0 threads=8 spinningthreads=0 needspinning=0 idlethreads=3 runqueue=0 [0 0 0 0]"
+	invalid := "SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2]"
+
+	// All Ps busy with empty queues is accepted unless validation is strict
+	_, ok := NewParser().Parse(allZero)
+	assert.True(t, ok)
+
+	_, ok, err := NewParser(ParserValidation(ValidationStrict)).ParseLine(allZero)
+	assert.False(t, ok)
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, domain.ParseRejected, parseErr.Reason)
+
+	// Lenient validation keeps invalid snapshots and reports them
+	snapshot, ok, err := NewParser(ParserValidation(ValidationLenient)).ParseLine(invalid)
+	assert.True(t, ok)
+	assert.Equal(t, []int{1, 2}, snapshot.LRQ)
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, domain.ParseSuspicious, parseErr.Reason)
+	assert.Equal(t, "suspicious: 2 local run queues for gomaxprocs=4", err.Error())
+}
+
+func TestParser_Diagnostics(t *testing.T) {
+	var diagnostics domain.ParseDiagnostics
+	parser := NewParser(ParserDiagnostics(&diagnostics))
+
+	lines := []string{
+		"starting server",
+		"PROCMETR num_goroutines=10",
+		"SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1 0]",
+		"SCHED 2000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1]",
+		"SCHED 3000ms: gomaxprocs=4 idleprocs=2 threads=8",
+	}
+	for _, line := range lines {
+		parser.Parse(line)
+	}
+
+	report := diagnostics.Report()
+	assert.Equal(t, 5, report.Lines)
+	assert.Equal(t, 1, report.Snapshots)
+	assert.Equal(t, 1, report.Unmatched)
+	assert.Equal(t, 1, report.Malformed)
+	assert.Equal(t, 1, report.Rejected)
+	assert.Equal(t, 2, report.Dropped())
+
+	require.Len(t, report.Recent, 2, "Unmatched lines are only counted")
+	assert.Equal(t, domain.ParseRejected, report.Recent[0].Reason)
+	assert.Equal(t, lines[3], report.Recent[0].Line)
+	assert.Equal(t, domain.ParseMalformed, report.Recent[1].Reason)
+	assert.Equal(t, "missing field idlethreads", report.Recent[1].Detail)
+}
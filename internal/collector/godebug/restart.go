@@ -0,0 +1,77 @@
+package godebug
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// RestartPolicy defines when the target is started again after it exits.
+type RestartPolicy int
+
+const (
+	// RestartNever leaves the target stopped after it exits.
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure restarts the target when it exits with a non-zero code or is killed by a signal.
+	RestartOnFailure
+	// RestartAlways restarts the target whenever it exits.
+	RestartAlways
+)
+
+// defaultRestartDelay limits how often a crashing target is restarted.
+const defaultRestartDelay = time.Second
+
+// ParseRestartPolicy parses "never", "on-failure" or "always". An empty string means never.
+func ParseRestartPolicy(s string) (RestartPolicy, error) {
+	switch s {
+	case "", "never":
+		return RestartNever, nil
+	case "on-failure":
+		return RestartOnFailure, nil
+	case "always":
+		return RestartAlways, nil
+	}
+	return 0, fmt.Errorf("unknown restart policy %q, expected never, on-failure or always", s)
+}
+
+// String returns the policy name accepted by ParseRestartPolicy.
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartAlways:
+		return "always"
+	default:
+		return "never"
+	}
+}
+
+// shouldRestart reports whether the policy restarts a process that ended with the status.
+func (p RestartPolicy) shouldRestart(status domain.ExitStatus) bool {
+	switch p {
+	case RestartOnFailure:
+		return status.Failed()
+	case RestartAlways:
+		return true
+	default:
+		return false
+	}
+}
+
+// exitStatus converts the state of a finished process.
+// A nil state means the process couldn't be waited for.
+func exitStatus(state *os.ProcessState, runtime time.Duration) domain.ExitStatus {
+	status := domain.ExitStatus{Code: -1, Runtime: runtime}
+	if state == nil {
+		return status
+	}
+
+	status.Code = state.ExitCode()
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		status.Signal = ws.Signal().String()
+	}
+	return status
+}
@@ -0,0 +1,48 @@
+package godebug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+func TestParseRestartPolicy(t *testing.T) {
+	for _, policy := range []RestartPolicy{RestartNever, RestartOnFailure, RestartAlways} {
+		parsed, err := ParseRestartPolicy(policy.String())
+		require.NoError(t, err)
+		assert.Equal(t, policy, parsed)
+	}
+
+	parsed, err := ParseRestartPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, RestartNever, parsed)
+
+	_, err = ParseRestartPolicy("sometimes")
+	assert.Error(t, err)
+}
+
+func TestRestartPolicy_ShouldRestart(t *testing.T) {
+	success := domain.ExitStatus{Code: 0}
+	failure := domain.ExitStatus{Code: 1}
+	killed := domain.ExitStatus{Code: -1, Signal: "killed"}
+
+	tests := []struct {
+		policy RestartPolicy
+		want   []bool // success, failure, killed
+	}{
+		{RestartNever, []bool{false, false, false}},
+		{RestartOnFailure, []bool{false, true, true}},
+		{RestartAlways, []bool{true, true, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			assert.Equal(t, tt.want[0], tt.policy.shouldRestart(success))
+			assert.Equal(t, tt.want[1], tt.policy.shouldRestart(failure))
+			assert.Equal(t, tt.want[2], tt.policy.shouldRestart(killed))
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package godebug
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// defaultWatchInterval is how often sources of the target are checked for changes.
+const defaultWatchInterval = 500 * time.Millisecond
+
+// sourceSet lists files whose changes trigger a rebuild.
+type sourceSet struct {
+	dirs  []string // package directories, all non-test .go files in them are watched
+	files []string // single files like go.mod or a .go file target
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// listedPackage is the part of "go list -json" output used to find sources.
+type listedPackage struct {
+	Dir    string
+	Module *struct {
+		Main  bool
+		GoMod string
+	}
+}
+
+// sources finds packages of the main module the target is built from.
+// Standard library and third-party dependencies are not watched.
+func (c *Collector) sources() (sourceSet, error) {
+	var set sourceSet
+	if c.kind == targetFile {
+		// Other files in the directory are not part of the build
+		set.files = append(set.files, c.path)
+	}
+
+	dir, pkg := c.buildArgs()
+	cmd := exec.Command("go", append([]string{"list", "-e", "-deps", "-json"}, append(c.build.tagArgs(), pkg)...)...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return set, fmt.Errorf("failed to list sources: %w", err)
+	}
+
+	seenMod := make(map[string]bool)
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var p listedPackage
+		if err := decoder.Decode(&p); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return set, fmt.Errorf("failed to parse package list: %w", err)
+		}
+		if p.Module == nil || !p.Module.Main {
+			continue
+		}
+		if p.Module.GoMod != "" && !seenMod[p.Module.GoMod] {
+			seenMod[p.Module.GoMod] = true
+			sum := filepath.Join(filepath.Dir(p.Module.GoMod), "go.sum")
+			set.files = append(set.files, p.Module.GoMod, sum)
+		}
+		if c.kind != targetFile {
+			set.dirs = append(set.dirs, p.Dir)
+		}
+	}
+
+	return set, nil
+}
+
+// stamps returns versions of all watched files. Missing files are skipped,
+// so a deleted file is a change as well.
+func (s sourceSet) stamps() map[string]fileStamp {
+	result := make(map[string]fileStamp)
+	add := func(path string) {
+		if info, err := os.Stat(path); err == nil {
+			result[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+
+	for _, dir := range s.dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+		for _, path := range matches {
+			if !strings.HasSuffix(path, "_test.go") {
+				add(path)
+			}
+		}
+	}
+	for _, path := range s.files {
+		add(path)
+	}
+	return result
+}
+
+// watchSources polls sources of the target and rebuilds it after they change.
+// last holds versions of the sources the running binary was built from.
+// Paths of successfully built binaries are sent to rebuilt, build errors go to the output pane.
+func (c *Collector) watchSources(ctx context.Context, binary string, set sourceSet, last map[string]fileStamp, rebuilt chan<- string) {
+	ticker := time.NewTicker(c.watchInterval)
+	defer ticker.Stop()
+
+	changed := false
+	for build := 1; ; {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		case <-c.done:
+			return
+		}
+
+		// Wait until an editor or a tool finishes writing files
+		current := set.stamps()
+		if !maps.Equal(current, last) {
+			last = current
+			changed = true
+			continue
+		}
+		if !changed {
+			continue
+		}
+		changed = false
+
+		next := filepath.Join(filepath.Dir(binary), fmt.Sprintf("build%d", build), filepath.Base(binary))
+		build++
+		if err := os.MkdirAll(filepath.Dir(next), 0700); err != nil {
+			c.setBuildError(err)
+			continue
+		}
+		if err := c.compile(next); err != nil {
+			c.setBuildError(err)
+			continue
+		}
+		c.setBuildError(nil)
+
+		// Imports may have changed together with the sources. Files watched before keep
+		// their versions from before the build, so changes made during it aren't missed
+		if updated, err := c.sources(); err == nil {
+			set = updated
+			current := set.stamps()
+			for path := range current {
+				if stamp, ok := last[path]; ok {
+					current[path] = stamp
+				}
+			}
+			last = current
+		}
+
+		select {
+		case rebuilt <- next:
+		case <-ctx.Done():
+			return
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// setBuildError records the result of a rebuild and shows compiler output in the output pane.
+func (c *Collector) setBuildError(err error) {
+	c.mu.Lock()
+	c.buildErr = err
+	c.mu.Unlock()
+
+	if err == nil {
+		return
+	}
+	now := time.Now()
+	for _, line := range strings.Split(err.Error(), "\n") {
+		c.logs.Add(domain.OutputLine{Time: now, Stream: "stderr", Text: line})
+	}
+}
@@ -0,0 +1,164 @@
+package godebug
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// setupWatchedModule writes a module whose main package depends on an internal package.
+func setupWatchedModule(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/watched\n\ngo 1.23\n",
+		"main.go": `package main
+
+import (
+	"time"
+
+	"example.com/watched/internal/pool"
+)
+
+func main() {
+	pool.Run()
+	time.Sleep(10 * time.Second)
+}`,
+		"main_test.go":          "package main\n",
+		"internal/pool/pool.go": "package pool\n\nfunc Run() {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0666))
+	}
+	return dir
+}
+
+func TestCollector_Sources(t *testing.T) {
+	dir := setupWatchedModule(t)
+
+	c := &Collector{path: dir, kind: targetPackage}
+	set, err := c.sources()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{dir, filepath.Join(dir, "internal", "pool")}, set.dirs)
+	assert.Contains(t, set.files, filepath.Join(dir, "go.mod"))
+
+	stamps := set.stamps()
+	assert.Contains(t, stamps, filepath.Join(dir, "main.go"))
+	assert.Contains(t, stamps, filepath.Join(dir, "internal", "pool", "pool.go"))
+	assert.NotContains(t, stamps, filepath.Join(dir, "main_test.go"), "Tests are not part of the build")
+
+	// Only the file itself is watched for a .go file target
+	c = &Collector{path: filepath.Join(dir, "main.go"), kind: targetFile}
+	set, err = c.sources()
+	require.NoError(t, err)
+	assert.Empty(t, set.dirs)
+	assert.Contains(t, set.files, filepath.Join(dir, "main.go"))
+}
+
+func TestCollector_WatchSources_EditDuringBuild(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	dir := setupWatchedModule(t)
+	poolFile := filepath.Join(dir, "internal", "pool", "pool.go")
+
+	// The build tools are run through a script that edits a source file once, in the middle of the build
+	tools := t.TempDir()
+	mark := filepath.Join(tools, "edit")
+	script := filepath.Join(tools, "toolexec.sh")
+	require.NoError(t, os.WriteFile(script, []byte(strings.Join([]string{
+		"#!/bin/sh",
+		"if mv " + mark + " " + mark + ".done 2>/dev/null; then echo '// edited' >> " + poolFile + "; fi",
+		`exec "$@"`,
+	}, "\n")+"\n"), 0755))
+	t.Setenv("GOFLAGS", "-toolexec="+script)
+
+	c := New(dir, 100)
+	c.kind = targetPackage
+	c.watchInterval = 20 * time.Millisecond
+	set, err := c.sources()
+	require.NoError(t, err)
+	last := set.stamps()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	rebuilt := make(chan string)
+	go c.watchSources(ctx, filepath.Join(t.TempDir(), "watched"), set, last, rebuilt)
+
+	next := func() string {
+		select {
+		case binary := <-rebuilt:
+			return binary
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for rebuild, output: %v", c.Logs())
+		}
+		return ""
+	}
+
+	require.NoError(t, os.WriteFile(mark, nil, 0666))
+	require.NoError(t, os.WriteFile(poolFile, []byte("package pool\n\nfunc Run() { println(\"v2\") }\n"), 0666))
+	assert.Contains(t, next(), "build1")
+	require.NoFileExists(t, mark, "Source should be edited during the build")
+
+	// The edit made during the first build triggers another one
+	assert.Contains(t, next(), "build2")
+}
+
+func TestCollector_Watch(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("Skipping test on non-Unix platform")
+	}
+
+	dir := setupWatchedModule(t)
+	poolFile := filepath.Join(dir, "internal", "pool", "pool.go")
+
+	collector := New(dir, 100, WithWatch())
+	collector.watchInterval = 50 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	snapshots, err := collector.Start(ctx)
+	require.NoError(t, err)
+	defer collector.Stop()
+
+	next := func() domain.SchedulerSnapshot {
+		select {
+		case snapshot, ok := <-snapshots:
+			require.True(t, ok, "Watched target should keep the channel open")
+			return snapshot
+		case <-ctx.Done():
+			t.Fatalf("Timed out waiting for snapshot, output: %v", collector.Logs())
+		}
+		return domain.SchedulerSnapshot{}
+	}
+	next()
+
+	// Broken code keeps the old process running
+	require.NoError(t, os.WriteFile(poolFile, []byte("package pool\n\nfunc Run() { undefined() }\n"), 0666))
+	for collector.Status() != "Build failed, see output" {
+		next()
+	}
+
+	// Fixed code replaces the process
+	require.NoError(t, os.WriteFile(poolFile, []byte("package pool\n\nfunc Run() { println(\"v2\") }\n"), 0666))
+	for next().Marker != domain.MarkerRebuild {
+	}
+	assert.Equal(t, "Rebuilds: 1", collector.Status())
+
+	_, exited := collector.Exit()
+	assert.False(t, exited)
+}
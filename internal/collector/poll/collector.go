@@ -0,0 +1,245 @@
+// Package poll implements collector.Collector for services exposing runtime metrics
+// over HTTP with metrics.Handler, so they can be watched without restarting them.
+package poll
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+	"github.com/JustSkiv/goschedviz/pkg/metrics"
+)
+
+// runtime/metrics names mapped to snapshot fields.
+// Metrics missing in older Go versions are left zero.
+const (
+	metricGoMaxProcs   = "/sched/gomaxprocs:threads"
+	metricGoroutines   = "/sched/goroutines:goroutines"
+	metricRunnable     = "/sched/goroutines/runnable:goroutines"
+	metricRunning      = "/sched/goroutines/running:goroutines"
+	metricThreads      = "/sched/threads/total:threads"
+	metricGCCycles     = "/gc/cycles/total:gc-cycles"
+	metricForcedCycles = "/gc/cycles/forced:gc-cycles"
+	metricHeapLive     = "/gc/heap/live:bytes"
+	metricHeapGoal     = "/gc/heap/goal:bytes"
+)
+
+// requestTimeout limits a single poll request.
+const requestTimeout = 5 * time.Second
+
+// Collector implements collector.Collector interface by polling
+// a metrics.Handler endpoint at a fixed interval.
+//
+// runtime/metrics doesn't expose per-P run queues, so snapshots carry
+// all runnable goroutines as RunQueue and leave LRQ empty.
+type Collector struct {
+	url      string
+	interval time.Duration
+	client   *http.Client
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu      sync.Mutex
+	lastErr error // error of the last poll, shown as status
+
+	gcCycles     int // GC cycles seen in the previous poll
+	forcedCycles int
+	seq          domain.Sequencer
+}
+
+// New creates a collector polling rawURL every interval.
+func New(rawURL string, interval time.Duration) *Collector {
+	return &Collector{
+		url:      rawURL,
+		interval: interval,
+		client:   &http.Client{Timeout: requestTimeout},
+		done:     make(chan struct{}),
+		seq:      domain.Sequencer{Source: rawURL},
+	}
+}
+
+// Start implements collector.Collector interface.
+// The first poll is done synchronously, so an unreachable endpoint is reported right away.
+func (c *Collector) Start(ctx context.Context) (<-chan domain.SchedulerSnapshot, error) {
+	u, err := url.Parse(c.url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid metrics URL %q: expected http(s)://host[:port]/path", c.url)
+	}
+	if c.interval <= 0 {
+		return nil, fmt.Errorf("poll interval must be positive, got %v", c.interval)
+	}
+
+	first, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	received := time.Now()
+	// Cycles before the first poll are not reported as markers
+	c.gcCycles = int(first.Metrics[metricGCCycles])
+	c.forcedCycles = int(first.Metrics[metricForcedCycles])
+
+	snapshots := make(chan domain.SchedulerSnapshot)
+
+	go func() {
+		defer close(snapshots)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		send := func(s domain.SchedulerSnapshot) bool {
+			select {
+			case snapshots <- s:
+				return true
+			case <-ctx.Done():
+				return false
+			case <-c.done:
+				return false
+			}
+		}
+
+		if !send(c.seq.Stamp(c.convert(first), received)) {
+			return
+		}
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			case <-c.done:
+				return
+			}
+
+			// Failed polls are skipped and reported as status
+			m, err := c.fetch(ctx)
+			c.setErr(err)
+			if err != nil {
+				continue
+			}
+			if !send(c.seq.Stamp(c.convert(m), time.Now())) {
+				return
+			}
+		}
+	}()
+
+	return snapshots, nil
+}
+
+// Stop implements collector.Collector interface.
+func (c *Collector) Stop() error {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+	return nil
+}
+
+// Status describes the last poll error, empty when polling works.
+func (c *Collector) Status() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lastErr == nil {
+		return ""
+	}
+	return "Poll failed: " + c.lastErr.Error()
+}
+
+func (c *Collector) setErr(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastErr = err
+}
+
+// fetch requests a single metrics snapshot.
+func (c *Collector) fetch(ctx context.Context) (metrics.Snapshot, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return metrics.Snapshot{}, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return metrics.Snapshot{}, fmt.Errorf("failed to poll metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return metrics.Snapshot{}, fmt.Errorf("failed to poll metrics: %s", resp.Status)
+	}
+
+	var snapshot metrics.Snapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return metrics.Snapshot{}, fmt.Errorf("failed to decode metrics: %w", err)
+	}
+	return snapshot, nil
+}
+
+// convert maps runtime metrics to a scheduler snapshot.
+// GC cycles completed since the previous poll are reported without phase details.
+func (c *Collector) convert(m metrics.Snapshot) domain.SchedulerSnapshot {
+	value := func(name string) int { return int(m.Metrics[name]) }
+
+	s := domain.SchedulerSnapshot{
+		TimeMs:     int(m.UptimeMs),
+		GoMaxProcs: value(metricGoMaxProcs),
+		Threads:    value(metricThreads),
+		RunQueue:   value(metricRunnable),
+		Goroutines: value(metricGoroutines),
+	}
+	if _, ok := m.Metrics[metricRunning]; ok {
+		s.IdleProcs = max(s.GoMaxProcs-value(metricRunning), 0)
+	}
+
+	// The same metrics as metrics.Reporter sends, so both sources show the same series
+	for name, v := range m.Metrics {
+		if metrics.Match(metrics.DefaultMetrics, name) {
+			if s.Metrics == nil {
+				s.Metrics = make(map[string]float64)
+			}
+			s.Metrics[name] = v
+		}
+	}
+	for name, h := range m.Histograms {
+		if metrics.Match(metrics.DefaultMetrics, name) {
+			if s.Histograms == nil {
+				s.Histograms = make(map[string]domain.Histogram)
+			}
+			s.Histograms[name] = convertHistogram(h)
+		}
+	}
+
+	cycles, forced := value(metricGCCycles), value(metricForcedCycles)
+	if cycles > c.gcCycles {
+		// Cycles since the previous poll can't be told apart, report them as one,
+		// forced if any of them was
+		s.GC = []domain.GCCycle{{
+			Number:     cycles,
+			AtMs:       s.TimeMs,
+			HeapLiveMB: value(metricHeapLive) >> 20,
+			HeapGoalMB: value(metricHeapGoal) >> 20,
+			Procs:      s.GoMaxProcs,
+			Forced:     forced > c.forcedCycles,
+			Merged:     cycles - c.gcCycles - 1,
+		}}
+	}
+	c.gcCycles, c.forcedCycles = cycles, forced
+
+	return s
+}
+
+// convertHistogram keeps non-empty buckets of a runtime/metrics histogram.
+// Handler already replaced the infinite bound with math.MaxFloat64.
+func convertHistogram(h metrics.Histogram) domain.Histogram {
+	var result domain.Histogram
+	for i, count := range h.Counts {
+		if count > 0 && i+1 < len(h.Buckets) {
+			result.Upper = append(result.Upper, h.Buckets[i+1])
+			result.Counts = append(result.Counts, count)
+		}
+	}
+	return result
+}
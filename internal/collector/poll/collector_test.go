@@ -0,0 +1,139 @@
+package poll
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+	"github.com/JustSkiv/goschedviz/pkg/metrics"
+)
+
+func receive(t *testing.T, snapshots <-chan domain.SchedulerSnapshot) domain.SchedulerSnapshot {
+	t.Helper()
+	select {
+	case s, ok := <-snapshots:
+		require.True(t, ok, "Channel should stay open")
+		return s
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timed out waiting for snapshot")
+	}
+	return domain.SchedulerSnapshot{}
+}
+
+func TestCollector_Handler(t *testing.T) {
+	server := httptest.NewServer(metrics.Handler())
+	defer server.Close()
+
+	c := New(server.URL, 50*time.Millisecond)
+	snapshots, err := c.Start(context.Background())
+	require.NoError(t, err)
+	defer c.Stop()
+
+	first := receive(t, snapshots)
+	second := receive(t, snapshots)
+
+	assert.Equal(t, runtime.GOMAXPROCS(0), first.GoMaxProcs)
+	assert.Positive(t, first.Goroutines)
+	assert.Greater(t, second.TimeMs, first.TimeMs, "Uptime should grow between polls")
+	assert.Empty(t, c.Status())
+}
+
+func TestCollector_Convert(t *testing.T) {
+	c := New("http://localhost", time.Second)
+	c.gcCycles, c.forcedCycles = 10, 1
+
+	s := c.convert(metrics.Snapshot{
+		UptimeMs: 1500,
+		Metrics: map[string]float64{
+			metricGoMaxProcs:   4,
+			metricGoroutines:   120,
+			metricRunnable:     7,
+			metricRunning:      3,
+			metricThreads:      9,
+			metricGCCycles:     13,
+			metricForcedCycles: 2,
+			metricHeapLive:     3 << 20,
+			metricHeapGoal:     8 << 20,
+		},
+		Histograms: map[string]metrics.Histogram{
+			"/sched/latencies:seconds": {Counts: []uint64{0, 5, 1}, Buckets: []float64{0, 1e-6, 1e-5, math.MaxFloat64}},
+		},
+	})
+
+	assert.Equal(t, 1500, s.TimeMs)
+	assert.Equal(t, 4, s.GoMaxProcs)
+	assert.Equal(t, 1, s.IdleProcs)
+	assert.Equal(t, 9, s.Threads)
+	assert.Equal(t, 7, s.RunQueue)
+	assert.Equal(t, 120, s.Goroutines)
+	assert.Empty(t, s.LRQ)
+	assert.Equal(t, 13.0, s.Metrics[metricGCCycles])
+	assert.NotContains(t, s.Metrics, metricRunnable, "Only reporter metrics are kept")
+	assert.Equal(t, domain.Histogram{Upper: []float64{1e-5, math.MaxFloat64}, Counts: []uint64{5, 1}},
+		s.Histograms["/sched/latencies:seconds"])
+
+	require.Len(t, s.GC, 1, "Cycles since the previous poll are reported as one")
+	assert.Equal(t, 13, s.GC[0].Number)
+	assert.Equal(t, 3, s.GC[0].Count())
+	assert.True(t, s.GC[0].Forced)
+	assert.Equal(t, 3, s.GC[0].HeapLiveMB)
+	assert.Equal(t, 8, s.GC[0].HeapGoalMB)
+
+	// Older Go versions don't report running goroutines
+	s = c.convert(metrics.Snapshot{Metrics: map[string]float64{metricGoMaxProcs: 4, metricGCCycles: 13}})
+	assert.Zero(t, s.IdleProcs)
+	assert.Empty(t, s.GC, "Cycles must be reported only once")
+}
+
+func TestCollector_Errors(t *testing.T) {
+	for _, rawURL := range []string{"", "localhost:6060", "ftp://host/metrics"} {
+		_, err := New(rawURL, time.Second).Start(context.Background())
+		assert.Error(t, err, "URL %q should be rejected", rawURL)
+	}
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	_, err := New(server.URL, time.Second).Start(context.Background())
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestCollector_PollFailure(t *testing.T) {
+	var fail atomic.Bool
+	handler := metrics.Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c := New(server.URL, 20*time.Millisecond)
+	snapshots, err := c.Start(context.Background())
+	require.NoError(t, err)
+	defer c.Stop()
+
+	receive(t, snapshots)
+	fail.Store(true)
+
+	assert.Eventually(t, func() bool {
+		return c.Status() != ""
+	}, 2*time.Second, 10*time.Millisecond, "Failed polls should be reported as status")
+	assert.Contains(t, c.Status(), "503")
+
+	fail.Store(false)
+	receive(t, snapshots)
+	assert.Eventually(t, func() bool {
+		return c.Status() == ""
+	}, 2*time.Second, 10*time.Millisecond, "Status should clear after a successful poll")
+}
@@ -0,0 +1,277 @@
+// Package remote implements a collector that receives snapshots from a goschedviz agent over TCP.
+package remote
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/agent"
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// Connection defaults.
+const (
+	DefaultDialTimeout    = 5 * time.Second
+	DefaultReconnectDelay = time.Second
+)
+
+// maxMessageSize limits a single message, snapshots with scheddetail can be large.
+const maxMessageSize = 16 << 20
+
+// Collector implements collector.Collector interface for a remote agent.
+// The connection is re-established when it breaks.
+type Collector struct {
+	addr           string
+	dialTimeout    time.Duration
+	reconnectDelay time.Duration
+	done           chan struct{}
+	stopOnce       sync.Once
+
+	mu        sync.Mutex // guards fields below
+	conn      net.Conn
+	connected bool
+	connErr   error // why the last connection attempt failed or broke
+	hello     agent.Hello
+	state     agent.State
+	logSeq    uint64 // last output line received from the agent
+
+	logs domain.LogBuffer
+}
+
+// New creates a collector that connects to the agent at addr ("host:port").
+func New(addr string) *Collector {
+	return &Collector{
+		addr:           addr,
+		dialTimeout:    DefaultDialTimeout,
+		reconnectDelay: DefaultReconnectDelay,
+		done:           make(chan struct{}),
+	}
+}
+
+// Start implements collector.Collector interface.
+// The first connection is made synchronously, so an unreachable agent is reported right away.
+func (c *Collector) Start(ctx context.Context) (<-chan domain.SchedulerSnapshot, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make(chan domain.SchedulerSnapshot)
+	go func() {
+		defer close(snapshots)
+
+		for {
+			c.receive(ctx, conn, snapshots)
+
+			// Reconnect until stopped
+			for {
+				select {
+				case <-time.After(c.reconnectDelay):
+				case <-ctx.Done():
+					return
+				case <-c.done:
+					return
+				}
+				if conn, err = c.dial(); err == nil {
+					break
+				}
+			}
+		}
+	}()
+
+	return snapshots, nil
+}
+
+// dial connects to the agent and records the result.
+func (c *Collector) dial() (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", c.addr, c.dialTimeout)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		c.connErr = err
+		return nil, fmt.Errorf("failed to connect to agent: %w", err)
+	}
+	c.conn = conn
+	c.connected = true
+	c.connErr = nil
+	return conn, nil
+}
+
+// receive reads messages until the connection breaks or the collector is stopped.
+func (c *Collector) receive(ctx context.Context, conn net.Conn, snapshots chan<- domain.SchedulerSnapshot) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-c.done:
+		case <-stop:
+		}
+		conn.Close()
+	}()
+
+	// The agent replays its history on every connection
+	marker := domain.MarkerReset
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var m agent.Message
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			c.disconnected(fmt.Errorf("invalid message: %w", err))
+			return
+		}
+
+		switch {
+		case m.Type == agent.TypeHello && m.Hello != nil:
+			if m.Hello.Version != agent.ProtocolVersion {
+				c.disconnected(fmt.Errorf("unsupported protocol version %d", m.Hello.Version))
+				return
+			}
+			c.setHello(*m.Hello)
+		case m.Type == agent.TypeSnapshot && m.Snapshot != nil:
+			// Receive time and sequence of the agent are kept, so data of several agents can be merged
+			snapshot := *m.Snapshot
+			snapshot.Source = remoteSource(snapshot.Source, c.addr)
+			if snapshot.Received.IsZero() {
+				snapshot.Received = time.Now()
+			}
+			if marker != domain.MarkerNone {
+				snapshot.Marker = marker
+				marker = domain.MarkerNone
+			}
+			select {
+			case snapshots <- snapshot:
+			case <-ctx.Done():
+				return
+			case <-c.done:
+				return
+			}
+		case m.Type == agent.TypeLog && m.Log != nil:
+			c.addLog(*m.Log)
+		case m.Type == agent.TypeState && m.State != nil:
+			c.mu.Lock()
+			c.state = *m.State
+			c.mu.Unlock()
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = fmt.Errorf("connection closed by agent")
+	}
+	c.disconnected(err)
+}
+
+// setHello records the agent description. A restarted agent numbers output lines anew.
+func (c *Collector) setHello(hello agent.Hello) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !hello.Started.Equal(c.hello.Started) {
+		c.logSeq = 0
+	}
+	c.hello = hello
+}
+
+// addLog keeps an output line unless it was already received before a reconnect.
+func (c *Collector) addLog(entry domain.LogEntry) {
+	c.mu.Lock()
+	if entry.Seq <= c.logSeq {
+		c.mu.Unlock()
+		return
+	}
+	c.logSeq = entry.Seq
+	c.mu.Unlock()
+
+	c.logs.Add(entry.OutputLine)
+}
+
+// disconnected records why the connection broke.
+func (c *Collector) disconnected(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.connected = false
+	select {
+	case <-c.done:
+		// Closed by Stop, not an error
+	default:
+		c.connErr = err
+	}
+}
+
+// Status describes the connection and the state of the remote collector.
+func (c *Collector) Status() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var lines []string
+	switch {
+	case c.connected && c.hello.Target != "":
+		lines = append(lines, fmt.Sprintf("Agent: %s (%s)", c.addr, c.hello.Target))
+	case c.connected:
+		lines = append(lines, "Agent: "+c.addr)
+	case c.connErr != nil:
+		lines = append(lines, fmt.Sprintf("Agent %s unavailable: %v, reconnecting", c.addr, c.connErr))
+	}
+	if c.state.Status != "" {
+		lines = append(lines, c.state.Status)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Exit returns how the remote program ended. It reports false while it's running.
+func (c *Collector) Exit() (domain.ExitStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state.Exit == nil {
+		return domain.ExitStatus{}, false
+	}
+	return *c.state.Exit, true
+}
+
+// Logs returns recent output of the remote program.
+func (c *Collector) Logs() []domain.LogEntry {
+	return c.logs.Entries()
+}
+
+// Stop implements collector.Collector interface.
+func (c *Collector) Stop() error {
+	c.stopOnce.Do(func() { close(c.done) })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	return nil
+}
+
+// remoteSource returns the ID of a source monitored by the agent at addr.
+func remoteSource(source, addr string) string {
+	if source == "" {
+		return addr
+	}
+	return source + "@" + addr
+}
+
+// Diagnostics returns parse outcomes reported by the agent.
+func (c *Collector) Diagnostics() domain.ParseReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state.Diagnostics == nil {
+		return domain.ParseReport{}
+	}
+	return *c.state.Diagnostics
+}
@@ -0,0 +1,143 @@
+package remote
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/agent"
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// programCollector stands in for a collector running next to the target.
+type programCollector struct {
+	snapshots chan domain.SchedulerSnapshot
+	mu        sync.Mutex
+	logs      []domain.LogEntry
+}
+
+func (p *programCollector) Start(ctx context.Context) (<-chan domain.SchedulerSnapshot, error) {
+	return p.snapshots, nil
+}
+
+func (p *programCollector) Stop() error { return nil }
+
+func (p *programCollector) Logs() []domain.LogEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]domain.LogEntry(nil), p.logs...)
+}
+
+func (p *programCollector) Exit() (domain.ExitStatus, bool) {
+	return domain.ExitStatus{}, false
+}
+
+// serveAgent runs an agent on the listener until the returned function is called.
+func serveAgent(t *testing.T, ln net.Listener, c *programCollector) (stop func()) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		agent.New(c, agent.Hello{Target: "./cmd/api", Started: time.Now()}).Serve(ctx, ln)
+	}()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+func receive(t *testing.T, snapshots <-chan domain.SchedulerSnapshot) domain.SchedulerSnapshot {
+	t.Helper()
+
+	select {
+	case snapshot, ok := <-snapshots:
+		require.True(t, ok, "Channel closed")
+		return snapshot
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for snapshot")
+	}
+	return domain.SchedulerSnapshot{}
+}
+
+func TestCollector_Loopback(t *testing.T) {
+	program := &programCollector{
+		snapshots: make(chan domain.SchedulerSnapshot),
+		logs:      []domain.LogEntry{{Seq: 1, OutputLine: domain.OutputLine{Stream: "stdout", Text: "ready"}}},
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	stopAgent := serveAgent(t, ln, program)
+
+	c := New(addr)
+	c.reconnectDelay = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snapshots, err := c.Start(ctx)
+	require.NoError(t, err)
+	defer c.Stop()
+
+	program.snapshots <- domain.SchedulerSnapshot{TimeMs: 100, GoMaxProcs: 4}
+	received := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	program.snapshots <- domain.SchedulerSnapshot{TimeMs: 200, GoMaxProcs: 4, Received: received, Seq: 2, Source: "./cmd/api"}
+
+	first := receive(t, snapshots)
+	assert.Equal(t, 100, first.TimeMs)
+	assert.Equal(t, domain.MarkerReset, first.Marker, "History is replayed on every connection")
+	assert.Equal(t, addr, first.Source)
+	assert.False(t, first.Received.IsZero())
+
+	second := receive(t, snapshots)
+	assert.Equal(t, domain.MarkerNone, second.Marker)
+	assert.Equal(t, received, second.Received.UTC(), "Receive time of the agent is kept")
+	assert.Equal(t, uint64(2), second.Seq)
+	assert.Equal(t, "./cmd/api@"+addr, second.Source)
+
+	require.Eventually(t, func() bool { return len(c.Logs()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "ready", c.Logs()[0].Text)
+	assert.Equal(t, "Agent: "+addr+" (./cmd/api)", c.Status())
+
+	// Agent restart: viewer reconnects and gets the new history
+	stopAgent()
+	require.Eventually(t, func() bool {
+		return c.Status() != "Agent: "+addr+" (./cmd/api)"
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Contains(t, c.Status(), "reconnecting")
+
+	ln, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	serveAgent(t, ln, program)
+
+	program.snapshots <- domain.SchedulerSnapshot{TimeMs: 300}
+	snapshot := receive(t, snapshots)
+	assert.Equal(t, 300, snapshot.TimeMs)
+	assert.Equal(t, domain.MarkerReset, snapshot.Marker)
+
+	// Output replayed after the reconnect is numbered by the new agent process
+	require.Eventually(t, func() bool { return len(c.Logs()) == 2 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestCollector_Unreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	_, err = New(addr).Start(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,392 @@
+// Package replay implements collector.Collector that plays back a recorded session.
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/collector/godebug"
+	"github.com/JustSkiv/goschedviz/internal/domain"
+	"github.com/JustSkiv/goschedviz/internal/recording"
+)
+
+const (
+	// MinSpeed and MaxSpeed limit playback speed multiplier.
+	MinSpeed = 0.25
+	MaxSpeed = 64
+)
+
+// Player feeds recorded output lines through the schedtrace parser,
+// preserving original timing scaled by playback speed.
+//
+// When playback reaches the end of the recording the player pauses
+// instead of closing the snapshot channel, so the session can be
+// rewound and studied further.
+type Player struct {
+	lines    []domain.OutputLine
+	source   string // ID of the recorded source
+	parse    []godebug.ParserOption
+	report   domain.ParseReport // parse outcomes of the whole recording
+	done     chan struct{}
+	wake     chan struct{} // signals control changes to the playback goroutine
+	stopOnce sync.Once
+
+	mu      sync.Mutex
+	speed   float64
+	paused  bool
+	pos     int  // index of the next line to play
+	seekTo  int  // line index requested by Seek
+	seeking bool // seekTo is pending
+}
+
+// Option configures a Player.
+type Option func(*Player)
+
+// WithValidation sets how strictly parsed snapshots are checked.
+func WithValidation(v godebug.Validation) Option {
+	return func(p *Player) {
+		p.parse = append(p.parse, godebug.ParserValidation(v))
+	}
+}
+
+// New creates a player for the recording.
+func New(rec *recording.Recording, speed float64, opts ...Option) *Player {
+	p := &Player{
+		lines:  rec.Lines,
+		source: rec.Header.Target,
+		done:   make(chan struct{}),
+		wake:   make(chan struct{}, 1),
+		speed:  clampSpeed(speed),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	// The recording doesn't change, so it's diagnosed once up front
+	var diagnostics domain.ParseDiagnostics
+	parser := godebug.NewParser(append([]godebug.ParserOption{godebug.ParserDiagnostics(&diagnostics)}, p.parse...)...)
+	for _, line := range p.lines {
+		parser.Parse(line.Text)
+	}
+	parser.Flush()
+	p.report = diagnostics.Report()
+
+	return p
+}
+
+// Snapshots parses the whole recording at once, e.g. to build an offline report.
+// Snapshots keep the original receive time of the recorded lines.
+func Snapshots(rec *recording.Recording, opts ...Option) []domain.SchedulerSnapshot {
+	p := &Player{}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	parser := godebug.NewParser(p.parse...)
+	seq := domain.Sequencer{Source: rec.Header.Target}
+	var snapshots []domain.SchedulerSnapshot
+	for _, line := range rec.Lines {
+		if s, ok := parser.Parse(line.Text); ok {
+			snapshots = append(snapshots, seq.Stamp(s, line.Time))
+		}
+	}
+	if s, ok := parser.Flush(); ok {
+		snapshots = append(snapshots, seq.Stamp(s, rec.Lines[len(rec.Lines)-1].Time))
+	}
+	return snapshots
+}
+
+// Diagnostics returns parse outcomes of the whole recording.
+func (p *Player) Diagnostics() domain.ParseReport {
+	return p.report
+}
+
+// Start implements collector.Collector interface.
+func (p *Player) Start(ctx context.Context) (<-chan domain.SchedulerSnapshot, error) {
+	if len(p.lines) == 0 {
+		return nil, fmt.Errorf("recording contains no output lines")
+	}
+
+	snapshots := make(chan domain.SchedulerSnapshot)
+	go p.run(ctx, snapshots)
+
+	return snapshots, nil
+}
+
+// Stop implements collector.Collector interface.
+func (p *Player) Stop() error {
+	p.stopOnce.Do(func() {
+		close(p.done)
+	})
+	return nil
+}
+
+// TogglePause pauses or resumes playback.
+func (p *Player) TogglePause() {
+	p.mu.Lock()
+	p.paused = !p.paused
+	p.mu.Unlock()
+	p.notify()
+}
+
+// SetSpeed changes the playback speed multiplier.
+func (p *Player) SetSpeed(speed float64) {
+	p.mu.Lock()
+	p.speed = clampSpeed(speed)
+	p.mu.Unlock()
+	p.notify()
+}
+
+// Speed returns the current playback speed multiplier.
+func (p *Player) Speed() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.speed
+}
+
+// Seek moves playback position by offset relative to the current position.
+// Negative offset rewinds.
+func (p *Player) Seek(offset time.Duration) {
+	p.mu.Lock()
+	target := p.offsetOf(p.pos) + offset
+	p.seekTo = p.indexAt(target)
+	p.seeking = true
+	p.mu.Unlock()
+	p.notify()
+}
+
+// SeekStart rewinds playback to the beginning of the recording.
+func (p *Player) SeekStart() {
+	p.mu.Lock()
+	p.seekTo = 0
+	p.seeking = true
+	p.mu.Unlock()
+	p.notify()
+}
+
+// Position returns current playback position and total duration of the recording.
+func (p *Player) Position() (time.Duration, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.offsetOf(p.pos), p.offsetOf(len(p.lines))
+}
+
+// Status returns human-readable playback state for the UI.
+func (p *Player) Status() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := "playing"
+	switch {
+	case p.pos >= len(p.lines):
+		state = "end"
+	case p.paused:
+		state = "paused"
+	}
+
+	return fmt.Sprintf("Replay %s/%s x%g %s",
+		formatOffset(p.offsetOf(p.pos)),
+		formatOffset(p.offsetOf(len(p.lines))),
+		p.speed,
+		state,
+	)
+}
+
+// run plays lines until the player is stopped.
+func (p *Player) run(ctx context.Context, snapshots chan<- domain.SchedulerSnapshot) {
+	defer close(snapshots)
+
+	parser := godebug.NewParser(p.parse...)
+	seq := domain.Sequencer{Source: p.source}
+	reset := false // next emitted snapshot must discard history
+
+	emit := func(s domain.SchedulerSnapshot) bool {
+		if reset {
+			s.Marker = domain.MarkerReset
+			reset = false
+		}
+		select {
+		case snapshots <- s:
+			return true
+		case <-ctx.Done():
+		case <-p.done:
+		}
+		return false
+	}
+
+	for {
+		p.mu.Lock()
+		if p.seeking {
+			target := p.seekTo
+			p.seeking = false
+			p.mu.Unlock()
+
+			var history []domain.SchedulerSnapshot
+			parser, seq, history = p.rewind(target)
+			p.mu.Lock()
+			p.pos = target
+			p.mu.Unlock()
+
+			reset = true
+			for _, s := range history {
+				if !emit(s) {
+					return
+				}
+			}
+			continue
+		}
+
+		if p.paused || p.pos >= len(p.lines) {
+			p.mu.Unlock()
+			if p.wait(ctx, nil) == waitStopped {
+				return
+			}
+			continue
+		}
+
+		var delay time.Duration
+		if p.pos > 0 {
+			delay = time.Duration(float64(p.lines[p.pos].Time.Sub(p.lines[p.pos-1].Time)) / p.speed)
+		}
+		p.mu.Unlock()
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			result := p.wait(ctx, timer.C)
+			timer.Stop()
+			switch result {
+			case waitStopped:
+				return
+			case waitWoken:
+				// Controls changed while waiting, re-evaluate state
+				continue
+			}
+		}
+
+		p.mu.Lock()
+		if p.seeking || p.paused {
+			p.mu.Unlock()
+			continue
+		}
+		line := p.lines[p.pos]
+		p.pos++
+		last := p.pos == len(p.lines)
+		p.mu.Unlock()
+
+		// Snapshots keep the original receive time of the recorded lines
+		if s, ok := parser.Parse(line.Text); ok {
+			if !emit(seq.Stamp(s, line.Time)) {
+				return
+			}
+		}
+		if last {
+			// Last scheddetail block is completed only by the end of recording
+			if s, ok := parser.Flush(); ok && !emit(seq.Stamp(s, line.Time)) {
+				return
+			}
+		}
+	}
+}
+
+// rewind parses lines before target from scratch and returns the parser and sequencer state
+// along with the snapshots that fit into monitor history.
+func (p *Player) rewind(target int) (*godebug.Parser, domain.Sequencer, []domain.SchedulerSnapshot) {
+	parser := godebug.NewParser(p.parse...)
+	seq := domain.Sequencer{Source: p.source}
+	var history []domain.SchedulerSnapshot
+
+	add := func(s domain.SchedulerSnapshot) {
+		history = append(history, s)
+		if len(history) > domain.MaxHistoryPoints {
+			history = history[1:]
+		}
+	}
+
+	for _, line := range p.lines[:target] {
+		if s, ok := parser.Parse(line.Text); ok {
+			add(seq.Stamp(s, line.Time))
+		}
+	}
+	if target == len(p.lines) {
+		if s, ok := parser.Flush(); ok {
+			add(seq.Stamp(s, p.lines[target-1].Time))
+		}
+	}
+
+	return parser, seq, history
+}
+
+// waitResult tells why wait returned.
+type waitResult int
+
+const (
+	waitFired   waitResult = iota // timer expired
+	waitWoken                     // playback controls changed
+	waitStopped                   // player was stopped or context canceled
+)
+
+// wait blocks until the timer fires, controls change or the player shuts down.
+// A nil timer waits for control changes only.
+func (p *Player) wait(ctx context.Context, timer <-chan time.Time) waitResult {
+	select {
+	case <-timer:
+		return waitFired
+	case <-p.wake:
+		return waitWoken
+	case <-ctx.Done():
+	case <-p.done:
+	}
+	return waitStopped
+}
+
+// notify wakes up the playback goroutine without blocking.
+func (p *Player) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// offsetOf returns the position of line index i relative to the first line.
+// Index past the end maps to the total duration.
+func (p *Player) offsetOf(i int) time.Duration {
+	if len(p.lines) == 0 {
+		return 0
+	}
+	if i >= len(p.lines) {
+		i = len(p.lines) - 1
+	}
+	return p.lines[i].Time.Sub(p.lines[0].Time)
+}
+
+// indexAt returns index of the first line at or after offset.
+func (p *Player) indexAt(offset time.Duration) int {
+	if offset <= 0 {
+		return 0
+	}
+	for i := range p.lines {
+		if p.offsetOf(i) >= offset {
+			return i
+		}
+	}
+	return len(p.lines)
+}
+
+// clampSpeed keeps speed within supported range.
+func clampSpeed(speed float64) float64 {
+	switch {
+	case speed < MinSpeed:
+		return MinSpeed
+	case speed > MaxSpeed:
+		return MaxSpeed
+	}
+	return speed
+}
+
+// formatOffset formats duration as mm:ss.
+func formatOffset(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
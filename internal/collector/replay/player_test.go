@@ -0,0 +1,209 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/collector/godebug"
+	"github.com/JustSkiv/goschedviz/internal/domain"
+	"github.com/JustSkiv/goschedviz/internal/recording"
+)
+
+// testRecording creates a recording with n SCHED lines one step apart
+// and a metrics line before each of them.
+func testRecording(n int, step time.Duration) *recording.Recording {
+	start := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	rec := &recording.Recording{Header: recording.Header{Version: recording.Version}}
+	for i := 0; i < n; i++ {
+		at := start.Add(time.Duration(i) * step)
+		rec.Lines = append(rec.Lines,
+			domain.OutputLine{Time: at, Stream: "stderr", Text: fmt.Sprintf("PROCMETR num_goroutines=%d", 10+i)},
+			domain.OutputLine{Time: at, Stream: "stderr", Text: fmt.Sprintf(
+				"SCHED %dms: gomaxprocs=2 idleprocs=1 threads=4 spinningthreads=0 needspinning=0 idlethreads=1 runqueue=%d [1 0]",
+				(i+1)*int(step/time.Millisecond), i)},
+		)
+	}
+	return rec
+}
+
+func receive(t *testing.T, snapshots <-chan domain.SchedulerSnapshot) domain.SchedulerSnapshot {
+	t.Helper()
+	select {
+	case s, ok := <-snapshots:
+		require.True(t, ok, "channel closed unexpectedly")
+		return s
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for snapshot")
+	}
+	return domain.SchedulerSnapshot{}
+}
+
+func TestPlayer_PlaysInOrder(t *testing.T) {
+	rec := testRecording(5, 100*time.Millisecond)
+	rec.Header.Target = "./cmd/api"
+	player := New(rec, MaxSpeed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	snapshots, err := player.Start(ctx)
+	require.NoError(t, err)
+	defer player.Stop()
+
+	for i := 0; i < 5; i++ {
+		s := receive(t, snapshots)
+		assert.Equal(t, i, s.RunQueue)
+		assert.Equal(t, 10+i, s.Goroutines, "metrics lines should be replayed through parser")
+		assert.Equal(t, domain.MarkerNone, s.Marker)
+		assert.Equal(t, uint64(i+1), s.Seq)
+		assert.Equal(t, rec.Lines[2*i+1].Time, s.Received, "Snapshots keep the recorded time")
+		assert.Equal(t, "./cmd/api", s.Source)
+	}
+
+	// Player stays open at the end of recording
+	select {
+	case _, ok := <-snapshots:
+		assert.True(t, ok, "channel must not be closed at the end of recording")
+		t.Fatal("unexpected snapshot after the end of recording")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.Contains(t, player.Status(), "end")
+}
+
+func TestPlayer_Diagnostics(t *testing.T) {
+	rec := testRecording(3, 100*time.Millisecond)
+	rec.Lines[3].Text = "SCHED 200ms: gomaxprocs=2 idleprocs=1 threads=4 spinningthreads=0 needspinning=0 idlethreads=1 runqueue=1 [1 0 0]"
+
+	report := New(rec, MaxSpeed).Diagnostics()
+	assert.Equal(t, 6, report.Lines)
+	assert.Equal(t, 2, report.Snapshots)
+	assert.Equal(t, 1, report.Rejected)
+
+	report = New(rec, MaxSpeed, WithValidation(godebug.ValidationLenient)).Diagnostics()
+	assert.Equal(t, 3, report.Snapshots)
+	assert.Equal(t, 0, report.Rejected)
+	assert.Equal(t, 1, report.Suspicious)
+}
+
+func TestPlayer_Timing(t *testing.T) {
+	player := New(testRecording(3, 200*time.Millisecond), 1)
+
+	snapshots, err := player.Start(context.Background())
+	require.NoError(t, err)
+	defer player.Stop()
+
+	started := time.Now()
+	receive(t, snapshots)
+	receive(t, snapshots)
+	receive(t, snapshots)
+
+	assert.GreaterOrEqual(t, time.Since(started), 350*time.Millisecond,
+		"snapshots should be spaced according to recorded timestamps")
+}
+
+func TestPlayer_PauseAndSeek(t *testing.T) {
+	player := New(testRecording(10, time.Second), 1)
+	player.TogglePause()
+
+	snapshots, err := player.Start(context.Background())
+	require.NoError(t, err)
+	defer player.Stop()
+
+	// Paused player emits nothing
+	select {
+	case <-snapshots:
+		t.Fatal("paused player should not emit snapshots")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.Contains(t, player.Status(), "paused")
+
+	// Seeking forward replays history up to the target, starting with a reset marker
+	player.Seek(5 * time.Second)
+	first := receive(t, snapshots)
+	assert.Equal(t, domain.MarkerReset, first.Marker)
+	assert.Equal(t, 0, first.RunQueue)
+	assert.Equal(t, uint64(1), first.Seq)
+	for i := 1; i < 5; i++ {
+		s := receive(t, snapshots)
+		assert.Equal(t, i, s.RunQueue)
+		assert.Equal(t, domain.MarkerNone, s.Marker)
+		assert.Equal(t, uint64(i+1), s.Seq, "Sequence is counted from the start of the recording")
+	}
+
+	pos, total := player.Position()
+	assert.Equal(t, 5*time.Second, pos)
+	assert.Equal(t, 9*time.Second, total)
+
+	// Rewinding to start resets history with nothing to replay yet,
+	// so the reset is carried by the next played snapshot
+	player.SeekStart()
+	time.Sleep(50 * time.Millisecond)
+	player.TogglePause()
+	s := receive(t, snapshots)
+	assert.Equal(t, domain.MarkerReset, s.Marker)
+	assert.Equal(t, 0, s.RunQueue)
+}
+
+func TestPlayer_Speed(t *testing.T) {
+	player := New(testRecording(1, time.Second), 2)
+	assert.Equal(t, 2.0, player.Speed())
+
+	player.SetSpeed(1000)
+	assert.Equal(t, float64(MaxSpeed), player.Speed())
+
+	player.SetSpeed(0)
+	assert.Equal(t, MinSpeed, player.Speed())
+}
+
+func TestPlayer_EmptyRecording(t *testing.T) {
+	player := New(&recording.Recording{}, 1)
+	snapshots, err := player.Start(context.Background())
+	assert.Error(t, err)
+	assert.Nil(t, snapshots)
+}
+
+func TestPlayer_Stop(t *testing.T) {
+	player := New(testRecording(100, time.Second), 1)
+
+	snapshots, err := player.Start(context.Background())
+	require.NoError(t, err)
+	receive(t, snapshots)
+
+	require.NoError(t, player.Stop())
+	require.NoError(t, player.Stop(), "Stop should be safe to call twice")
+
+	select {
+	case _, ok := <-snapshots:
+		assert.False(t, ok, "channel should be closed after Stop")
+	case <-time.After(time.Second):
+		t.Fatal("channel was not closed after Stop")
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	rec := testRecording(5, 100*time.Millisecond)
+	rec.Header.Target = "./cmd/api"
+
+	snapshots := Snapshots(rec)
+	require.Len(t, snapshots, 5)
+	for i, s := range snapshots {
+		assert.Equal(t, i, s.RunQueue)
+		assert.Equal(t, 10+i, s.Goroutines)
+		assert.Equal(t, rec.Lines[2*i+1].Time, s.Received)
+		assert.Equal(t, uint64(i+1), s.Seq)
+		assert.Equal(t, "./cmd/api", s.Source)
+	}
+
+	assert.Empty(t, Snapshots(&recording.Recording{}))
+}
+
+func TestFormatOffset(t *testing.T) {
+	assert.Equal(t, "00:00", formatOffset(0))
+	assert.Equal(t, "01:05", formatOffset(65*time.Second))
+	assert.Equal(t, "61:00", formatOffset(time.Hour+time.Minute))
+}
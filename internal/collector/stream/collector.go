@@ -0,0 +1,191 @@
+// Package stream implements collector.Collector for schedtrace output produced
+// outside of goschedviz, e.g. piped through stdin or written to a log file.
+package stream
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/collector/godebug"
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// lineSource produces raw output lines until the source is exhausted or done is closed.
+// It closes lines when it returns.
+type lineSource func(ctx context.Context, done <-chan struct{}, lines chan<- string) error
+
+// Collector implements collector.Collector interface for schedtrace lines
+// read from an external stream instead of a process started by goschedviz.
+type Collector struct {
+	source        lineSource
+	metricsSocket string // Unix socket path for metrics reporters, empty if disabled
+	metricsLn     net.Listener
+	done          chan struct{}
+	stopOnce      sync.Once
+	logs          domain.LogBuffer // recent input lines that are not trace output
+	seq           domain.Sequencer
+	validation    godebug.Validation
+	diagnostics   domain.ParseDiagnostics
+}
+
+// Option configures a Collector.
+type Option func(*Collector)
+
+// WithMetricsSocket listens on a Unix socket at path for metrics lines, so programs
+// started with GOSCHEDVIZ_METRICS=unix:<path> keep PROCMETR lines out of their output.
+func WithMetricsSocket(path string) Option {
+	return func(c *Collector) {
+		c.metricsSocket = path
+	}
+}
+
+// WithValidation sets how strictly parsed snapshots are checked.
+func WithValidation(v godebug.Validation) Option {
+	return func(c *Collector) {
+		c.validation = v
+	}
+}
+
+// New creates a collector that reads lines from r until EOF.
+//
+// Example:
+//
+//	GODEBUG=schedtrace=1000 myapp 2>&1 | goschedviz -
+func New(r io.Reader, opts ...Option) *Collector {
+	return newCollector("input", readerSource(r), opts)
+}
+
+// newCollector creates a collector for the source with options applied.
+// Snapshots are stamped with the source ID.
+func newCollector(id string, source lineSource, opts []Option) *Collector {
+	c := &Collector{
+		source: source,
+		done:   make(chan struct{}),
+		seq:    domain.Sequencer{Source: id},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Start implements collector.Collector interface.
+func (c *Collector) Start(ctx context.Context) (<-chan domain.SchedulerSnapshot, error) {
+	if c.source == nil {
+		return nil, fmt.Errorf("no input source configured")
+	}
+
+	var metricsLines chan string
+	if c.metricsSocket != "" {
+		ln, err := listenMetrics(c.metricsSocket)
+		if err != nil {
+			return nil, err
+		}
+		c.metricsLn = ln
+		metricsLines = make(chan string)
+		go serveMetrics(ln, metricsLines, c.done)
+	}
+
+	lines := make(chan string)
+	snapshots := make(chan domain.SchedulerSnapshot)
+
+	go func() {
+		if err := c.source(ctx, c.done, lines); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		}
+	}()
+
+	go func() {
+		defer close(snapshots)
+
+		parser := godebug.NewParser(godebug.ParserValidation(c.validation), godebug.ParserDiagnostics(&c.diagnostics))
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					// Last scheddetail block is completed only by the end of input
+					if snapshot, ok := parser.Flush(); ok {
+						select {
+						case snapshots <- c.seq.Stamp(snapshot, time.Now()):
+						case <-ctx.Done():
+						case <-c.done:
+						}
+					}
+					return
+				}
+				received := time.Now()
+				if !godebug.IsTrace(line) {
+					c.logs.Add(domain.OutputLine{Time: received, Stream: "input", Text: line})
+				}
+				snapshot, ok := parser.Parse(line)
+				if !ok {
+					continue
+				}
+				select {
+				case snapshots <- c.seq.Stamp(snapshot, received):
+				case <-ctx.Done():
+					return
+				case <-c.done:
+					return
+				}
+			case line := <-metricsLines:
+				// Metrics only update the goroutines count of the next snapshot
+				parser.Parse(line)
+			case <-ctx.Done():
+				return
+			case <-c.done:
+				return
+			}
+		}
+	}()
+
+	return snapshots, nil
+}
+
+// Logs returns recent input lines, excluding trace output.
+func (c *Collector) Logs() []domain.LogEntry {
+	return c.logs.Entries()
+}
+
+// Stop implements collector.Collector interface.
+func (c *Collector) Stop() error {
+	c.stopOnce.Do(func() {
+		close(c.done)
+		if c.metricsLn != nil {
+			// Closing removes the socket file
+			c.metricsLn.Close()
+		}
+	})
+	return nil
+}
+
+// readerSource reads lines from r until EOF.
+func readerSource(r io.Reader) lineSource {
+	return func(ctx context.Context, done <-chan struct{}, lines chan<- string) error {
+		defer close(lines)
+
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			select {
+			case lines <- strings.TrimRight(scanner.Text(), "\r"):
+			case <-ctx.Done():
+				return nil
+			case <-done:
+				return nil
+			}
+		}
+		return scanner.Err()
+	}
+}
+
+// Diagnostics returns parse outcomes of the input.
+func (c *Collector) Diagnostics() domain.ParseReport {
+	return c.diagnostics.Report()
+}
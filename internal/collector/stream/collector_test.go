@@ -0,0 +1,125 @@
+package stream
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/collector/godebug"
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+const (
+	schedLine1 = "SCHED 1000ms: gomaxprocs=4 idleprocs=2 threads=8 spinningthreads=1 needspinning=0 idlethreads=3 runqueue=5 [1 2 1 0]"
+	schedLine2 = "SCHED 2000ms: gomaxprocs=4 idleprocs=1 threads=9 spinningthreads=0 needspinning=0 idlethreads=2 runqueue=7 [0 3 1 2]"
+)
+
+// collect reads snapshots until the channel is closed or n snapshots are received.
+func collect(t *testing.T, snapshots <-chan domain.SchedulerSnapshot, n int) []domain.SchedulerSnapshot {
+	t.Helper()
+
+	var result []domain.SchedulerSnapshot
+	timeout := time.After(3 * time.Second)
+	for len(result) < n {
+		select {
+		case s, ok := <-snapshots:
+			if !ok {
+				return result
+			}
+			result = append(result, s)
+		case <-timeout:
+			t.Fatalf("timed out after %d of %d snapshots", len(result), n)
+		}
+	}
+	return result
+}
+
+func TestCollector_Reader(t *testing.T) {
+	input := strings.Join([]string{
+		"app: starting",
+		"PROCMETR num_goroutines=42",
+		schedLine1 + "\r",
+		"app: some log line",
+		schedLine2,
+	}, "\n")
+
+	c := New(strings.NewReader(input))
+	snapshots, err := c.Start(context.Background())
+	require.NoError(t, err)
+	defer c.Stop()
+
+	got := collect(t, snapshots, 3)
+	require.Len(t, got, 2, "Only SCHED lines should produce snapshots")
+
+	assert.Equal(t, 1000, got[0].TimeMs)
+	assert.Equal(t, 5, got[0].RunQueue)
+	assert.Equal(t, 42, got[0].Goroutines)
+	assert.Equal(t, 2000, got[1].TimeMs)
+	assert.Equal(t, []int{0, 3, 1, 2}, got[1].LRQ)
+	assert.Equal(t, []uint64{1, 2}, []uint64{got[0].Seq, got[1].Seq})
+	assert.Equal(t, "input", got[1].Source)
+	assert.False(t, got[1].Received.IsZero())
+
+	logs := c.Logs()
+	require.Len(t, logs, 2, "Only program output should be kept as logs")
+	assert.Equal(t, "app: starting", logs[0].Text)
+	assert.Equal(t, "app: some log line", logs[1].Text)
+	assert.Equal(t, "input", logs[1].Stream)
+}
+
+func TestCollector_Diagnostics(t *testing.T) {
+	input := strings.Join([]string{
+		"app: starting",
+		schedLine1,
+		"SCHED 1500ms: gomaxprocs=4 idleprocs=2",
+		"SCHED 2000ms: gomaxprocs=4 idleprocs=1 threads=9 spinningthreads=0 needspinning=0 idlethreads=2 runqueue=7 [0 3]",
+	}, "\n")
+
+	c := New(strings.NewReader(input), WithValidation(godebug.ValidationLenient))
+	snapshots, err := c.Start(context.Background())
+	require.NoError(t, err)
+	defer c.Stop()
+
+	got := collect(t, snapshots, 3)
+	require.Len(t, got, 2, "Suspicious snapshot should be kept in lenient mode")
+
+	report := c.Diagnostics()
+	assert.Equal(t, 4, report.Lines)
+	assert.Equal(t, 1, report.Unmatched)
+	assert.Equal(t, 1, report.Malformed)
+	assert.Equal(t, 1, report.Suspicious)
+	require.Len(t, report.Recent, 2)
+	assert.Equal(t, "missing field threads", report.Recent[0].Detail)
+}
+
+func TestCollector_Stop(t *testing.T) {
+	// Reader that never returns data, like an idle pipe
+	r, w := io.Pipe()
+	defer w.Close()
+
+	c := New(r)
+	snapshots, err := c.Start(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, c.Stop())
+	require.NoError(t, c.Stop(), "Stop should be safe to call twice")
+
+	select {
+	case _, ok := <-snapshots:
+		assert.False(t, ok, "Channel should be closed after Stop")
+	case <-time.After(time.Second):
+		t.Fatal("Channel was not closed after Stop")
+	}
+}
+
+func TestCollector_NoSource(t *testing.T) {
+	c := &Collector{done: make(chan struct{})}
+	snapshots, err := c.Start(context.Background())
+	assert.Error(t, err)
+	assert.Nil(t, snapshots)
+}
@@ -0,0 +1,163 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultPollInterval defines how often a followed file is checked for new data.
+const DefaultPollInterval = 250 * time.Millisecond
+
+// NewFollower creates a collector that follows the file at path like "tail -F".
+//
+// The file is read from the beginning, then new lines are picked up as they
+// are appended. The collector waits for the file if it doesn't exist yet,
+// reopens it when it is replaced by log rotation and starts over when it is truncated.
+func NewFollower(path string, pollInterval time.Duration, opts ...Option) *Collector {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	f := &follower{path: path, poll: pollInterval}
+	return newCollector(path, f.run, opts)
+}
+
+// follower keeps track of the currently open file and read position.
+type follower struct {
+	path string
+	poll time.Duration
+
+	file    *os.File
+	reader  *bufio.Reader
+	offset  int64  // bytes consumed from the current file
+	partial string // incomplete last line waiting for its newline
+}
+
+// run implements lineSource.
+func (f *follower) run(ctx context.Context, done <-chan struct{}, lines chan<- string) error {
+	defer close(lines)
+	defer f.close()
+
+	ticker := time.NewTicker(f.poll)
+	defer ticker.Stop()
+
+	// send passes a line on, it reports false once the collector is stopped
+	send := func(line string) bool {
+		select {
+		case lines <- line:
+			return true
+		case <-ctx.Done():
+		case <-done:
+		}
+		return false
+	}
+
+	for {
+		if f.file == nil {
+			if err := f.open(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+
+		if f.file != nil {
+			line, err := f.reader.ReadString('\n')
+			f.offset += int64(len(line))
+
+			switch {
+			case err == nil:
+				line = strings.TrimRight(f.partial+line, "\r\n")
+				f.partial = ""
+				if !send(line) {
+					return nil
+				}
+				continue
+			case errors.Is(err, io.EOF):
+				f.partial += line
+				last, err := f.checkRotation()
+				if err != nil {
+					return err
+				}
+				// The old file won't get the rest of its last line, so it's passed on as is
+				if last != "" {
+					if !send(last) {
+						return nil
+					}
+					continue
+				}
+			default:
+				return err
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// open opens the file at path and starts reading it from the beginning.
+func (f *follower) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	f.file = file
+	f.reader = bufio.NewReader(file)
+	f.offset = 0
+	f.partial = ""
+	return nil
+}
+
+// close releases the currently open file, if any.
+func (f *follower) close() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+// checkRotation detects that the followed file was replaced or truncated.
+// A rotated file has been fully read at this point, so the new one is opened
+// from the beginning. A missing file means rotation is in progress: the old
+// file is kept until the new one appears. An incomplete last line of the old
+// file is returned, so it isn't lost.
+func (f *follower) checkRotation() (last string, err error) {
+	current, err := f.file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	latest, err := os.Stat(f.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	switch {
+	case !os.SameFile(current, latest):
+		last = strings.TrimRight(f.partial, "\r\n")
+		f.close()
+		return last, f.open()
+	case latest.Size() < f.offset:
+		last = strings.TrimRight(f.partial, "\r\n")
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		f.reader.Reset(f.file)
+		f.offset = 0
+		f.partial = ""
+	}
+
+	return last, nil
+}
@@ -0,0 +1,131 @@
+package stream
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	defer f.Close()
+
+	for _, line := range lines {
+		_, err := f.WriteString(line + "\n")
+		require.NoError(t, err)
+	}
+}
+
+func startFollower(t *testing.T, path string) func(n int) []int {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	c := NewFollower(path, 10*time.Millisecond)
+	snapshots, err := c.Start(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Stop() })
+
+	// next returns TimeMs of the next n snapshots
+	next := func(n int) []int {
+		var times []int
+		for _, s := range collect(t, snapshots, n) {
+			times = append(times, s.TimeMs)
+		}
+		return times
+	}
+	return next
+}
+
+func TestFollower_WaitsForFileAndFollowsAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	next := startFollower(t, path)
+
+	// File appears after the collector started
+	time.Sleep(30 * time.Millisecond)
+	appendLines(t, path, "starting", schedLine1)
+	assert.Equal(t, []int{1000}, next(1))
+
+	appendLines(t, path, schedLine2)
+	assert.Equal(t, []int{2000}, next(1))
+}
+
+func TestFollower_PartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	next := startFollower(t, path)
+
+	// Line is written in two chunks, it must be parsed only once complete
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.WriteString(schedLine1[:20])
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	_, err = f.WriteString(schedLine1[20:] + "\n")
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{1000}, next(1))
+}
+
+func TestFollower_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendLines(t, path, schedLine1)
+
+	next := startFollower(t, path)
+	assert.Equal(t, []int{1000}, next(1))
+
+	// Rotate: move the old file away and start a new one
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "app.log.1")))
+	time.Sleep(30 * time.Millisecond)
+	appendLines(t, path, schedLine2)
+
+	assert.Equal(t, []int{2000}, next(1))
+}
+
+func TestFollower_RotationKeepsPartialLine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendLines(t, path, schedLine1)
+
+	// The old file ends without a newline
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(schedLine2)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	next := startFollower(t, path)
+	assert.Equal(t, []int{1000}, next(1))
+
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "app.log.1")))
+	time.Sleep(30 * time.Millisecond)
+	appendLines(t, path, "SCHED 3000ms: gomaxprocs=4 idleprocs=2 threads=9 spinningthreads=0 needspinning=0 idlethreads=3 runqueue=0 [0 0 0 0]")
+
+	assert.Equal(t, []int{2000, 3000}, next(2), "The last line of the rotated file is not lost")
+}
+
+func TestFollower_Truncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendLines(t, path, "some long preamble that will be truncated away", schedLine1)
+
+	next := startFollower(t, path)
+	assert.Equal(t, []int{1000}, next(1))
+
+	// copytruncate-style rotation: same file, size reset
+	require.NoError(t, os.Truncate(path, 0))
+	time.Sleep(30 * time.Millisecond)
+	appendLines(t, path, schedLine2)
+
+	assert.Equal(t, []int{2000}, next(1))
+}
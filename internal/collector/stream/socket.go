@@ -0,0 +1,78 @@
+package stream
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+)
+
+// listenMetrics listens on a Unix socket at path.
+// A stale socket left by a previous run is replaced, other files are not touched.
+func listenMetrics(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for metrics: %w", err)
+	}
+	return ln, nil
+}
+
+// serveMetrics accepts reporter connections and sends their lines until done is closed.
+// Several connections are served at once, e.g. while a restarted program reconnects.
+func serveMetrics(ln net.Listener, lines chan<- string, done <-chan struct{}) {
+	var (
+		mu     sync.Mutex
+		conns  = make(map[net.Conn]struct{})
+		closed bool
+	)
+
+	go func() {
+		<-done
+		ln.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		closed = true
+		for conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		if closed {
+			mu.Unlock()
+			conn.Close()
+			return
+		}
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		go func() {
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				conn.Close()
+			}()
+
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				select {
+				case lines <- scanner.Text():
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+}
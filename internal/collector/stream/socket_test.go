@@ -0,0 +1,65 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector_MetricsSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.sock")
+	// Stale socket from a crashed run must not prevent listening
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("Unix sockets are not supported: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	r, w := io.Pipe()
+	defer w.Close()
+
+	c := New(r, WithMetricsSocket(path))
+	snapshots, err := c.Start(context.Background())
+	require.NoError(t, err)
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Reporter writes metrics to the socket, schedtrace keeps coming from the stream
+	fmt.Fprintln(conn, "PROCMETR num_goroutines=64")
+	require.Eventually(t, func() bool {
+		go fmt.Fprintln(w, schedLine1)
+		select {
+		case s := <-snapshots:
+			return s.Goroutines == 64
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, c.Stop())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "Socket should be removed after Stop")
+}
+
+func TestListenMetrics_KeepsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0666))
+
+	_, err := listenMetrics(path)
+	assert.Error(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data), "Regular files must not be replaced")
+}
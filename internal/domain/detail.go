@@ -0,0 +1,156 @@
+package domain
+
+// SchedDetail holds per-P, per-M and per-G scheduler state
+// reported by the runtime with GODEBUG scheddetail=1.
+type SchedDetail struct {
+	GCWaiting    bool // Whether the world is being stopped for GC
+	NMIdleLocked int  // Number of idle Ms locked to a goroutine
+	StopWait     int  // Number of Ps still to stop during stop-the-world
+	SysmonWait   bool // Whether sysmon is sleeping
+	Procs        []ProcState
+	Threads      []ThreadState
+	Goroutines   []GoroutineState
+}
+
+// NoID marks an absent reference, e.g. a P that is not held by any M.
+const NoID = -1
+
+// ProcStatus is the status of a P as reported by the runtime.
+type ProcStatus int
+
+// P statuses from runtime/runtime2.go.
+const (
+	ProcIdle ProcStatus = iota
+	ProcRunning
+	ProcSyscall
+	ProcGCStop
+	ProcDead
+)
+
+// String returns a short status name.
+func (s ProcStatus) String() string {
+	switch s {
+	case ProcIdle:
+		return "idle"
+	case ProcRunning:
+		return "running"
+	case ProcSyscall:
+		return "syscall"
+	case ProcGCStop:
+		return "gcstop"
+	case ProcDead:
+		return "dead"
+	}
+	return "unknown"
+}
+
+// ProcState describes a single P (processor).
+type ProcState struct {
+	ID          int
+	Status      ProcStatus
+	SchedTick   uint64 // Incremented on every scheduler call
+	SyscallTick uint64 // Incremented on every system call
+	M           int    // ID of the M holding this P, or NoID
+	RunqSize    int    // Local run queue length
+	GFreeCnt    int    // Number of free Gs cached by this P
+	TimersLen   int    // Number of timers on this P
+}
+
+// ThreadState describes a single M (OS thread).
+type ThreadState struct {
+	ID       int
+	P        int // ID of the P held by this M, or NoID
+	CurG     int // ID of the goroutine running on this M, or NoID
+	Spinning bool
+	Blocked  bool
+	LockedG  int // ID of the goroutine locked to this M, or NoID
+}
+
+// GoroutineStatus is the status of a goroutine as reported by the runtime.
+type GoroutineStatus int
+
+// Goroutine statuses from runtime/runtime2.go.
+const (
+	GoroutineIdle GoroutineStatus = iota
+	GoroutineRunnable
+	GoroutineRunning
+	GoroutineSyscall
+	GoroutineWaiting
+	goroutineMoribundUnused
+	GoroutineDead
+	goroutineEnqueueUnused
+	GoroutineCopyStack
+	GoroutinePreempted
+)
+
+// goroutineScan is set in status while the goroutine stack is being scanned by GC.
+const goroutineScan GoroutineStatus = 0x1000
+
+// String returns a short status name.
+func (s GoroutineStatus) String() string {
+	switch s &^ goroutineScan {
+	case GoroutineIdle:
+		return "idle"
+	case GoroutineRunnable:
+		return "runnable"
+	case GoroutineRunning:
+		return "running"
+	case GoroutineSyscall:
+		return "syscall"
+	case GoroutineWaiting:
+		return "waiting"
+	case GoroutineDead:
+		return "dead"
+	case GoroutineCopyStack:
+		return "copystack"
+	case GoroutinePreempted:
+		return "preempted"
+	}
+	return "unknown"
+}
+
+// GoroutineState describes a single goroutine.
+type GoroutineState struct {
+	ID         int
+	Status     GoroutineStatus
+	WaitReason string // Why the goroutine is parked, e.g. "chan receive"; may be empty
+	M          int    // ID of the M running this goroutine, or NoID
+	LockedM    int    // ID of the M this goroutine is locked to, or NoID
+}
+
+// Proc returns the state of P with the given ID.
+func (d *SchedDetail) Proc(id int) (ProcState, bool) {
+	for _, p := range d.Procs {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return ProcState{}, false
+}
+
+// Thread returns the state of M with the given ID.
+func (d *SchedDetail) Thread(id int) (ThreadState, bool) {
+	for _, m := range d.Threads {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return ThreadState{}, false
+}
+
+// WaitReasons counts waiting goroutines by the reason they are parked.
+// Goroutines without a reported reason are counted by status name.
+func (d *SchedDetail) WaitReasons() map[string]int {
+	reasons := make(map[string]int)
+	for _, g := range d.Goroutines {
+		if g.Status&^goroutineScan != GoroutineWaiting {
+			continue
+		}
+		reason := g.WaitReason
+		if reason == "" {
+			reason = g.Status.String()
+		}
+		reasons[reason]++
+	}
+	return reasons
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcStatus_String(t *testing.T) {
+	assert.Equal(t, "idle", ProcIdle.String())
+	assert.Equal(t, "running", ProcRunning.String())
+	assert.Equal(t, "syscall", ProcSyscall.String())
+	assert.Equal(t, "gcstop", ProcGCStop.String())
+	assert.Equal(t, "dead", ProcDead.String())
+	assert.Equal(t, "unknown", ProcStatus(42).String())
+}
+
+func TestGoroutineStatus_String(t *testing.T) {
+	assert.Equal(t, "runnable", GoroutineRunnable.String())
+	assert.Equal(t, "waiting", GoroutineWaiting.String())
+	assert.Equal(t, "waiting", (GoroutineWaiting | goroutineScan).String(), "scan bit should be ignored")
+	assert.Equal(t, "preempted", GoroutinePreempted.String())
+	assert.Equal(t, "unknown", GoroutineStatus(42).String())
+}
+
+func TestSchedDetail_Lookups(t *testing.T) {
+	d := &SchedDetail{
+		Procs:   []ProcState{{ID: 0, M: 3}, {ID: 1, M: NoID}},
+		Threads: []ThreadState{{ID: 3, P: 0, CurG: 7}},
+		Goroutines: []GoroutineState{
+			{ID: 1, Status: GoroutineWaiting, WaitReason: "select"},
+			{ID: 2, Status: GoroutineWaiting | goroutineScan, WaitReason: "select"},
+			{ID: 3, Status: GoroutineWaiting},
+			{ID: 7, Status: GoroutineRunning, M: 3},
+		},
+	}
+
+	p, ok := d.Proc(0)
+	assert.True(t, ok)
+	assert.Equal(t, 3, p.M)
+	_, ok = d.Proc(5)
+	assert.False(t, ok)
+
+	m, ok := d.Thread(3)
+	assert.True(t, ok)
+	assert.Equal(t, 7, m.CurG)
+	_, ok = d.Thread(0)
+	assert.False(t, ok)
+
+	assert.Equal(t, map[string]int{"select": 2, "waiting": 1}, d.WaitReasons())
+}
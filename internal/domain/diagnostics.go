@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// MaxParseIssues defines how many recent problem lines are kept for diagnostics
+const MaxParseIssues = 20
+
+// ParseReason tells why an input line didn't produce a snapshot as is.
+type ParseReason int
+
+const (
+	// ParseUnmatched is a line that is not trace output, e.g. a log line of the program.
+	ParseUnmatched ParseReason = iota + 1
+	// ParseMalformed is a line that looks like trace output but doesn't match its format.
+	ParseMalformed
+	// ParseRejected is a parsed snapshot with values that failed validation.
+	ParseRejected
+	// ParseSuspicious is a snapshot that failed validation but was kept in lenient mode.
+	ParseSuspicious
+)
+
+// String returns a short lowercase name of the reason.
+func (r ParseReason) String() string {
+	switch r {
+	case ParseUnmatched:
+		return "unmatched"
+	case ParseMalformed:
+		return "malformed"
+	case ParseRejected:
+		return "rejected"
+	case ParseSuspicious:
+		return "suspicious"
+	}
+	return "unknown"
+}
+
+// ParseIssue describes a line that was dropped or kept with a warning.
+type ParseIssue struct {
+	Time   time.Time // Wall-clock time the line was parsed
+	Reason ParseReason
+	Detail string // Human-readable explanation, e.g. "missing field runqueue"
+	Line   string
+}
+
+// ParseReport summarizes how well the input of a source was understood.
+type ParseReport struct {
+	Lines      int // All parsed lines
+	Snapshots  int // Snapshots produced, including suspicious ones
+	Unmatched  int
+	Malformed  int
+	Rejected   int
+	Suspicious int
+	Recent     []ParseIssue // Latest malformed, rejected and suspicious lines, oldest first
+
+	// FieldSet names Go releases whose SCHED line fields were detected in the latest snapshot.
+	FieldSet string
+	// Unknown lists names of SCHED line fields goschedviz doesn't know, sorted.
+	Unknown []string
+}
+
+// Dropped returns the number of trace lines that didn't produce a snapshot.
+func (r ParseReport) Dropped() int {
+	return r.Malformed + r.Rejected
+}
+
+// ParseDiagnostics counts parse outcomes of a source.
+// It is safe for concurrent use.
+type ParseDiagnostics struct {
+	mu     sync.Mutex
+	report ParseReport
+}
+
+// Record counts a parse outcome. Line tells whether an input line was consumed,
+// it's false when a snapshot is completed by the end of input. Snapshot tells
+// whether a snapshot was produced, issue is nil when everything was understood.
+// Unmatched lines are counted but not kept, they are usually program output.
+func (d *ParseDiagnostics) Record(line, snapshot bool, issue *ParseIssue) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if line {
+		d.report.Lines++
+	}
+	if snapshot {
+		d.report.Snapshots++
+	}
+	if issue == nil {
+		return
+	}
+
+	switch issue.Reason {
+	case ParseUnmatched:
+		d.report.Unmatched++
+		return
+	case ParseMalformed:
+		d.report.Malformed++
+	case ParseRejected:
+		d.report.Rejected++
+	case ParseSuspicious:
+		d.report.Suspicious++
+	}
+
+	d.report.Recent = append(d.report.Recent, *issue)
+	if len(d.report.Recent) > MaxParseIssues {
+		d.report.Recent = d.report.Recent[len(d.report.Recent)-MaxParseIssues:]
+	}
+}
+
+// Detect records the field set and unknown fields of a parsed snapshot.
+func (d *ParseDiagnostics) Detect(fieldSet string, extra map[string]string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.report.FieldSet = fieldSet
+	for name := range extra {
+		if i, found := slices.BinarySearch(d.report.Unknown, name); !found {
+			d.report.Unknown = slices.Insert(d.report.Unknown, i, name)
+		}
+	}
+}
+
+// Report returns a copy of the counters and recent issues.
+func (d *ParseDiagnostics) Report() ParseReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	report := d.report
+	report.Recent = append([]ParseIssue(nil), d.report.Recent...)
+	report.Unknown = slices.Clone(d.report.Unknown)
+	return report
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDiagnostics_Record(t *testing.T) {
+	var d ParseDiagnostics
+
+	d.Record(true, false, &ParseIssue{Reason: ParseUnmatched, Line: "hello"})
+	d.Record(true, true, nil)
+	d.Record(false, true, nil) // Snapshot completed by the end of input
+	for i := range MaxParseIssues + 5 {
+		d.Record(true, false, &ParseIssue{Reason: ParseMalformed, Detail: fmt.Sprint(i)})
+	}
+	d.Record(true, true, &ParseIssue{Reason: ParseSuspicious, Detail: "last"})
+
+	report := d.Report()
+	assert.Equal(t, MaxParseIssues+8, report.Lines)
+	assert.Equal(t, 3, report.Snapshots)
+	assert.Equal(t, 1, report.Unmatched)
+	assert.Equal(t, MaxParseIssues+5, report.Malformed)
+	assert.Equal(t, 1, report.Suspicious)
+	assert.Equal(t, MaxParseIssues+5, report.Dropped())
+
+	require.Len(t, report.Recent, MaxParseIssues, "Only latest issues are kept")
+	assert.Equal(t, "6", report.Recent[0].Detail)
+	assert.Equal(t, "last", report.Recent[MaxParseIssues-1].Detail)
+
+	// Report is a copy
+	report.Recent[0].Detail = "changed"
+	assert.Equal(t, "6", d.Report().Recent[0].Detail)
+}
+
+func TestParseDiagnostics_Detect(t *testing.T) {
+	var d ParseDiagnostics
+
+	d.Detect("go1.5-go1.20", nil)
+	d.Detect("go1.21+", map[string]string{"schedticks": "[1 2]", "b": "1"})
+	d.Detect("go1.21+", map[string]string{"b": "2", "a": "3"})
+
+	report := d.Report()
+	assert.Equal(t, "go1.21+", report.FieldSet)
+	assert.Equal(t, []string{"a", "b", "schedticks"}, report.Unknown)
+}
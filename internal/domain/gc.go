@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+// GCCycle describes a single garbage collection cycle
+// reported by the runtime with GODEBUG gctrace=1.
+type GCCycle struct {
+	Number     int // Cycle number, incremented at each GC
+	AtMs       int // Time since program start in milliseconds
+	CPUPercent int // Share of CPU time spent in GC since program start
+
+	// Wall-clock phase durations
+	SweepTermination time.Duration // Stop-the-world sweep termination
+	Mark             time.Duration // Concurrent mark and scan
+	MarkTermination  time.Duration // Stop-the-world mark termination
+
+	// CPU time spent in phases, summed over all Ps
+	SweepTerminationCPU time.Duration
+	AssistCPU           time.Duration // Mark work done by mutator assists
+	BackgroundCPU       time.Duration // Mark work done by background workers
+	IdleCPU             time.Duration // Mark work done by idle Ps
+	MarkTerminationCPU  time.Duration
+
+	HeapStartMB int // Heap size at GC start
+	HeapEndMB   int // Heap size at GC end
+	HeapLiveMB  int // Live heap after marking
+	HeapGoalMB  int // Heap goal of this cycle
+	StacksMB    int // Scannable stack size, 0 if not reported
+	GlobalsMB   int // Scannable globals size, 0 if not reported
+	Procs       int // Number of Ps used
+	Forced      bool
+
+	// Earlier cycles folded into this one when they can't be told apart,
+	// e.g. cycles completed between two polls of runtime/metrics
+	Merged int
+}
+
+// Count returns the number of cycles the entry stands for.
+func (c GCCycle) Count() int {
+	return 1 + c.Merged
+}
+
+// CountGC returns the number of cycles the entries stand for.
+func CountGC(cycles []GCCycle) int {
+	n := 0
+	for _, c := range cycles {
+		n += c.Count()
+	}
+	return n
+}
+
+// STW returns total stop-the-world time of the cycle.
+func (c GCCycle) STW() time.Duration {
+	return c.SweepTermination + c.MarkTermination
+}
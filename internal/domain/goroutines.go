@@ -0,0 +1,14 @@
+package domain
+
+// GoroutineProfile summarizes the goroutine profile of the program, as reported
+// by metrics.Reporter with WithGoroutineProfile.
+type GoroutineProfile struct {
+	States map[string]int  // Goroutines by state, e.g. "runnable" or "chan_receive"
+	Sites  []GoroutineSite // Functions that created most goroutines, most first
+}
+
+// GoroutineSite is a function that created goroutines.
+type GoroutineSite struct {
+	Function string
+	Count    int
+}
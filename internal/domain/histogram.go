@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"math"
+	"slices"
+)
+
+// Histogram is a cumulative runtime/metrics histogram, e.g. "/sched/latencies:seconds".
+// Only non-empty buckets are kept. The unbounded last bucket has math.MaxFloat64
+// as its upper bound, so histograms can be encoded as JSON.
+type Histogram struct {
+	Upper  []float64 // Upper bound of each bucket, ascending
+	Counts []uint64  // Events counted in each bucket since the program started
+}
+
+// Total returns the number of counted events.
+func (h Histogram) Total() uint64 {
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	return total
+}
+
+// Since returns events counted after prev was sampled.
+// If any bucket count went down, the program was restarted and h is returned as is.
+func (h Histogram) Since(prev Histogram) Histogram {
+	before := make(map[float64]uint64, len(prev.Upper))
+	for i, upper := range prev.Upper {
+		before[upper] = prev.Counts[i]
+	}
+
+	var diff Histogram
+	for i, upper := range h.Upper {
+		n := h.Counts[i]
+		if prevCount, found := before[upper]; found {
+			if n < prevCount {
+				return h
+			}
+			n -= prevCount
+			delete(before, upper)
+		}
+		if n > 0 {
+			diff.Upper = append(diff.Upper, upper)
+			diff.Counts = append(diff.Counts, n)
+		}
+	}
+	if len(before) > 0 {
+		// Buckets disappeared, the counts were reset
+		return h
+	}
+	return diff
+}
+
+// Quantile returns the upper bound of the bucket holding the q-th event, 0 if there are none.
+// The unbounded bucket reports the bound of the bucket before it.
+func (h Histogram) Quantile(q float64) float64 {
+	total := h.Total()
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	rank = max(rank, 1)
+	var seen uint64
+	for i, c := range h.Counts {
+		seen += c
+		if seen >= rank {
+			if h.Upper[i] == math.MaxFloat64 && i > 0 {
+				return h.Upper[i-1]
+			}
+			return h.Upper[i]
+		}
+	}
+	return h.Upper[len(h.Upper)-1]
+}
+
+// Bands counts events within coarse bands: band i holds buckets with an upper bound
+// not above bounds[i], the extra last band holds the rest.
+func (h Histogram) Bands(bounds []float64) []uint64 {
+	bands := make([]uint64, len(bounds)+1)
+	for i, upper := range h.Upper {
+		band, _ := slices.BinarySearch(bounds, upper)
+		bands[band] += h.Counts[i]
+	}
+	return bands
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram_Since(t *testing.T) {
+	prev := Histogram{Upper: []float64{1e-6, 1e-5}, Counts: []uint64{10, 5}}
+	next := Histogram{Upper: []float64{1e-6, 1e-5, 1e-3}, Counts: []uint64{12, 5, 1}}
+
+	diff := next.Since(prev)
+	assert.Equal(t, []float64{1e-6, 1e-3}, diff.Upper, "Unchanged buckets are dropped")
+	assert.Equal(t, []uint64{2, 1}, diff.Counts)
+	assert.Equal(t, uint64(3), diff.Total())
+
+	restarted := Histogram{Upper: []float64{1e-6}, Counts: []uint64{3}}
+	assert.Equal(t, restarted, restarted.Since(prev), "Counts went down after restart")
+	assert.Equal(t, next, next.Since(Histogram{}))
+}
+
+func TestHistogram_Quantile(t *testing.T) {
+	h := Histogram{
+		Upper:  []float64{1e-6, 1e-5, 1e-4, math.MaxFloat64},
+		Counts: []uint64{50, 40, 9, 1},
+	}
+
+	assert.Equal(t, 1e-6, h.Quantile(0.5))
+	assert.Equal(t, 1e-5, h.Quantile(0.9))
+	assert.Equal(t, 1e-4, h.Quantile(0.99))
+	assert.Equal(t, 1e-4, h.Quantile(1), "Unbounded bucket reports the previous bound")
+	assert.Zero(t, Histogram{}.Quantile(0.5))
+}
+
+func TestHistogram_Bands(t *testing.T) {
+	h := Histogram{
+		Upper:  []float64{5e-7, 1e-6, 2e-6, 5e-3, math.MaxFloat64},
+		Counts: []uint64{1, 2, 3, 4, 5},
+	}
+	assert.Equal(t, []uint64{3, 3, 4, 5}, h.Bands([]float64{1e-6, 1e-5, 1e-2}))
+}
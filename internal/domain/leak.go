@@ -0,0 +1,120 @@
+package domain
+
+import "math"
+
+// Goroutine leak detection thresholds
+const (
+	// LeakMinPoints is the least number of samples growth is judged by.
+	LeakMinPoints = 10
+	// LeakMinGrowth is the least number of goroutines added over the window
+	// for growth to be reported. Growth also has to be at least a tenth of the baseline.
+	LeakMinGrowth = 10
+	// LeakMinConfidence is the least confidence growth is reported with.
+	LeakMinConfidence = 0.8
+)
+
+// LeakReport is the result of goroutine leak detection.
+type LeakReport struct {
+	Suspected  bool    `json:"suspected"`
+	Slope      float64 `json:"slope"`      // Goroutines added per second, least squares fit
+	Confidence float64 `json:"confidence"` // From 0 to 1, how steady and monotonic the growth is
+	Baseline   int     `json:"baseline"`   // Lowest goroutines count in the first quarter of the window
+	Latest     int     `json:"latest"`
+	Window     float64 `json:"window"` // Seconds covered by analyzed samples
+	Points     int     `json:"points"`
+}
+
+// Growth returns the number of goroutines added since the baseline.
+func (r LeakReport) Growth() int {
+	return r.Latest - r.Baseline
+}
+
+// DetectLeak analyzes the goroutines count over history. Growth is suspected to be a leak
+// when it's steady, mostly monotonic and the count never returns to the baseline.
+// Samples before the latest restart and samples without goroutines count are skipped.
+func DetectLeak(history []SchedulerSnapshot) LeakReport {
+	var points []SchedulerSnapshot
+	for _, s := range history {
+		if s.Marker == MarkerRestart || s.Marker == MarkerRebuild {
+			points = points[:0]
+		}
+		if s.Goroutines > 0 {
+			points = append(points, s)
+		}
+	}
+
+	report := LeakReport{Points: len(points)}
+	if len(points) < 2 {
+		return report
+	}
+	first, last := points[0], points[len(points)-1]
+	report.Window = float64(last.TimeMs-first.TimeMs) / 1000
+	report.Latest = last.Goroutines
+
+	// Baseline is the lowest count at the start, growth must never fall back to it
+	quarter := max(len(points)/4, 1)
+	report.Baseline = points[0].Goroutines
+	for _, s := range points[:quarter] {
+		report.Baseline = min(report.Baseline, s.Goroutines)
+	}
+	returned := false
+	for _, s := range points[quarter:] {
+		if s.Goroutines <= report.Baseline {
+			returned = true
+		}
+	}
+
+	slope, r2 := fitLine(points)
+	report.Slope = slope
+	report.Confidence = r2 * risingShare(points)
+
+	report.Suspected = len(points) >= LeakMinPoints &&
+		!returned &&
+		slope > 0 &&
+		report.Growth() >= max(LeakMinGrowth, report.Baseline/10) &&
+		report.Confidence >= LeakMinConfidence
+	return report
+}
+
+// fitLine fits goroutines count over time in seconds with least squares.
+// It returns the slope and the coefficient of determination, R² is 0 for a flat series.
+func fitLine(points []SchedulerSnapshot) (slope, r2 float64) {
+	n := float64(len(points))
+	var sumX, sumY float64
+	for _, p := range points {
+		sumX += float64(p.TimeMs) / 1000
+		sumY += float64(p.Goroutines)
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var sxx, sxy, syy float64
+	for _, p := range points {
+		dx, dy := float64(p.TimeMs)/1000-meanX, float64(p.Goroutines)-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0, 0
+	}
+	slope = sxy / sxx
+	r2 = sxy * sxy / (sxx * syy)
+	return slope, math.Min(r2, 1)
+}
+
+// risingShare returns the share of growth among all changes of the count,
+// so small dips of a growing series barely lower it.
+func risingShare(points []SchedulerSnapshot) float64 {
+	var up, down int
+	for i := 1; i < len(points); i++ {
+		if d := points[i].Goroutines - points[i-1].Goroutines; d > 0 {
+			up += d
+		} else {
+			down -= d
+		}
+	}
+	if up+down == 0 {
+		return 0
+	}
+	return float64(up) / float64(up+down)
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// goroutinesHistory returns a snapshot per second with the given goroutines counts.
+func goroutinesHistory(counts ...int) []SchedulerSnapshot {
+	history := make([]SchedulerSnapshot, len(counts))
+	for i, n := range counts {
+		history[i] = SchedulerSnapshot{TimeMs: (i + 1) * 1000, Goroutines: n}
+	}
+	return history
+}
+
+func TestDetectLeak(t *testing.T) {
+	tests := []struct {
+		name      string
+		counts    []int
+		suspected bool
+	}{
+		{
+			name:      "steady growth",
+			counts:    []int{100, 110, 121, 130, 139, 150, 161, 170, 180, 191, 200, 210},
+			suspected: true,
+		},
+		{
+			name:      "growth with small dips",
+			counts:    []int{100, 112, 110, 125, 140, 138, 155, 170, 168, 185, 200, 215},
+			suspected: true,
+		},
+		{
+			name:   "flat",
+			counts: []int{100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100},
+		},
+		{
+			name:   "returns to baseline",
+			counts: []int{100, 110, 120, 130, 140, 150, 160, 170, 180, 190, 100, 110},
+		},
+		{
+			name:   "bursty load",
+			counts: []int{100, 300, 120, 280, 110, 320, 130, 290, 140, 310, 150, 300},
+		},
+		{
+			name:   "too small growth",
+			counts: []int{1000, 1001, 1002, 1003, 1004, 1005, 1006, 1007, 1008, 1009, 1010, 1011},
+		},
+		{
+			name:   "too few points",
+			counts: []int{100, 120, 140, 160, 180},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := DetectLeak(goroutinesHistory(tt.counts...))
+			assert.Equal(t, tt.suspected, report.Suspected, "%+v", report)
+		})
+	}
+}
+
+func TestDetectLeak_Report(t *testing.T) {
+	report := DetectLeak(goroutinesHistory(100, 110, 120, 130, 140, 150, 160, 170, 180, 190, 200, 210))
+
+	assert.True(t, report.Suspected)
+	assert.InDelta(t, 10, report.Slope, 1e-9, "Goroutines per second")
+	assert.InDelta(t, 1, report.Confidence, 1e-9)
+	assert.Equal(t, 100, report.Baseline)
+	assert.Equal(t, 210, report.Latest)
+	assert.Equal(t, 110, report.Growth())
+	assert.Equal(t, 11.0, report.Window)
+	assert.Equal(t, 12, report.Points)
+}
+
+func TestDetectLeak_Restart(t *testing.T) {
+	history := goroutinesHistory(100, 110, 120, 130, 140, 150, 160, 170, 180, 190, 200, 210, 10, 11, 10)
+	history[12].Marker = MarkerRestart
+
+	report := DetectLeak(history)
+	assert.False(t, report.Suspected, "Growth before the restart doesn't count")
+	assert.Equal(t, 3, report.Points)
+
+	// Samples without goroutines count are skipped
+	assert.Zero(t, DetectLeak(make([]SchedulerSnapshot, 20)).Points)
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "sync"
+
+// MaxLogLines defines how many output lines of the monitored program are kept for display
+const MaxLogLines = 1000
+
+// LogEntry is an output line numbered in the order it was received.
+type LogEntry struct {
+	Seq uint64 // Sequence number, starting from 1
+	OutputLine
+}
+
+// LogBuffer keeps the most recent output lines of the monitored program.
+// It is safe for concurrent use.
+type LogBuffer struct {
+	mu      sync.Mutex
+	entries []LogEntry // ring buffer, oldest entry at start once full
+	start   int
+	seq     uint64
+}
+
+// Add appends a line, dropping the oldest one when the buffer is full.
+func (b *LogBuffer) Add(line OutputLine) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	entry := LogEntry{Seq: b.seq, OutputLine: line}
+	if len(b.entries) < MaxLogLines {
+		b.entries = append(b.entries, entry)
+		return
+	}
+	b.entries[b.start] = entry
+	b.start = (b.start + 1) % MaxLogLines
+}
+
+// Entries returns a copy of kept lines, oldest first.
+func (b *LogBuffer) Entries() []LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result := make([]LogEntry, 0, len(b.entries))
+	result = append(result, b.entries[b.start:]...)
+	return append(result, b.entries[:b.start]...)
+}
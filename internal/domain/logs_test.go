@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogBuffer(t *testing.T) {
+	var b LogBuffer
+	assert.Empty(t, b.Entries())
+
+	b.Add(OutputLine{Stream: "stdout", Text: "first"})
+	b.Add(OutputLine{Stream: "stderr", Text: "second"})
+
+	entries := b.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint64(1), entries[0].Seq)
+	assert.Equal(t, "first", entries[0].Text)
+	assert.Equal(t, "stderr", entries[1].Stream)
+}
+
+func TestLogBuffer_Overflow(t *testing.T) {
+	var b LogBuffer
+	total := MaxLogLines + 10
+	for i := 1; i <= total; i++ {
+		b.Add(OutputLine{Text: fmt.Sprint(i)})
+	}
+
+	entries := b.Entries()
+	require.Len(t, entries, MaxLogLines)
+	assert.Equal(t, uint64(11), entries[0].Seq, "Oldest lines should be dropped")
+	assert.Equal(t, "11", entries[0].Text)
+	assert.Equal(t, uint64(total), entries[len(entries)-1].Seq)
+
+	for i := 1; i < len(entries); i++ {
+		assert.Equal(t, entries[i-1].Seq+1, entries[i].Seq, "Entries should stay in order")
+	}
+}
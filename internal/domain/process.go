@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ExitStatus describes how a run of the monitored process ended.
+type ExitStatus struct {
+	Code       int           // Exit code, -1 if the process was killed by a signal
+	Signal     string        // Signal that killed the process, empty otherwise
+	Runtime    time.Duration // Time between start and exit of the process
+	Restarts   int           // Number of restarts before this run
+	Restarting bool          // Process is going to be started again
+	Watching   bool          // Process is started again when its sources change
+}
+
+// Failed reports whether the process exited with a non-zero code or was killed.
+func (s ExitStatus) Failed() bool {
+	return s.Code != 0 || s.Signal != ""
+}
+
+// String describes the exit, e.g. "exited with code 1 after 2.5s".
+func (s ExitStatus) String() string {
+	runtime := s.Runtime.Round(100 * time.Millisecond)
+	if s.Signal != "" {
+		return fmt.Sprintf("killed by signal %q after %s", s.Signal, runtime)
+	}
+	return fmt.Sprintf("exited with code %d after %s", s.Code, runtime)
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     ExitStatus
+		wantFailed bool
+		wantString string
+	}{
+		{
+			name:       "success",
+			status:     ExitStatus{Code: 0, Runtime: 12340 * time.Millisecond},
+			wantString: "exited with code 0 after 12.3s",
+		},
+		{
+			name:       "exit code",
+			status:     ExitStatus{Code: 2, Runtime: 1500 * time.Millisecond},
+			wantFailed: true,
+			wantString: "exited with code 2 after 1.5s",
+		},
+		{
+			name:       "signal",
+			status:     ExitStatus{Code: -1, Signal: "killed", Runtime: time.Minute},
+			wantFailed: true,
+			wantString: `killed by signal "killed" after 1m0s`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantFailed, tt.status.Failed())
+			assert.Equal(t, tt.wantString, tt.status.String())
+		})
+	}
+}
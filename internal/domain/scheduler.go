@@ -1,22 +1,96 @@
 // Package domain defines the core types and interfaces for the Go scheduler monitoring system.
 package domain
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
+
+// Marker flags a notable event that happened right before a snapshot was taken.
+type Marker int
+
+const (
+	// MarkerNone is a regular snapshot.
+	MarkerNone Marker = iota
+	// MarkerReset discards previously accumulated history, e.g. after seeking in a replay.
+	MarkerReset
+	// MarkerRestart is the first snapshot of a restarted process. History is kept.
+	MarkerRestart
+	// MarkerRebuild is the first snapshot of a process rebuilt after its sources changed. History is kept.
+	MarkerRebuild
+)
+
+// OutputLine is a single raw line written by the monitored process.
+type OutputLine struct {
+	Time   time.Time // Wall-clock time the line was received
+	Stream string    // Source name: "stderr", "stdout", "metrics" side channel or "input" stream
+	Text   string    // Line content without trailing newline
+}
 
 // SchedulerSnapshot represents parsed values from a single "SCHED" trace line.
 // It contains various metrics about Go runtime scheduler state at a specific moment.
 type SchedulerSnapshot struct {
-	TimeMs          int   // Time since start in milliseconds
-	GoMaxProcs      int   // Current GOMAXPROCS value
-	IdleProcs       int   // Number of idle processors
-	Threads         int   // Total number of threads
-	SpinningThreads int   // Number of spinning threads
-	NeedSpinning    int   // Number of threads that need spinning
-	IdleThreads     int   // Number of idle threads
-	RunQueue        int   // Global Run Queue (GRQ) length
-	LRQSum          int   // Sum of all Local Run Queues
-	LRQ             []int // Local Run Queue length for each P
-	Goroutines      int   // Number of goroutines from process metrics
+	TimeMs          int    // Time since start in milliseconds
+	GoMaxProcs      int    // Current GOMAXPROCS value
+	IdleProcs       int    // Number of idle processors
+	Threads         int    // Total number of threads
+	SpinningThreads int    // Number of spinning threads
+	NeedSpinning    int    // Number of threads that need spinning
+	IdleThreads     int    // Number of idle threads
+	RunQueue        int    // Global Run Queue (GRQ) length
+	LRQSum          int    // Sum of all Local Run Queues
+	LRQ             []int  // Local Run Queue length for each P
+	Goroutines      int    // Number of goroutines from process metrics
+	Marker          Marker // Event that happened right before this snapshot
+
+	// Received is the wall-clock time the snapshot was received by goschedviz
+	// or, for recordings and remote agents, by the original collector.
+	Received time.Time
+	// Seq increases by one with every snapshot of the source, across restarts of the program.
+	Seq uint64
+	// Source identifies where the snapshot came from, e.g. target path or metrics URL.
+	Source string
+
+	// Metrics holds runtime/metrics values by full name, e.g. "/gc/cycles/total:gc-cycles",
+	// from the latest metrics.Reporter line or Handler poll. It's shared between
+	// snapshots and must not be modified.
+	Metrics map[string]float64
+	// Histograms holds runtime/metrics histograms by full name, e.g. "/sched/latencies:seconds".
+	// Like Metrics, it's shared between snapshots and must not be modified.
+	Histograms map[string]Histogram
+	// GoroutineProfile summarizes the goroutine profile when the program reports it, nil otherwise.
+	// It's shared between snapshots and must not be modified.
+	GoroutineProfile *GoroutineProfile
+
+	// FieldSet names Go releases whose SCHED line fields were detected, e.g. "go1.21+".
+	FieldSet string
+	// Extra holds SCHED line fields goschedviz doesn't know, e.g. ones added by
+	// a newer Go release, nil if there are none.
+	Extra map[string]string
+
+	// Detail holds per-P, per-M and per-G state when the target runs with
+	// GODEBUG scheddetail=1, nil otherwise.
+	Detail *SchedDetail
+
+	// GC holds garbage collection cycles completed since the previous snapshot
+	// when the target runs with GODEBUG gctrace=1.
+	GC []GCCycle
+}
+
+// Sequencer stamps snapshots of a single source with receive time,
+// sequence number and source ID. It is not safe for concurrent use.
+type Sequencer struct {
+	Source string
+	seq    uint64
+}
+
+// Stamp returns the snapshot stamped with the receive time and the next sequence number.
+func (s *Sequencer) Stamp(snapshot SchedulerSnapshot, received time.Time) SchedulerSnapshot {
+	s.seq++
+	snapshot.Received = received
+	snapshot.Seq = s.seq
+	snapshot.Source = s.Source
+	return snapshot
 }
 
 // MonitorState maintains the current state and history of scheduler metrics.
@@ -37,11 +111,19 @@ type MonitorState struct {
 // MaxHistoryPoints defines how many data points we keep for plotting
 const MaxHistoryPoints = 60
 
-// Update saves new snapshot and adds it to history, maintaining max history size
+// Update saves new snapshot and adds it to history, maintaining max history size.
+// A snapshot with MarkerReset starts history over. A stamped snapshot that isn't
+// newer than the latest one of the same source is a duplicate and is ignored.
 func (ms *MonitorState) Update(data SchedulerSnapshot) {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
+	if data.Marker == MarkerReset {
+		ms.history = nil
+	} else if data.Seq != 0 && data.Source == ms.latest.Source && data.Seq <= ms.latest.Seq {
+		return
+	}
+
 	ms.latest = data
 	ms.history = append(ms.history, data)
 	if len(ms.history) > MaxHistoryPoints {
@@ -3,6 +3,7 @@ package domain
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -89,6 +90,31 @@ func TestMonitorState_Update(t *testing.T) {
 	}
 }
 
+func TestMonitorState_ResetMarker(t *testing.T) {
+	ms := &MonitorState{}
+	ms.Update(SchedulerSnapshot{TimeMs: 1000})
+	ms.Update(SchedulerSnapshot{TimeMs: 2000})
+
+	reset := SchedulerSnapshot{TimeMs: 500, Marker: MarkerReset}
+	ms.Update(reset)
+	ms.Update(SchedulerSnapshot{TimeMs: 600})
+
+	latest, history := ms.GetSnapshot()
+	assert.Equal(t, 600, latest.TimeMs)
+	require.Len(t, history, 2, "history before reset marker should be discarded")
+	assert.Equal(t, reset, history[0])
+}
+
+func TestMonitorState_RestartKeepsHistory(t *testing.T) {
+	ms := &MonitorState{}
+	ms.Update(SchedulerSnapshot{TimeMs: 5000})
+	ms.Update(SchedulerSnapshot{TimeMs: 100, Marker: MarkerRestart})
+
+	_, history := ms.GetSnapshot()
+	require.Len(t, history, 2)
+	assert.Equal(t, MarkerRestart, history[1].Marker)
+}
+
 func TestMonitorState_ConcurrentAccess(t *testing.T) {
 	ms := &MonitorState{}
 	const numGoroutines = 10
@@ -171,3 +197,35 @@ func TestMonitorState_GetSnapshot(t *testing.T) {
 	assert.Equal(t, original.TimeMs, newHistory[0].TimeMs, "internal history TimeMs was modified")
 	assert.Equal(t, original.LRQ[0], newHistory[0].LRQ[0], "internal history LRQ was modified")
 }
+
+func TestSequencer_Stamp(t *testing.T) {
+	seq := Sequencer{Source: "./cmd/api"}
+	received := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	first := seq.Stamp(SchedulerSnapshot{TimeMs: 1000}, received)
+	second := seq.Stamp(SchedulerSnapshot{TimeMs: 100, Marker: MarkerRestart}, received.Add(time.Second))
+
+	assert.Equal(t, uint64(1), first.Seq)
+	assert.Equal(t, received, first.Received)
+	assert.Equal(t, "./cmd/api", first.Source)
+	assert.Equal(t, uint64(2), second.Seq, "Sequence continues across restarts")
+	assert.Equal(t, MarkerRestart, second.Marker)
+}
+
+func TestMonitorState_Duplicates(t *testing.T) {
+	ms := &MonitorState{}
+	ms.Update(SchedulerSnapshot{Source: "api", Seq: 1, Goroutines: 1})
+	ms.Update(SchedulerSnapshot{Source: "api", Seq: 2, Goroutines: 2})
+	ms.Update(SchedulerSnapshot{Source: "api", Seq: 2, Goroutines: 2})
+	ms.Update(SchedulerSnapshot{Source: "api", Seq: 1, Goroutines: 1})
+
+	latest, history := ms.GetSnapshot()
+	assert.Equal(t, uint64(2), latest.Seq)
+	assert.Len(t, history, 2, "Duplicates are ignored")
+
+	// Reset starts a new sequence, e.g. after the agent was restarted
+	ms.Update(SchedulerSnapshot{Source: "api", Seq: 1, Goroutines: 3, Marker: MarkerReset})
+	latest, history = ms.GetSnapshot()
+	assert.Equal(t, 3, latest.Goroutines)
+	assert.Len(t, history, 1)
+}
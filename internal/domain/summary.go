@@ -0,0 +1,302 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SummaryFields lists snapshot fields a session summary covers, named like in the schedtrace line.
+var SummaryFields = []string{
+	"gomaxprocs",
+	"idleprocs",
+	"threads",
+	"spinningthreads",
+	"needspinning",
+	"idlethreads",
+	"runqueue",
+	"lrqsum",
+	"goroutines",
+}
+
+// DefaultSummaryThresholds measure time with goroutines waiting in the global run queue
+// and time with idle Ps.
+var DefaultSummaryThresholds = []Threshold{
+	{Field: "runqueue", Value: 0},
+	{Field: "idleprocs", Value: 0},
+}
+
+// Threshold is a value of a snapshot field time above which is measured.
+type Threshold struct {
+	Field string
+	Value float64
+}
+
+// ParseThreshold parses a threshold in the "field=value" form, e.g. "runqueue=10".
+func ParseThreshold(s string) (Threshold, error) {
+	field, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return Threshold{}, fmt.Errorf("expected field=value, got %q", s)
+	}
+	field = strings.ToLower(strings.TrimSpace(field))
+	if !slices.Contains(SummaryFields, field) {
+		return Threshold{}, fmt.Errorf("unknown field %q, use one of: %s", field, strings.Join(SummaryFields, ", "))
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return Threshold{}, fmt.Errorf("invalid threshold of %s: %q", field, value)
+	}
+	return Threshold{Field: field, Value: v}, nil
+}
+
+// FieldValue returns the value of a summary field of the snapshot, 0 for unknown fields.
+func FieldValue(s SchedulerSnapshot, field string) float64 {
+	switch field {
+	case "gomaxprocs":
+		return float64(s.GoMaxProcs)
+	case "idleprocs":
+		return float64(s.IdleProcs)
+	case "threads":
+		return float64(s.Threads)
+	case "spinningthreads":
+		return float64(s.SpinningThreads)
+	case "needspinning":
+		return float64(s.NeedSpinning)
+	case "idlethreads":
+		return float64(s.IdleThreads)
+	case "runqueue":
+		return float64(s.RunQueue)
+	case "lrqsum":
+		return float64(s.LRQSum)
+	case "goroutines":
+		return float64(s.Goroutines)
+	}
+	return 0
+}
+
+// Stats describes the distribution of a series. Percentiles use the nearest-rank method.
+type Stats struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+}
+
+// NewStats computes statistics of the values, zero for no values.
+func NewStats(values []float64) Stats {
+	if len(values) == 0 {
+		return Stats{}
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		return sorted[min(max(i, 0), len(sorted)-1)]
+	}
+	return Stats{
+		Min:  sorted[0],
+		Max:  sorted[len(sorted)-1],
+		Mean: sum / float64(len(sorted)),
+		P50:  rank(0.50),
+		P95:  rank(0.95),
+		P99:  rank(0.99),
+	}
+}
+
+// FieldStats describes the distribution of a snapshot field over the session.
+type FieldStats struct {
+	Field string `json:"field"`
+	Stats
+}
+
+// ThresholdTime is the time a field stayed above a threshold.
+type ThresholdTime struct {
+	Field     string  `json:"field"`
+	Threshold float64 `json:"threshold"`
+	Above     float64 `json:"above"` // Seconds
+	Share     float64 `json:"share"` // Of the session duration, from 0 to 1
+}
+
+// ProcStats describes the local run queue of a single P over the session.
+type ProcStats struct {
+	P int `json:"p"`
+	Stats
+}
+
+// Imbalance describes how unevenly goroutines are spread over local run queues.
+type Imbalance struct {
+	// Spread is the difference between the longest and the shortest LRQ of a snapshot
+	Spread Stats `json:"spread"`
+	// Imbalanced is the share of snapshots where some P has at least two goroutines
+	// more queued than another one, from 0 to 1
+	Imbalanced float64     `json:"imbalanced"`
+	Procs      []ProcStats `json:"procs"`
+}
+
+// Summary holds statistics of a monitoring session.
+type Summary struct {
+	Snapshots  int             `json:"snapshots"`
+	Excluded   int             `json:"excluded"` // Snapshots within the warm-up
+	Warmup     float64         `json:"warmup"`   // Seconds
+	Duration   float64         `json:"duration"` // Seconds covered by summarized snapshots
+	Fields     []FieldStats    `json:"fields"`
+	Thresholds []ThresholdTime `json:"thresholds,omitempty"`
+	Imbalance  *Imbalance      `json:"imbalance,omitempty"` // Nil if no snapshot had LRQs
+	Leak       LeakReport      `json:"leak"`
+}
+
+// Summarizer accumulates every snapshot of a session, unlike MonitorState that keeps
+// only recent history. It is safe for concurrent use.
+type Summarizer struct {
+	warmup     time.Duration
+	thresholds []Threshold
+
+	mu       sync.Mutex
+	start    time.Time // Time of the first snapshot
+	excluded int
+	last     time.Time // Time of the latest summarized snapshot
+	lastSnap SchedulerSnapshot
+
+	values     map[string][]float64
+	goroutines bool
+	above      []time.Duration // By threshold
+	duration   time.Duration
+	spreads    []float64
+	imbalanced int
+	lrq        [][]float64         // By P
+	counts     []SchedulerSnapshot // Goroutines counts for leak detection
+}
+
+// NewSummarizer creates a summarizer that skips snapshots received within the warm-up
+// after the first one and measures time above the thresholds.
+func NewSummarizer(warmup time.Duration, thresholds []Threshold) *Summarizer {
+	s := &Summarizer{warmup: warmup, thresholds: thresholds}
+	s.reset()
+	return s
+}
+
+// reset starts the session over.
+func (s *Summarizer) reset() {
+	s.start, s.last = time.Time{}, time.Time{}
+	s.lastSnap = SchedulerSnapshot{}
+	s.excluded, s.imbalanced = 0, 0
+	s.values = make(map[string][]float64, len(SummaryFields))
+	s.goroutines = false
+	s.above = make([]time.Duration, len(s.thresholds))
+	s.duration = 0
+	s.spreads, s.lrq, s.counts = nil, nil, nil
+}
+
+// SnapshotTime returns when the snapshot was taken. Snapshots without receive time
+// are placed by time since program start.
+func SnapshotTime(snapshot SchedulerSnapshot) time.Time {
+	if !snapshot.Received.IsZero() {
+		return snapshot.Received
+	}
+	return time.UnixMilli(int64(snapshot.TimeMs))
+}
+
+// Add accounts for the snapshot. A snapshot with MarkerReset, e.g. after seeking a replay,
+// starts the session over.
+func (s *Summarizer) Add(snapshot SchedulerSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if snapshot.Marker == MarkerReset {
+		s.reset()
+	}
+	at := SnapshotTime(snapshot)
+	if s.start.IsZero() {
+		s.start = at
+	}
+	if at.Sub(s.start) < s.warmup {
+		s.excluded++
+		return
+	}
+
+	// The previous snapshot stands for the time until this one
+	if !s.last.IsZero() {
+		if dt := at.Sub(s.last); dt > 0 {
+			s.duration += dt
+			for i, t := range s.thresholds {
+				if FieldValue(s.lastSnap, t.Field) > t.Value {
+					s.above[i] += dt
+				}
+			}
+		}
+	}
+	s.last, s.lastSnap = at, snapshot
+
+	for _, field := range SummaryFields {
+		s.values[field] = append(s.values[field], FieldValue(snapshot, field))
+	}
+	if snapshot.Goroutines > 0 {
+		s.goroutines = true
+	}
+	s.counts = append(s.counts, SchedulerSnapshot{TimeMs: snapshot.TimeMs, Goroutines: snapshot.Goroutines, Marker: snapshot.Marker})
+
+	if len(snapshot.LRQ) > 0 {
+		shortest, longest := slices.Min(snapshot.LRQ), slices.Max(snapshot.LRQ)
+		s.spreads = append(s.spreads, float64(longest-shortest))
+		if longest-shortest >= 2 {
+			s.imbalanced++
+		}
+		for len(s.lrq) < len(snapshot.LRQ) {
+			s.lrq = append(s.lrq, nil)
+		}
+		for p, n := range snapshot.LRQ {
+			s.lrq[p] = append(s.lrq[p], float64(n))
+		}
+	}
+}
+
+// Summary returns statistics of the snapshots added so far. Goroutines are left out
+// if the program doesn't report their count.
+func (s *Summarizer) Summary() Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := Summary{
+		Snapshots: len(s.values["gomaxprocs"]),
+		Excluded:  s.excluded,
+		Warmup:    s.warmup.Seconds(),
+		Duration:  s.duration.Seconds(),
+		Leak:      DetectLeak(s.counts),
+	}
+	for _, field := range SummaryFields {
+		if field == "goroutines" && !s.goroutines {
+			continue
+		}
+		summary.Fields = append(summary.Fields, FieldStats{Field: field, Stats: NewStats(s.values[field])})
+	}
+	for i, t := range s.thresholds {
+		tt := ThresholdTime{Field: t.Field, Threshold: t.Value, Above: s.above[i].Seconds()}
+		if s.duration > 0 {
+			tt.Share = float64(s.above[i]) / float64(s.duration)
+		}
+		summary.Thresholds = append(summary.Thresholds, tt)
+	}
+
+	if len(s.spreads) > 0 {
+		imbalance := &Imbalance{
+			Spread:     NewStats(s.spreads),
+			Imbalanced: float64(s.imbalanced) / float64(len(s.spreads)),
+		}
+		for p, values := range s.lrq {
+			imbalance.Procs = append(imbalance.Procs, ProcStats{P: p, Stats: NewStats(values)})
+		}
+		summary.Imbalance = imbalance
+	}
+	return summary
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStats(t *testing.T) {
+	values := make([]float64, 100)
+	for i := range values {
+		values[len(values)-1-i] = float64(i + 1)
+	}
+	assert.Equal(t, Stats{Min: 1, Max: 100, Mean: 50.5, P50: 50, P95: 95, P99: 99}, NewStats(values))
+	assert.Equal(t, float64(100), values[0], "Values are not sorted in place")
+
+	assert.Equal(t, Stats{Min: 7, Max: 7, Mean: 7, P50: 7, P95: 7, P99: 7}, NewStats([]float64{7}))
+	assert.Equal(t, Stats{}, NewStats(nil))
+}
+
+func TestParseThreshold(t *testing.T) {
+	threshold, err := ParseThreshold("RunQueue=10")
+	require.NoError(t, err)
+	assert.Equal(t, Threshold{Field: "runqueue", Value: 10}, threshold)
+
+	for _, s := range []string{"runqueue", "grq=10", "threads=many", "=1"} {
+		_, err := ParseThreshold(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestSummarizer(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewSummarizer(2*time.Second, []Threshold{{Field: "runqueue", Value: 5}})
+
+	// Warm-up
+	s.Add(SchedulerSnapshot{Received: start, GoMaxProcs: 2, RunQueue: 100, LRQ: []int{50, 0}})
+	s.Add(SchedulerSnapshot{Received: start.Add(time.Second), GoMaxProcs: 2, RunQueue: 100, LRQ: []int{50, 0}})
+
+	s.Add(SchedulerSnapshot{Received: start.Add(2 * time.Second), GoMaxProcs: 2, RunQueue: 10, Threads: 4, LRQ: []int{3, 0}})
+	s.Add(SchedulerSnapshot{Received: start.Add(3 * time.Second), GoMaxProcs: 2, RunQueue: 0, Threads: 6, LRQ: []int{1, 1}})
+	s.Add(SchedulerSnapshot{Received: start.Add(5 * time.Second), GoMaxProcs: 2, RunQueue: 8, Threads: 5, LRQ: []int{0, 2}})
+
+	summary := s.Summary()
+	assert.Equal(t, 3, summary.Snapshots)
+	assert.Equal(t, 2, summary.Excluded)
+	assert.Equal(t, 3.0, summary.Duration)
+
+	require.Len(t, summary.Fields, len(SummaryFields)-1, "Goroutines are left out when not reported")
+	stats := map[string]Stats{}
+	for _, f := range summary.Fields {
+		stats[f.Field] = f.Stats
+	}
+	assert.Equal(t, Stats{Min: 0, Max: 10, Mean: 6, P50: 8, P95: 10, P99: 10}, stats["runqueue"])
+	assert.Equal(t, Stats{Min: 4, Max: 6, Mean: 5, P50: 5, P95: 6, P99: 6}, stats["threads"])
+
+	require.Len(t, summary.Thresholds, 1)
+	assert.Equal(t, 1.0, summary.Thresholds[0].Above, "Only the first second has GRQ above 5")
+	assert.InDelta(t, 1.0/3, summary.Thresholds[0].Share, 0.001)
+
+	require.NotNil(t, summary.Imbalance)
+	assert.Equal(t, 3.0, summary.Imbalance.Spread.Max)
+	assert.InDelta(t, 2.0/3, summary.Imbalance.Imbalanced, 0.001)
+	require.Len(t, summary.Imbalance.Procs, 2)
+	assert.Equal(t, ProcStats{P: 1, Stats: Stats{Min: 0, Max: 2, Mean: 1, P50: 1, P95: 2, P99: 2}}, summary.Imbalance.Procs[1])
+
+	// Seeking a replay starts over
+	s.Add(SchedulerSnapshot{Received: start, GoMaxProcs: 2, Marker: MarkerReset})
+	summary = s.Summary()
+	assert.Equal(t, 1, summary.Excluded)
+	assert.Zero(t, summary.Snapshots)
+}
+
+func TestSummarizer_Goroutines(t *testing.T) {
+	s := NewSummarizer(0, nil)
+	for i := range LeakMinPoints * 2 {
+		s.Add(SchedulerSnapshot{TimeMs: i * 1000, GoMaxProcs: 1, Goroutines: 100 + i*20})
+	}
+
+	summary := s.Summary()
+	assert.Equal(t, 19.0, summary.Duration, "Snapshots without receive time are placed by TimeMs")
+	last := summary.Fields[len(summary.Fields)-1]
+	assert.Equal(t, "goroutines", last.Field)
+	assert.Equal(t, float64(480), last.Max)
+	assert.True(t, summary.Leak.Suspected)
+	assert.Nil(t, summary.Imbalance)
+}
@@ -0,0 +1,170 @@
+// Package recording stores raw output of a monitored process with timestamps,
+// so a session can be replayed later.
+//
+// A recording is a JSON Lines file. The first line is the session Header,
+// every following line is a single output line of the target:
+//
+//	{"version":1,"started":"2025-01-02T15:04:05Z","target":"./cmd/api","period":1000,...}
+//	{"t":"2025-01-02T15:04:06.001Z","s":"stderr","l":"SCHED 1001ms: gomaxprocs=8 ..."}
+//	{"t":"2025-01-02T15:04:06.120Z","s":"stderr","l":"PROCMETR num_goroutines=42"}
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+// Version is the current recording format version.
+const Version = 1
+
+// Header describes the recorded session.
+type Header struct {
+	Version   int       `json:"version"`
+	Started   time.Time `json:"started"`
+	Target    string    `json:"target"`
+	Args      []string  `json:"args,omitempty"`
+	Build     []string  `json:"build,omitempty"` // "go build" flags of the target
+	Period    int       `json:"period"`          // schedtrace period in milliseconds
+	GOOS      string    `json:"goos"`
+	GOARCH    string    `json:"goarch"`
+	GoVersion string    `json:"go_version"` // Go version goschedviz was built with
+	Hostname  string    `json:"hostname,omitempty"`
+}
+
+// entry is the on-disk form of a single output line.
+type entry struct {
+	Time   time.Time `json:"t"`
+	Stream string    `json:"s"`
+	Text   string    `json:"l"`
+}
+
+// Recording is a fully loaded session.
+type Recording struct {
+	Header Header
+	Lines  []domain.OutputLine
+}
+
+// Duration returns time between the first and the last recorded line.
+func (r *Recording) Duration() time.Duration {
+	if len(r.Lines) == 0 {
+		return 0
+	}
+	return r.Lines[len(r.Lines)-1].Time.Sub(r.Lines[0].Time)
+}
+
+// Writer appends output lines to a recording.
+// It is safe for concurrent use.
+type Writer struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+// NewWriter writes the header to w and returns a Writer for output lines.
+func NewWriter(w io.Writer, h Header) (*Writer, error) {
+	if h.Version == 0 {
+		h.Version = Version
+	}
+
+	bw := bufio.NewWriter(w)
+	rw := &Writer{w: bw, enc: json.NewEncoder(bw)}
+	if err := rw.enc.Encode(h); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	return rw, nil
+}
+
+// Create creates a recording file at path.
+func Create(path string, h Header) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	w, err := NewWriter(f, h)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.closer = f
+
+	return w, nil
+}
+
+// Write appends a single output line. Each line is flushed immediately,
+// so the recording stays usable if goschedviz is killed.
+func (w *Writer) Write(line domain.OutputLine) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.enc.Encode(entry{Time: line.Time, Stream: line.Stream, Text: line.Text}); err != nil {
+		return err
+	}
+	return w.w.Flush()
+}
+
+// Close flushes buffered data and closes the underlying file, if any.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.w.Flush()
+	if w.closer != nil {
+		if cerr := w.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Read loads a recording from r.
+// A truncated last line, e.g. left by a killed recorder, is ignored.
+func Read(r io.Reader) (*Recording, error) {
+	dec := json.NewDecoder(r)
+
+	var rec Recording
+	if err := dec.Decode(&rec.Header); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if rec.Header.Version != Version {
+		return nil, fmt.Errorf("unsupported recording version %d", rec.Header.Version)
+	}
+
+	for {
+		var e entry
+		err := dec.Decode(&e)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read line %d: %w", len(rec.Lines)+2, err)
+		}
+		rec.Lines = append(rec.Lines, domain.OutputLine{Time: e.Time, Stream: e.Stream, Text: e.Text})
+	}
+
+	return &rec, nil
+}
+
+// Open loads a recording from the file at path.
+func Open(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer f.Close()
+
+	return Read(f)
+}
@@ -0,0 +1,101 @@
+package recording
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+)
+
+func TestWriterRead_RoundTrip(t *testing.T) {
+	started := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	header := Header{
+		Started: started,
+		Target:  "./cmd/api",
+		Build:   []string{"-race"},
+		Args:    []string{"-listen", ":8080"},
+		Period:  1000,
+		GOOS:    "linux",
+		GOARCH:  "amd64",
+	}
+	lines := []domain.OutputLine{
+		{Time: started.Add(time.Second), Stream: "stderr", Text: "SCHED 1000ms: gomaxprocs=1 idleprocs=0 threads=2 spinningthreads=0 needspinning=0 idlethreads=0 runqueue=1 [0]"},
+		{Time: started.Add(1100 * time.Millisecond), Stream: "stderr", Text: "PROCMETR num_goroutines=7"},
+		{Time: started.Add(3 * time.Second), Stream: "stderr", Text: `app said "hello"`},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, header)
+	require.NoError(t, err)
+	for _, line := range lines {
+		require.NoError(t, w.Write(line))
+	}
+	require.NoError(t, w.Close())
+
+	rec, err := Read(&buf)
+	require.NoError(t, err)
+
+	header.Version = Version
+	assert.Equal(t, header, rec.Header)
+	require.Len(t, rec.Lines, len(lines))
+	for i := range lines {
+		assert.True(t, lines[i].Time.Equal(rec.Lines[i].Time), "line %d time mismatch", i)
+		assert.Equal(t, lines[i].Stream, rec.Lines[i].Stream)
+		assert.Equal(t, lines[i].Text, rec.Lines[i].Text)
+	}
+	assert.Equal(t, 2*time.Second, rec.Duration())
+}
+
+func TestRead_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty input", ""},
+		{"not json", "SCHED 1000ms: gomaxprocs=1"},
+		{"unsupported version", `{"version":99}`},
+		{"corrupted line", `{"version":1}` + "\n" + `{"t":"bad time"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.input))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRead_TruncatedTail(t *testing.T) {
+	input := `{"version":1,"target":"main.go"}` + "\n" +
+		`{"t":"2025-01-02T15:04:06Z","s":"stderr","l":"first"}` + "\n" +
+		`{"t":"2025-01-02T15:04:07Z","s":"std`
+
+	rec, err := Read(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, rec.Lines, 1)
+	assert.Equal(t, "first", rec.Lines[0].Text)
+}
+
+func TestCreateOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+
+	w, err := Create(path, Header{Target: "main.go", Period: 500})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(domain.OutputLine{Time: time.Now(), Stream: "stderr", Text: "line"}))
+	require.NoError(t, w.Close())
+
+	rec, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, "main.go", rec.Header.Target)
+	assert.Equal(t, 500, rec.Header.Period)
+	require.Len(t, rec.Lines, 1)
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing.jsonl"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,274 @@
+// Package report renders a recorded session as a single HTML page: session metadata,
+// summary tables and SVG charts of every series. The page has no external assets,
+// so it can be attached to an issue or sent by mail and opened offline.
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+	"github.com/JustSkiv/goschedviz/internal/recording"
+)
+
+// Data is the session a report is rendered from.
+type Data struct {
+	Header    recording.Header
+	Snapshots []domain.SchedulerSnapshot
+	Summary   domain.Summary
+	Generated time.Time
+}
+
+// fieldTitles describes summary fields in chart titles.
+var fieldTitles = map[string]string{
+	"gomaxprocs":      "GOMAXPROCS",
+	"idleprocs":       "Idle Ps",
+	"threads":         "Threads",
+	"spinningthreads": "Spinning threads",
+	"needspinning":    "Threads needing to spin",
+	"idlethreads":     "Idle threads",
+	"runqueue":        "Global run queue",
+	"lrqsum":          "Local run queues, total",
+	"goroutines":      "Goroutines",
+}
+
+// chart is a rendered line chart.
+type chart struct {
+	Title string
+	Name  string // Field or metric name
+	SVG   template.HTML
+}
+
+// page is the template model.
+type page struct {
+	Data
+	Duration time.Duration
+	Meta     [][2]string
+	GCCycles int
+	Charts   []chart
+	Metrics  []chart
+	Heatmap  template.HTML
+}
+
+// Write renders the report as HTML.
+func Write(w io.Writer, data Data) error {
+	return pageTemplate.Execute(w, newPage(data))
+}
+
+// offsets returns seconds since the first snapshot for every snapshot. Snapshots are placed
+// by receive time, or by time since program start if they have none.
+func offsets(snapshots []domain.SchedulerSnapshot) []float64 {
+	times := make([]float64, len(snapshots))
+	if len(snapshots) == 0 {
+		return times
+	}
+	first := snapshots[0]
+	for i, s := range snapshots {
+		if !s.Received.IsZero() && !first.Received.IsZero() {
+			times[i] = s.Received.Sub(first.Received).Seconds()
+		} else {
+			times[i] = float64(s.TimeMs-first.TimeMs) / 1000
+		}
+		if i > 0 {
+			times[i] = max(times[i], times[i-1])
+		}
+	}
+	return times
+}
+
+// gcMarkers places GC cycles reported with snapshots on the time axis. Cycles are timed
+// relative to the snapshot they came with, since both count from program start.
+func gcMarkers(snapshots []domain.SchedulerSnapshot, times []float64, duration float64) []gcMarker {
+	var gc []gcMarker
+	for i, s := range snapshots {
+		for _, c := range s.GC {
+			t := times[i] - float64(s.TimeMs-c.AtMs)/1000
+			number := fmt.Sprintf("#%d", c.Number)
+			if c.Merged > 0 {
+				number = fmt.Sprintf("#%d-%d", c.Number-c.Merged, c.Number)
+			}
+			gc = append(gc, gcMarker{
+				t:     min(max(t, 0), duration),
+				title: fmt.Sprintf("GC %s at %s: heap %d→%d MB, STW %s", number, formatSeconds(t), c.HeapStartMB, c.HeapEndMB, c.STW()),
+			})
+		}
+	}
+	return gc
+}
+
+// newPage builds charts and tables of the report.
+func newPage(data Data) page {
+	p := page{Data: data}
+	times := offsets(data.Snapshots)
+	duration := 0.0
+	if len(times) > 0 {
+		duration = times[len(times)-1]
+	}
+	p.Duration = time.Duration(duration * float64(time.Second)).Round(time.Millisecond)
+	gc := gcMarkers(data.Snapshots, times, duration)
+	for _, s := range data.Snapshots {
+		p.GCCycles += domain.CountGC(s.GC)
+	}
+
+	h := data.Header
+	p.Meta = [][2]string{
+		{"Target", h.Target},
+		{"Arguments", strings.Join(h.Args, " ")},
+		{"Build flags", strings.Join(h.Build, " ")},
+		{"Started", h.Started.Format(time.RFC3339)},
+		{"Duration", p.Duration.String()},
+		{"Snapshots", fmt.Sprint(len(data.Snapshots))},
+		{"Schedtrace period", fmt.Sprintf("%dms", h.Period)},
+		{"Platform", h.GOOS + "/" + h.GOARCH},
+		{"Go version", h.GoVersion},
+		{"Host", h.Hostname},
+		{"GC cycles", fmt.Sprint(p.GCCycles)},
+		{"Generated", data.Generated.Format(time.RFC3339)},
+	}
+	p.Meta = slices.DeleteFunc(p.Meta, func(m [2]string) bool {
+		return m[1] == "" || m[1] == "/"
+	})
+
+	goroutines := slices.ContainsFunc(data.Snapshots, func(s domain.SchedulerSnapshot) bool { return s.Goroutines > 0 })
+	for _, field := range domain.SummaryFields {
+		if field == "goroutines" && !goroutines {
+			continue
+		}
+		points := make([]point, len(data.Snapshots))
+		for i, s := range data.Snapshots {
+			points[i] = point{t: times[i], v: domain.FieldValue(s, field)}
+		}
+		p.Charts = append(p.Charts, chart{Title: fieldTitles[field], Name: field, SVG: lineChart(points, duration, gc)})
+	}
+
+	metrics := make(map[string][]point)
+	for i, s := range data.Snapshots {
+		for name, v := range s.Metrics {
+			metrics[name] = append(metrics[name], point{t: times[i], v: v})
+		}
+	}
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		p.Metrics = append(p.Metrics, chart{Title: name, Name: name, SVG: lineChart(metrics[name], duration, gc)})
+	}
+
+	lrq := make([][]int, len(data.Snapshots))
+	for i, s := range data.Snapshots {
+		lrq[i] = s.LRQ
+	}
+	p.Heatmap = heatmap(times, lrq, duration, gc)
+	return p
+}
+
+var pageTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"num": formatNumber,
+	"mean": func(v float64) string {
+		return fmt.Sprintf("%.2f", v)
+	},
+	"percent": func(v float64) string {
+		return fmt.Sprintf("%.1f%%", v*100)
+	},
+	"seconds": func(s float64) string {
+		return time.Duration(s * float64(time.Second)).Round(time.Millisecond).String()
+	},
+}).Parse(pageHTML))
+
+const pageHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>goschedviz report: {{.Header.Target}}</title>
+<style>
+body { font: 14px/1.4 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; margin: 0 auto; max-width: 960px; padding: 16px; }
+h1 { font-size: 22px; } h2 { font-size: 18px; margin-top: 32px; border-bottom: 1px solid #ddd; } h3 { font-size: 15px; margin: 20px 0 4px; }
+table { border-collapse: collapse; margin: 8px 0; }
+th, td { padding: 3px 10px; border-bottom: 1px solid #eee; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+table.meta td:first-child { color: #666; }
+code { font-size: 13px; }
+.chart { width: 100%; height: auto; display: block; }
+.frame { fill: none; stroke: #ccc; }
+.label { font-size: 11px; fill: #666; }
+.series { fill: none; stroke: #1f77b4; stroke-width: 1.5; stroke-linejoin: round; }
+.gc { stroke: #9467bd; stroke-width: 1; stroke-dasharray: 3 3; }
+.warn { color: #c00; font-weight: bold; }
+.note { color: #666; }
+</style>
+</head>
+<body>
+<h1>goschedviz report: <code>{{.Header.Target}}</code></h1>
+
+<table class="meta">
+{{- range .Meta}}
+<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Summary</h2>
+<p>{{.Summary.Snapshots}} snapshots over {{seconds .Summary.Duration}}{{if .Summary.Warmup}}, {{.Summary.Excluded}} snapshots of {{seconds .Summary.Warmup}} warm-up excluded{{end}}.</p>
+{{- with .Summary.Leak}}{{if .Suspected}}
+<p class="warn">Goroutine leak suspected: +{{.Growth}} goroutines in {{seconds .Window}} ({{printf "%.1f" .Slope}}/s, {{percent .Confidence}} confidence).</p>
+{{- end}}{{end}}
+<table>
+<tr><th>Field</th><th>Min</th><th>Max</th><th>Mean</th><th>p50</th><th>p95</th><th>p99</th></tr>
+{{- range .Summary.Fields}}
+<tr><td>{{.Field}}</td><td>{{num .Min}}</td><td>{{num .Max}}</td><td>{{mean .Mean}}</td><td>{{num .P50}}</td><td>{{num .P95}}</td><td>{{num .P99}}</td></tr>
+{{- end}}
+</table>
+{{- if .Summary.Thresholds}}
+
+<h3>Time above thresholds</h3>
+<table>
+<tr><th>Field</th><th>Threshold</th><th>Time</th><th>Share</th></tr>
+{{- range .Summary.Thresholds}}
+<tr><td>{{.Field}}</td><td>&gt; {{num .Threshold}}</td><td>{{seconds .Above}}</td><td>{{percent .Share}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- with .Summary.Imbalance}}
+
+<h3>Local run queue imbalance</h3>
+<p>Spread between the longest and the shortest LRQ: mean {{mean .Spread.Mean}}, p95 {{num .Spread.P95}}, max {{num .Spread.Max}}.
+Spread of 2 or more in {{percent .Imbalanced}} of snapshots.</p>
+<table>
+<tr><th>P</th><th>Min</th><th>Max</th><th>Mean</th><th>p50</th><th>p95</th><th>p99</th></tr>
+{{- range .Procs}}
+<tr><td>P{{.P}}</td><td>{{num .Min}}</td><td>{{num .Max}}</td><td>{{mean .Mean}}</td><td>{{num .P50}}</td><td>{{num .P95}}</td><td>{{num .P99}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+<h2>Scheduler</h2>
+{{- if .GCCycles}}
+<p class="note">Dashed lines mark garbage collection cycles, hover them for details.</p>
+{{- end}}
+{{- range .Charts}}
+<h3>{{.Title}} <code class="note">{{.Name}}</code></h3>
+{{.SVG}}
+{{- end}}
+{{- if .Heatmap}}
+
+<h2>Local run queues</h2>
+<p class="note">Queue length of every P over time, darker is longer. Hover a cell for its value.</p>
+{{.Heatmap}}
+{{- end}}
+{{- if .Metrics}}
+
+<h2>Runtime metrics</h2>
+{{- range .Metrics}}
+<h3><code>{{.Name}}</code></h3>
+{{.SVG}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+`
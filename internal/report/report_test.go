@@ -0,0 +1,119 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/domain"
+	"github.com/JustSkiv/goschedviz/internal/recording"
+)
+
+// testSnapshots returns n snapshots a second apart with a GC cycle in the middle.
+func testSnapshots(n int) []domain.SchedulerSnapshot {
+	start := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	snapshots := make([]domain.SchedulerSnapshot, n)
+	for i := range snapshots {
+		snapshots[i] = domain.SchedulerSnapshot{
+			TimeMs:     (i + 1) * 1000,
+			Received:   start.Add(time.Duration(i) * time.Second),
+			GoMaxProcs: 2,
+			Threads:    5,
+			RunQueue:   i,
+			LRQ:        []int{i, 0},
+			Metrics:    map[string]float64{"/gc/heap/allocs:bytes": float64(i * 1024)},
+		}
+	}
+	snapshots[n/2].GC = []domain.GCCycle{{Number: 1, AtMs: (n/2+1)*1000 - 500, HeapStartMB: 4, HeapEndMB: 6}}
+	return snapshots
+}
+
+func TestWrite(t *testing.T) {
+	snapshots := testSnapshots(10)
+	summarizer := domain.NewSummarizer(0, domain.DefaultSummaryThresholds)
+	for _, s := range snapshots {
+		summarizer.Add(s)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, Data{
+		Header: recording.Header{
+			Target:    "./cmd/api <v2>",
+			Args:      []string{"-listen", ":8080"},
+			Period:    1000,
+			GOOS:      "linux",
+			GOARCH:    "amd64",
+			GoVersion: "go1.23.4",
+		},
+		Snapshots: snapshots,
+		Summary:   summarizer.Summary(),
+		Generated: time.Date(2025, 1, 2, 16, 0, 0, 0, time.UTC),
+	}))
+	html := buf.String()
+
+	assert.Contains(t, html, "<title>goschedviz report: ./cmd/api &lt;v2&gt;</title>", "Metadata is escaped")
+	assert.Contains(t, html, "<tr><td>Arguments</td><td>-listen :8080</td></tr>")
+	assert.Contains(t, html, "<tr><td>Duration</td><td>9s</td></tr>")
+	assert.NotContains(t, html, "<td>Host</td>", "Unknown metadata is left out")
+	assert.Contains(t, html, "<tr><td>runqueue</td><td>0</td><td>9</td><td>4.50</td><td>4</td><td>9</td><td>9</td></tr>")
+	assert.Contains(t, html, "<tr><td>runqueue</td><td>&gt; 0</td><td>8s</td><td>88.9%</td></tr>")
+	assert.Contains(t, html, "<tr><td>P0</td>")
+	assert.NotContains(t, html, "Goroutines", "Goroutines are left out when not reported")
+
+	// A chart per scheduler field and metric, and the heatmap
+	assert.Equal(t, len(domain.SummaryFields)-1+1+1, strings.Count(html, "<svg"))
+	assert.Contains(t, html, "<code>/gc/heap/allocs:bytes</code>")
+	assert.Contains(t, html, "<title>P0 at 9s: 9</title>")
+	assert.Contains(t, html, "<title>GC #1 at 4.5s: heap 4→6 MB, STW 0s</title>")
+	assert.Contains(t, html, "<tr><td>GC cycles</td><td>1</td></tr>")
+
+	// Nothing is loaded from elsewhere
+	assert.NotRegexp(t, regexp.MustCompile(`(?i)(src|href)=|https?://|@import|url\(`), html)
+}
+
+func TestDownsample(t *testing.T) {
+	points := make([]point, 10)
+	for i := range points {
+		points[i] = point{t: float64(i), v: float64(i % 3)}
+	}
+	assert.Equal(t, points, downsample(points, 10))
+	assert.Equal(t, []point{{t: 2, v: 2}, {t: 5, v: 2}}, downsample(points, 2), "Peaks of buckets are kept")
+}
+
+func TestOffsets(t *testing.T) {
+	start := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	assert.Equal(t, []float64{0, 1.5, 1.5}, offsets([]domain.SchedulerSnapshot{
+		{Received: start},
+		{Received: start.Add(1500 * time.Millisecond)},
+		{Received: start.Add(time.Second)},
+	}), "Time never goes back")
+	assert.Equal(t, []float64{0, 0.25}, offsets([]domain.SchedulerSnapshot{{TimeMs: 1000}, {TimeMs: 1250}}),
+		"Snapshots without receive time are placed by TimeMs")
+}
+
+func TestHeatmap(t *testing.T) {
+	assert.Empty(t, heatmap(nil, nil, 0, nil))
+	assert.Empty(t, heatmap([]float64{0}, [][]int{nil}, 0, nil), "No Ps, no heatmap")
+
+	// Long sessions are bucketed, keeping the longest queue
+	n := maxColumns * 2
+	times := make([]float64, n)
+	lrq := make([][]int, n)
+	for i := range n {
+		times[i] = float64(i)
+		lrq[i] = []int{i % 2, 0, 0}
+	}
+	svg := string(heatmap(times, lrq, float64(n-1), nil))
+	assert.Equal(t, maxColumns*3, strings.Count(svg, "<rect"))
+	assert.Equal(t, maxColumns, strings.Count(svg, fmt.Sprintf(`fill="%s"`, heatColor(1, 1))))
+	assert.Contains(t, svg, ">P2</text>")
+
+	assert.Equal(t, "#f2f2f2", heatColor(0, 5))
+	assert.Equal(t, "#b10026", heatColor(5, 5))
+}
@@ -0,0 +1,204 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+	"time"
+)
+
+// Chart geometry in SVG user units, charts scale to the page width.
+const (
+	chartWidth  = 900
+	chartHeight = 170
+	marginLeft  = 56
+	marginRight = 12
+	marginTop   = 10
+	marginBot   = 24
+	plotWidth   = chartWidth - marginLeft - marginRight
+	plotHeight  = chartHeight - marginTop - marginBot
+
+	// maxPoints limits points of a line chart, longer series keep the peak of every bucket.
+	maxPoints = 1000
+	// maxColumns limits columns of the heatmap, longer sessions keep the longest queue of every bucket.
+	maxColumns = 300
+	// heatRow is the height of a heatmap row per P.
+	heatRow = 14
+)
+
+// point is a value of a series at a time since the start of the session.
+type point struct {
+	t float64 // Seconds
+	v float64
+}
+
+// gcMarker is a garbage collection cycle shown on every chart.
+type gcMarker struct {
+	t     float64 // Seconds since the start of the session
+	title string
+}
+
+// downsample splits points into at most n buckets and keeps the largest value of each.
+func downsample(points []point, n int) []point {
+	if len(points) <= n {
+		return points
+	}
+	out := make([]point, 0, n)
+	for i := range n {
+		bucket := points[i*len(points)/n : (i+1)*len(points)/n]
+		peak := bucket[0]
+		for _, p := range bucket[1:] {
+			if p.v > peak.v {
+				peak = p
+			}
+		}
+		out = append(out, peak)
+	}
+	return out
+}
+
+// x maps time since the start to the horizontal chart coordinate.
+func x(t, duration float64) float64 {
+	if duration <= 0 {
+		return marginLeft
+	}
+	return marginLeft + t/duration*plotWidth
+}
+
+// formatSeconds formats a time axis label.
+func formatSeconds(s float64) string {
+	return (time.Duration(s * float64(time.Second))).Round(100 * time.Millisecond).String()
+}
+
+// formatNumber formats an axis label without needless decimals.
+func formatNumber(v float64) string {
+	switch {
+	case v == math.Trunc(v) && math.Abs(v) < 1e15:
+		return fmt.Sprintf("%.0f", v)
+	case math.Abs(v) >= 100:
+		return fmt.Sprintf("%.0f", v)
+	case math.Abs(v) >= 1:
+		return fmt.Sprintf("%.2f", v)
+	}
+	return fmt.Sprintf("%.3g", v)
+}
+
+// axes draws the frame, value labels of the y axis and time labels of the x axis.
+func axes(b *strings.Builder, lo, hi, duration float64) {
+	fmt.Fprintf(b, `<rect class="frame" x="%d" y="%d" width="%d" height="%d"/>`, marginLeft, marginTop, plotWidth, plotHeight)
+	fmt.Fprintf(b, `<text class="label" x="%d" y="%d" text-anchor="end">%s</text>`, marginLeft-6, marginTop+10, formatNumber(hi))
+	fmt.Fprintf(b, `<text class="label" x="%d" y="%d" text-anchor="end">%s</text>`, marginLeft-6, marginTop+plotHeight, formatNumber(lo))
+	for i, anchor := range []string{"start", "middle", "end"} {
+		t := duration * float64(i) / 2
+		fmt.Fprintf(b, `<text class="label" x="%.1f" y="%d" text-anchor="%s">%s</text>`,
+			x(t, duration), chartHeight-6, anchor, formatSeconds(t))
+	}
+}
+
+// markers draws GC cycles as vertical lines with tooltips.
+func markers(b *strings.Builder, gc []gcMarker, top, height int, duration float64) {
+	for _, m := range gc {
+		px := x(m.t, duration)
+		fmt.Fprintf(b, `<line class="gc" x1="%.1f" y1="%d" x2="%.1f" y2="%d"><title>%s</title></line>`,
+			px, top, px, top+height, template.HTMLEscapeString(m.title))
+	}
+}
+
+// lineChart draws a series over the session as an SVG line chart with GC markers.
+func lineChart(points []point, duration float64, gc []gcMarker) template.HTML {
+	points = downsample(points, maxPoints)
+
+	lo, hi := 0.0, 0.0
+	for _, p := range points {
+		lo, hi = min(lo, p.v), max(hi, p.v)
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+	y := func(v float64) float64 {
+		return marginTop + plotHeight - (v-lo)/(hi-lo)*plotHeight
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img">`, chartWidth, chartHeight)
+	axes(&b, lo, hi, duration)
+	markers(&b, gc, marginTop, plotHeight, duration)
+
+	b.WriteString(`<polyline class="series" points="`)
+	for i, p := range points {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "%.1f,%.1f", x(p.t, duration), y(p.v))
+	}
+	b.WriteString(`"/>`)
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// heatColor returns the fill of a heatmap cell, from light gray for empty queues to red for the longest.
+func heatColor(v, hi float64) string {
+	if v <= 0 || hi <= 0 {
+		return "#f2f2f2"
+	}
+	f := math.Sqrt(v / hi) // Short queues stay visible next to long ones
+	mix := func(from, to float64) int { return int(from + (to-from)*f) }
+	return fmt.Sprintf("#%02x%02x%02x", mix(0xfe, 0xb1), mix(0xe0, 0x00), mix(0xd2, 0x26))
+}
+
+// heatmap draws local run queues of every P over the session, a row per P.
+// times holds seconds since the start of every snapshot, lrq its queues.
+func heatmap(times []float64, lrq [][]int, duration float64, gc []gcMarker) template.HTML {
+	procs := 0
+	for _, q := range lrq {
+		procs = max(procs, len(q))
+	}
+	columns := min(len(lrq), maxColumns)
+	if procs == 0 || columns == 0 {
+		return ""
+	}
+
+	// Every column keeps the longest queue of its snapshots
+	cells := make([][]int, columns)
+	starts := make([]float64, columns+1)
+	hi := 0
+	for c := range columns {
+		from, to := c*len(lrq)/columns, (c+1)*len(lrq)/columns
+		cells[c] = make([]int, procs)
+		starts[c] = times[from]
+		for _, q := range lrq[from:to] {
+			for p, n := range q {
+				cells[c][p] = max(cells[c][p], n)
+				hi = max(hi, n)
+			}
+		}
+	}
+	starts[columns] = duration
+
+	height := procs * heatRow
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="chart" viewBox="0 0 %d %d" role="img">`, chartWidth, height+marginTop+marginBot)
+	for p := range procs {
+		fmt.Fprintf(&b, `<text class="label" x="%d" y="%d" text-anchor="end">P%d</text>`,
+			marginLeft-6, marginTop+p*heatRow+heatRow-3, p)
+	}
+	for c, column := range cells {
+		x0, x1 := x(starts[c], duration), x(starts[c+1], duration)
+		if c == columns-1 || x1 <= x0 {
+			x1 = max(x1, x0+float64(plotWidth)/float64(columns))
+		}
+		for p, n := range column {
+			fmt.Fprintf(&b, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s"><title>P%d at %s: %d</title></rect>`,
+				x0, marginTop+p*heatRow, x1-x0, heatRow-1, heatColor(float64(n), float64(hi)), p, formatSeconds(starts[c]), n)
+		}
+	}
+	markers(&b, gc, marginTop, height, duration)
+	for i, anchor := range []string{"start", "middle", "end"} {
+		t := duration * float64(i) / 2
+		fmt.Fprintf(&b, `<text class="label" x="%.1f" y="%d" text-anchor="%s">%s</text>`,
+			x(t, duration), height+marginTop+marginBot-6, anchor, formatSeconds(t))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
@@ -1,5 +1,7 @@
 package ui
 
+import "time"
+
 // UIData represents the data structure passed to UI for visualization.
 type UIData struct {
 	// Current values
@@ -13,6 +15,53 @@ type UIData struct {
 
 	// Gauge values
 	Gauges GaugeValues
+
+	// Status is an optional one-line state of the data source, e.g. replay position
+	Status string
+
+	// Detail contains per-P state when scheddetail output is available, nil otherwise
+	Detail *DetailValues
+
+	// GC summarizes GC cycles within the history window, nil if there were none
+	GC *GCValues
+
+	// Logs contains recent output of the monitored program, oldest first
+	Logs []LogLine
+
+	// Exit describes how the monitored program ended, nil while it's running
+	Exit *ExitValues
+
+	// Latency holds scheduling latency over history, nil if the program doesn't report it
+	Latency *LatencyValues
+
+	// Goroutines holds goroutine counts by state over history, nil if the program doesn't report them
+	Goroutines *GoroutineValues
+
+	// Alerts lists alert rules that currently fire
+	Alerts []AlertValues
+
+	// Leak describes suspected goroutine leak, nil if the goroutines count doesn't grow steadily
+	Leak *LeakValues
+
+	// Diagnostics tells how well the input of the source is parsed, nil if it's not parsed
+	Diagnostics *DiagnosticsValues
+
+	// Tabs names monitored sources when there are several of them, Tab is the shown one
+	Tabs []string
+	Tab  int
+
+	// Split holds histories of all monitored sources on a shared time axis when
+	// there are several of them. Every history covers the last SplitWindow.
+	Split       []SourceHistory
+	SplitWindow time.Duration
+}
+
+// SourceHistory describes a single monitored source in the split view.
+type SourceHistory struct {
+	Name    string
+	Current CurrentValues
+	History []HistoricalValues // Evenly spaced points, the last one is now
+	Exited  bool               // Program has exited and isn't restarted yet
 }
 
 // CurrentValues contains the latest scheduler metrics.
@@ -29,16 +78,26 @@ type CurrentValues struct {
 	NumP            int   // Number of P (processors)
 	LRQ             []int // Local run queues by P
 	Goroutines      int
+	Received        time.Time // Wall-clock time the snapshot was received, zero if unknown
+	Seq             uint64    // Sequence number of the snapshot within its source
+	Source          string    // ID of the source the snapshot came from
 }
 
 // HistoricalValues contains metrics used for plotting history.
 type HistoricalValues struct {
 	TimeMs     int
+	Time       time.Time // Wall-clock time the point was received, zero if unknown
+	Seq        uint64
 	GRQ        int
 	LRQSum     int
 	IdleProcs  int
 	Threads    int
 	Goroutines int
+	GCCycles   int  // GC cycles completed since the previous point
+	Restarted  bool // First point after the program was restarted
+	Rebuilt    bool // First point after the program was rebuilt in watch mode
+	// Metrics holds runtime/metrics values reported by the program, nil if it doesn't report them
+	Metrics map[string]float64
 }
 
 // GaugeValues contains data for all gauges
@@ -60,3 +119,154 @@ type GaugeValues struct {
 		Max     int
 	}
 }
+
+// DetailValues contains per-P state and goroutine wait reasons from scheddetail output.
+type DetailValues struct {
+	Procs   []ProcValues
+	Waiting map[string]int // Waiting goroutines count by wait reason
+}
+
+// ProcValues describes a single P and the M holding it.
+type ProcValues struct {
+	ID       int
+	Status   string
+	M        int // ID of the M holding the P, -1 if none
+	CurG     int // ID of the goroutine running on that M, -1 if none
+	RunqSize int
+}
+
+// GCValues summarizes GC cycles from gctrace output within the history window.
+type GCValues struct {
+	Last     GCCycleValues // Most recent cycle
+	Cycles   int           // Number of cycles
+	Forced   int           // Number of forced cycles
+	MaxSTWMs float64       // Longest stop-the-world pause
+}
+
+// GCCycleValues describes a single GC cycle.
+type GCCycleValues struct {
+	Number      int
+	AtMs        int
+	CPUPercent  int
+	SweepTermMs float64 // Stop-the-world sweep termination
+	MarkMs      float64 // Concurrent mark
+	MarkTermMs  float64 // Stop-the-world mark termination
+	AssistCPUMs float64 // CPU time of mutator assists
+	HeapStartMB int
+	HeapEndMB   int
+	HeapLiveMB  int
+	HeapGoalMB  int
+	Forced      bool
+	Merged      int // Earlier cycles reported together with this one
+}
+
+// LogLine is a single output line of the monitored program.
+type LogLine struct {
+	Seq    uint64 // Sequence number, increasing with every received line
+	Time   time.Time
+	Stream string
+	Text   string
+}
+
+// ExitValues describes how the monitored program ended.
+type ExitValues struct {
+	Code       int    // Exit code, -1 if killed by a signal
+	Signal     string // Signal that killed the program, empty otherwise
+	Runtime    time.Duration
+	Restarts   int  // Restarts before the last run
+	Restarting bool // Program is going to be started again
+	Watching   bool // Program is started again when its sources change
+}
+
+// DiagnosticsValues tells how well the input of the source is parsed.
+type DiagnosticsValues struct {
+	Lines      int // All parsed lines
+	Snapshots  int
+	Unmatched  int // Lines that are not trace output
+	Malformed  int // Trace lines that don't match the expected format
+	Rejected   int // Snapshots dropped by validation
+	Suspicious int // Snapshots that failed validation but were kept
+	Recent     []ParseIssue
+	FieldSet   string   // Go releases whose SCHED fields were detected, e.g. "go1.21+"
+	Unknown    []string // SCHED fields goschedviz doesn't know
+}
+
+// ParseIssue describes a trace line that was dropped or kept with a warning.
+type ParseIssue struct {
+	Time   time.Time
+	Reason string // "malformed", "rejected" or "suspicious"
+	Detail string
+	Line   string
+}
+
+// LatencyBounds are upper bounds of scheduling latency bands, the last band has no bound.
+var LatencyBounds = []time.Duration{
+	time.Microsecond,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// LatencyValues holds how long runnable goroutines waited before they ran.
+type LatencyValues struct {
+	Points []LatencyPoint // Oldest first
+}
+
+// LatencyPoint describes goroutines scheduled since the previous point.
+type LatencyPoint struct {
+	TimeMs        int
+	P50, P90, P99 time.Duration
+	Bands         []uint64 // Goroutines per band of LatencyBounds, len(LatencyBounds)+1
+}
+
+// GoroutineStates lists goroutine states in stacking order, as reported by metrics.Reporter.
+// States goschedviz doesn't know are counted as "other".
+var GoroutineStates = []string{
+	"running",
+	"runnable",
+	"io_wait",
+	"chan_receive",
+	"chan_send",
+	"select",
+	"syscall",
+	"sleep",
+	"semacquire",
+	"other",
+}
+
+// GoroutineValues holds goroutine counts by state and top creation sites.
+type GoroutineValues struct {
+	Samples []GoroutineSample // Oldest first
+	Sites   []GoroutineSite   // Top creation sites of the latest sample, most goroutines first
+}
+
+// GoroutineSample holds goroutine counts by state at a moment.
+type GoroutineSample struct {
+	TimeMs int
+	Counts []int // Goroutines by state, aligned with GoroutineStates
+}
+
+// GoroutineSite is a function that created goroutines.
+type GoroutineSite struct {
+	Function string
+	Count    int
+}
+
+// LeakValues describes steady growth of the goroutines count that looks like a leak.
+type LeakValues struct {
+	Slope      float64 // Goroutines added per second
+	Confidence float64 // From 0 to 1
+	Growth     int     // Goroutines added since the baseline
+	Window     time.Duration
+}
+
+// AlertValues describes a firing alert rule.
+type AlertValues struct {
+	Name      string
+	Condition string
+	Duration  time.Duration // How long the condition has held
+	Widgets   []string      // Widgets to highlight: grq, goroutines, threads, idleprocs, lrq, plots or info
+}
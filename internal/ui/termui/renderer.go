@@ -2,6 +2,9 @@
 package termui
 
 import (
+	"math"
+	"sync"
+
 	"github.com/gizak/termui/v3"
 
 	"github.com/JustSkiv/goschedviz/internal/ui"
@@ -63,9 +66,37 @@ type TermUI struct {
 	logPlot         *widgets.LogHistoryPlot
 	legend          *widgets.PlotLegend
 	info            *widgets.InfoBox
+	detail          *widgets.SchedDetailBox
+	gc              *widgets.GCPanel
+	logs            *widgets.LogPane
+	diagnostics     *widgets.DiagnosticsPane
+	runtime         *widgets.RuntimeMetricsPane
+	latency         *widgets.LatencyHeatmap
+	latencyPlot     *widgets.LatencyPlot
+	goroutineStates *widgets.GoroutineStatesChart
+	goroutineSites  *widgets.GoroutineSitesBox
+	exit            *widgets.ExitBanner
+	tabs            *widgets.SourceTabs
+	sourcePlots     []*widgets.SourcePlot
 	grid            *termui.Grid
 	done            chan struct{}
 	term            terminalAPI
+
+	mu             sync.Mutex // guards grid layout and rendering
+	showDetail     bool       // detail box replaces LRQ bar chart
+	showLogs       bool       // log pane replaces gauges
+	showDiag       bool       // diagnostics pane replaces gauges
+	showMetric     bool       // runtime metrics pane replaces gauges
+	showLatency    bool       // scheduling latency heatmap replaces gauges
+	showGoroutines bool       // goroutine states chart replaces gauges
+	showExit       bool       // exit banner is shown above all widgets
+	showTabs       bool       // tab bar of monitored sources is shown on top
+	showSplit      bool       // plots of all sources replace widgets of the selected one
+
+	borders map[*termui.Block]termui.Color // original border colors of widgets alerts highlight
+
+	bindingsMu sync.Mutex
+	bindings   map[string]func() // custom key handlers
 }
 
 // New creates a new terminal UI implementation.
@@ -101,6 +132,24 @@ func (t *TermUI) Start() error {
 	t.logPlot = widgets.NewLogHistoryPlot()
 	t.legend = widgets.NewPlotLegend()
 	t.info = widgets.NewInfoBox()
+	t.detail = widgets.NewSchedDetailBox()
+	t.gc = widgets.NewGCPanel()
+	t.logs = widgets.NewLogPane()
+	t.diagnostics = widgets.NewDiagnosticsPane()
+	t.runtime = widgets.NewRuntimeMetricsPane()
+	t.latency = widgets.NewLatencyHeatmap()
+	t.latencyPlot = widgets.NewLatencyPlot()
+	t.goroutineStates = widgets.NewGoroutineStatesChart()
+	t.goroutineSites = widgets.NewGoroutineSitesBox()
+	t.exit = widgets.NewExitBanner()
+	t.tabs = widgets.NewSourceTabs()
+
+	t.borders = make(map[*termui.Block]termui.Color)
+	for _, blocks := range t.highlightable() {
+		for _, b := range blocks {
+			t.borders[b] = b.BorderStyle.Fg
+		}
+	}
 
 	// Setup grid
 	t.setupGrid()
@@ -116,6 +165,18 @@ func (t *TermUI) Stop() {
 	t.term.Close()
 }
 
+// Bind registers a handler for a key event ID, e.g. "<Space>" or "+".
+// Handlers run on the UI event goroutine. Quit keys can't be rebound.
+func (t *TermUI) Bind(key string, handler func()) {
+	t.bindingsMu.Lock()
+	defer t.bindingsMu.Unlock()
+
+	if t.bindings == nil {
+		t.bindings = make(map[string]func())
+	}
+	t.bindings[key] = handler
+}
+
 // Done implements ui.Presenter interface.
 func (t *TermUI) Done() <-chan struct{} {
 	return t.done
@@ -123,6 +184,9 @@ func (t *TermUI) Done() <-chan struct{} {
 
 // Update implements ui.Presenter interface.
 func (t *TermUI) Update(data ui.UIData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	t.table.Update(data.Current)
 	t.barChart.Update(data.Current.LRQ)
 	t.grqGauge.Update(data.Gauges.GRQ)
@@ -131,9 +195,137 @@ func (t *TermUI) Update(data ui.UIData) {
 	t.idleProcsGauge.Update(data.Gauges.IdleProcs)
 	t.linearPlot.Update(data.History.Raw)
 	t.logPlot.Update(data.History.Raw)
-	t.info.Update(data.Current, data.Gauges)
+	t.info.UpdateWithStatus(data.Current, data.Gauges, data.Status)
+	t.info.Warn(data.Leak)
+	t.highlight(data.Alerts)
+	t.detail.Update(data.Detail)
+	t.gc.Update(data.GC)
+	t.logs.Update(data.Logs)
+	t.diagnostics.Update(data.Diagnostics)
+	t.runtime.Update(data.History.Raw)
+	t.latency.Update(data.Latency)
+	t.latencyPlot.Update(data.Latency)
+	t.goroutineStates.Update(data.Goroutines)
+	t.goroutineSites.Update(data.Goroutines)
+	t.exit.Update(data.Exit)
+	t.tabs.Update(data.Tabs, data.Tab)
+
+	// A plot per source, the grid is rebuilt when sources come and go
+	resized := len(t.sourcePlots) != len(data.Split)
+	for len(t.sourcePlots) < len(data.Split) {
+		t.sourcePlots = append(t.sourcePlots, widgets.NewSourcePlot())
+	}
+	t.sourcePlots = t.sourcePlots[:len(data.Split)]
+	for i, source := range data.Split {
+		t.sourcePlots[i].Update(source, data.SplitWindow, i == data.Tab)
+	}
+
+	// Banner appears when the program exits and disappears when it's restarted
+	showExit, showTabs := data.Exit != nil, len(data.Tabs) > 1
+	if showExit != t.showExit || showTabs != t.showTabs || (resized && t.showSplit) {
+		t.showExit, t.showTabs = showExit, showTabs
+		t.relayout()
+		return
+	}
+
+	t.term.Render(t.grid)
+}
+
+// relayout rebuilds the grid after a panel was switched, keeping its size.
+// Caller must hold t.mu.
+func (t *TermUI) relayout() {
+	rect := t.grid.GetRect()
+	t.setupGrid()
+	t.grid.SetRect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y)
+	t.term.Clear()
+	t.term.Render(t.grid)
+}
+
+// highlightable maps widget names used by alert rules to their blocks.
+func (t *TermUI) highlightable() map[string][]*termui.Block {
+	return map[string][]*termui.Block{
+		"grq":        {&t.grqGauge.Block},
+		"goroutines": {&t.goroutinesGauge.Block},
+		"threads":    {&t.threadsGauge.Block},
+		"idleprocs":  {&t.idleProcsGauge.Block},
+		"lrq":        {&t.barChart.Block},
+		"plots":      {&t.linearPlot.Block, &t.logPlot.Block},
+		"info":       {&t.info.Block},
+	}
+}
+
+// highlight paints borders of widgets named by firing alerts red and restores the others.
+// Caller must hold t.mu.
+func (t *TermUI) highlight(alerts []ui.AlertValues) {
+	names := make(map[string]bool)
+	for _, a := range alerts {
+		for _, w := range a.Widgets {
+			names[w] = true
+		}
+	}
+	for name, blocks := range t.highlightable() {
+		for _, b := range blocks {
+			b.BorderStyle.Fg = t.borders[b]
+			if names[name] {
+				b.BorderStyle.Fg = termui.ColorRed
+			}
+		}
+	}
+}
+
+// togglePane shows or hides one of the panes that replace gauges, hiding the others.
+// Caller must hold t.mu.
+func (t *TermUI) togglePane(pane *bool) {
+	show := !*pane
+	t.showLogs, t.showDiag, t.showMetric, t.showLatency, t.showGoroutines = false, false, false, false, false
+	*pane = show
+	t.relayout()
+}
+
+// handleLogKey processes log pane keys: scrolling, search and toggling.
+// It reports whether the key was consumed.
+func (t *TermUI) handleLogKey(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.showLogs && t.logs.Searching() {
+		t.logs.HandleSearchKey(id)
+		t.term.Render(t.grid)
+		return true
+	}
+
+	if id == "l" {
+		t.togglePane(&t.showLogs)
+		return true
+	}
+	if !t.showLogs {
+		return false
+	}
 
+	switch id {
+	case "<Up>":
+		t.logs.ScrollUp(1)
+	case "<Down>":
+		t.logs.ScrollDown(1)
+	case "<PageUp>":
+		t.logs.ScrollUp(t.logs.PageSize())
+	case "<PageDown>":
+		t.logs.ScrollDown(t.logs.PageSize())
+	case "<End>":
+		t.logs.ScrollToEnd()
+	case "/":
+		t.logs.StartSearch()
+	case "n":
+		t.logs.NextMatch()
+	case "N":
+		t.logs.PrevMatch()
+	case "<Escape>":
+		t.logs.ClearSearch()
+	default:
+		return false
+	}
 	t.term.Render(t.grid)
+	return true
 }
 
 // setupGrid initializes the terminal UI layout.
@@ -142,28 +334,81 @@ func (t *TermUI) setupGrid() {
 	width, height := t.term.TerminalDimensions()
 	t.grid.SetRect(0, 0, width, height)
 
-	t.grid.Set(
-		termui.NewRow(0.3,
+	var topRight termui.Drawable = t.barChart
+	if t.showDetail {
+		topRight = t.detail
+	}
+
+	middle := []interface{}{
+		termui.NewCol(0.35,
+			termui.NewRow(0.5, t.threadsGauge),
+			termui.NewRow(0.5, t.idleProcsGauge),
+		),
+		termui.NewCol(0.35,
+			termui.NewRow(0.5, t.goroutinesGauge),
+			termui.NewRow(0.5, t.grqGauge),
+		),
+		termui.NewCol(0.3, t.gc),
+	}
+	switch {
+	case t.showLogs:
+		middle = []interface{}{termui.NewCol(1, t.logs)}
+	case t.showDiag:
+		middle = []interface{}{termui.NewCol(1, t.diagnostics)}
+	case t.showMetric:
+		middle = []interface{}{termui.NewCol(1, t.runtime)}
+	case t.showLatency:
+		middle = []interface{}{termui.NewCol(0.6, t.latency), termui.NewCol(0.4, t.latencyPlot)}
+	case t.showGoroutines:
+		middle = []interface{}{termui.NewCol(0.6, t.goroutineStates), termui.NewCol(0.4, t.goroutineSites)}
+	}
+
+	// Tab bar and exit banner take a fixed number of rows, the rest is shared as usual
+	var rows []interface{}
+	scale := 1.0
+	header := func(d termui.Drawable, rowsHeight int) {
+		if height <= 0 {
+			return
+		}
+		ratio := math.Min(float64(rowsHeight)/float64(height), scale)
+		rows = append(rows, termui.NewRow(ratio, d))
+		scale -= ratio
+	}
+	if t.showTabs {
+		header(t.tabs, widgets.SourceTabsHeight)
+	}
+	if t.showExit {
+		header(t.exit, widgets.ExitBannerHeight)
+	}
+
+	if t.showSplit && len(t.sourcePlots) > 1 {
+		plots := make([]interface{}, len(t.sourcePlots))
+		for i, p := range t.sourcePlots {
+			plots[i] = termui.NewRow(1/float64(len(t.sourcePlots)), p)
+		}
+		rows = append(rows, termui.NewRow(scale,
+			termui.NewCol(0.1, t.legend),
+			termui.NewCol(0.9, plots...),
+		))
+		t.grid.Set(rows...)
+		return
+	}
+
+	rows = append(rows,
+		termui.NewRow(0.3*scale,
 			termui.NewCol(0.30, t.table),
 			termui.NewCol(0.15, t.info),
-			termui.NewCol(0.55, t.barChart),
+			termui.NewCol(0.55, topRight),
 		),
-		termui.NewRow(0.3,
-			termui.NewCol(0.5,
-				termui.NewRow(0.5, t.threadsGauge),
-				termui.NewRow(0.5, t.idleProcsGauge),
-			),
-			termui.NewCol(0.5,
-				termui.NewRow(0.5, t.goroutinesGauge),
-				termui.NewRow(0.5, t.grqGauge),
-			),
-		),
-		termui.NewRow(0.4,
+		termui.NewRow(0.3*scale, middle...),
+		termui.NewRow(0.4*scale,
 			termui.NewCol(0.1, t.legend),
 			termui.NewCol(0.45, t.linearPlot),
 			termui.NewCol(0.45, t.logPlot),
 		),
 	)
+
+	t.grid.Set(rows...)
 }
 
 // handleEvents processes terminal UI events.
@@ -172,15 +417,55 @@ func (t *TermUI) handleEvents() {
 	for {
 		select {
 		case e := <-uiEvents:
+			// Log pane keys go first, so a search query can contain any character
+			if e.Type == termui.KeyboardEvent && e.ID != "<C-c>" && t.handleLogKey(e.ID) {
+				continue
+			}
+
 			switch e.ID {
 			case "q", "<C-c>":
 				close(t.done)
 				return
 			case "<Resize>":
 				payload := e.Payload.(termui.Resize)
+				t.mu.Lock()
 				t.grid.SetRect(0, 0, payload.Width, payload.Height)
 				t.term.Clear()
 				t.term.Render(t.grid)
+				t.mu.Unlock()
+			case "p":
+				t.mu.Lock()
+				t.showDetail = !t.showDetail
+				t.relayout()
+				t.mu.Unlock()
+			case "d":
+				t.mu.Lock()
+				t.togglePane(&t.showDiag)
+				t.mu.Unlock()
+			case "m":
+				t.mu.Lock()
+				t.togglePane(&t.showMetric)
+				t.mu.Unlock()
+			case "h":
+				t.mu.Lock()
+				t.togglePane(&t.showLatency)
+				t.mu.Unlock()
+			case "g":
+				t.mu.Lock()
+				t.togglePane(&t.showGoroutines)
+				t.mu.Unlock()
+			case "s":
+				t.mu.Lock()
+				t.showSplit = !t.showSplit
+				t.relayout()
+				t.mu.Unlock()
+			default:
+				t.bindingsMu.Lock()
+				handler := t.bindings[e.ID]
+				t.bindingsMu.Unlock()
+				if handler != nil {
+					handler()
+				}
 			}
 		case <-t.done:
 			return
@@ -8,7 +8,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/JustSkiv/goschedviz/internal/alert"
 	"github.com/JustSkiv/goschedviz/internal/ui"
+	"github.com/JustSkiv/goschedviz/internal/ui/termui/widgets"
 )
 
 func TestTermUI_New(t *testing.T) {
@@ -160,3 +162,332 @@ func TestTermUI_ResizeEvent(t *testing.T) {
 	<-done
 	term.Stop()
 }
+
+func TestTermUI_Bind(t *testing.T) {
+	mock := newTestTerminal()
+	term := newWithTerminal(mock)
+
+	called := make(chan string, 2)
+	term.Bind("<Space>", func() { called <- "space" })
+	term.Bind("q", func() { called <- "q" })
+
+	err := term.Start()
+	require.NoError(t, err)
+
+	mock.SendEvent(termui.Event{ID: "<Space>"})
+	select {
+	case got := <-called:
+		assert.Equal(t, "space", got)
+	case <-time.After(time.Second):
+		t.Fatal("Bound handler was not called")
+	}
+
+	// Quit key keeps its meaning even if bound
+	mock.SendEvent(termui.Event{ID: "q"})
+	select {
+	case <-term.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Quit event was not processed")
+	}
+	assert.Empty(t, called)
+
+	term.Stop()
+}
+
+func TestTermUI_ToggleDetail(t *testing.T) {
+	mock := newTestTerminal()
+	term := newWithTerminal(mock)
+
+	err := term.Start()
+	require.NoError(t, err)
+
+	mock.SendEvent(termui.Event{ID: "p"})
+	// Unbuffered channel: next send completes only after "p" was handled
+	mock.SendEvent(termui.Event{ID: "<Unknown>"})
+
+	term.mu.Lock()
+	assert.True(t, term.showDetail, "'p' should switch to detail view")
+	assert.Equal(t, 100, term.grid.GetRect().Dx(), "Grid size should be kept")
+	term.mu.Unlock()
+
+	assert.NotPanics(t, func() {
+		term.Update(ui.UIData{
+			Gauges: ui.GaugeValues{
+				GRQ:        struct{ Current, Max int }{0, 1},
+				Goroutines: struct{ Current, Max int }{0, 1},
+				Threads:    struct{ Current, Max int }{0, 1},
+				IdleProcs:  struct{ Current, Max int }{0, 1},
+			},
+			Detail: &ui.DetailValues{
+				Procs: []ui.ProcValues{{ID: 0, Status: "running", M: 1, CurG: 5}},
+			},
+		})
+	})
+
+	mock.SendEvent(termui.Event{ID: "p"})
+	mock.SendEvent(termui.Event{ID: "<Unknown>"})
+
+	term.mu.Lock()
+	assert.False(t, term.showDetail, "Second 'p' should switch back to LRQ chart")
+	term.mu.Unlock()
+
+	term.Stop()
+}
+
+func TestTermUI_LogPane(t *testing.T) {
+	mock := newTestTerminal()
+	term := newWithTerminal(mock)
+
+	err := term.Start()
+	require.NoError(t, err)
+
+	mock.SendEvent(termui.Event{ID: "l"})
+	mock.SendEvent(termui.Event{ID: "/"})
+	// Keys typed into the search query must not quit or trigger bindings
+	mock.SendEvent(termui.Event{ID: "q"})
+	mock.SendEvent(termui.Event{ID: "<Enter>"})
+	mock.SendEvent(termui.Event{ID: "<Unknown>"})
+
+	select {
+	case <-term.Done():
+		t.Fatal("'q' typed in search should not quit")
+	default:
+	}
+
+	term.mu.Lock()
+	assert.True(t, term.showLogs, "'l' should show the log pane")
+	assert.False(t, term.logs.Searching())
+	assert.Equal(t, "q", term.logs.Query())
+	term.mu.Unlock()
+
+	mock.SendEvent(termui.Event{ID: "q"})
+	select {
+	case <-term.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Quit event was not processed")
+	}
+
+	term.Stop()
+}
+
+func TestTermUI_Diagnostics(t *testing.T) {
+	mock := newTestTerminal()
+	term := newWithTerminal(mock)
+
+	err := term.Start()
+	require.NoError(t, err)
+
+	term.Update(ui.UIData{
+		Gauges: ui.GaugeValues{
+			GRQ:        struct{ Current, Max int }{0, 1},
+			Goroutines: struct{ Current, Max int }{0, 1},
+			Threads:    struct{ Current, Max int }{0, 1},
+			IdleProcs:  struct{ Current, Max int }{0, 1},
+		},
+		Diagnostics: &ui.DiagnosticsValues{Lines: 3, Malformed: 1},
+	})
+
+	mock.SendEvent(termui.Event{ID: "l"})
+	mock.SendEvent(termui.Event{ID: "d"})
+	// A no-op event makes sure the previous one was processed
+	mock.SendEvent(termui.Event{ID: "<Unknown>"})
+
+	term.mu.Lock()
+	assert.True(t, term.showDiag, "'d' should show the diagnostics pane")
+	assert.False(t, term.showLogs, "Diagnostics pane should replace the log pane")
+	lines, _ := term.diagnostics.Lines()
+	assert.Contains(t, lines[0], "Malformed: 1")
+	term.mu.Unlock()
+
+	mock.SendEvent(termui.Event{ID: "l"})
+	mock.SendEvent(termui.Event{ID: "<Unknown>"})
+
+	term.mu.Lock()
+	assert.False(t, term.showDiag, "Log pane should replace the diagnostics pane")
+	assert.True(t, term.showLogs)
+	term.mu.Unlock()
+
+	mock.SendEvent(termui.Event{ID: "m"})
+	mock.SendEvent(termui.Event{ID: "<Unknown>"})
+
+	term.mu.Lock()
+	assert.True(t, term.showMetric, "'m' should show the runtime metrics pane")
+	assert.False(t, term.showLogs)
+	term.mu.Unlock()
+
+	mock.SendEvent(termui.Event{ID: "h"})
+	mock.SendEvent(termui.Event{ID: "<Unknown>"})
+
+	term.mu.Lock()
+	assert.True(t, term.showLatency, "'h' should show the latency heatmap")
+	assert.False(t, term.showMetric)
+	term.grid.Draw(termui.NewBuffer(term.grid.GetRect()))
+	assert.Equal(t, term.latency.GetRect().Max.X, term.latencyPlot.GetRect().Min.X, "Plot is right of the heatmap")
+	term.mu.Unlock()
+
+	mock.SendEvent(termui.Event{ID: "g"})
+	mock.SendEvent(termui.Event{ID: "<Unknown>"})
+
+	term.mu.Lock()
+	assert.True(t, term.showGoroutines, "'g' should show the goroutine states chart")
+	assert.False(t, term.showLatency)
+	term.grid.Draw(termui.NewBuffer(term.grid.GetRect()))
+	assert.Equal(t, term.goroutineStates.GetRect().Max.X, term.goroutineSites.GetRect().Min.X,
+		"Creation sites are right of the chart")
+	term.mu.Unlock()
+
+	term.Stop()
+}
+
+func TestTermUI_ExitBanner(t *testing.T) {
+	mock := newTestTerminal()
+	term := newWithTerminal(mock)
+
+	err := term.Start()
+	require.NoError(t, err)
+
+	data := ui.UIData{
+		Gauges: ui.GaugeValues{
+			GRQ:        struct{ Current, Max int }{0, 1},
+			Goroutines: struct{ Current, Max int }{0, 1},
+			Threads:    struct{ Current, Max int }{0, 1},
+			IdleProcs:  struct{ Current, Max int }{0, 1},
+		},
+		Exit: &ui.ExitValues{Code: 1},
+	}
+	term.Update(data)
+
+	term.mu.Lock()
+	assert.True(t, term.showExit, "Banner should be shown after exit")
+	// Grid positions widgets when drawn
+	term.grid.Draw(termui.NewBuffer(term.grid.GetRect()))
+	rect := term.exit.GetRect()
+	assert.Equal(t, 0, rect.Min.Y, "Banner should be on top")
+	assert.Equal(t, 100, rect.Dx())
+	assert.Equal(t, widgets.ExitBannerHeight, rect.Dy())
+	term.mu.Unlock()
+
+	// Restarted program hides the banner
+	data.Exit = nil
+	term.Update(data)
+
+	term.mu.Lock()
+	assert.False(t, term.showExit)
+	term.mu.Unlock()
+
+	term.Stop()
+}
+
+func TestTermUI_Alerts(t *testing.T) {
+	mock := newTestTerminal()
+	term := newWithTerminal(mock)
+	require.NoError(t, term.Start())
+	defer term.Stop()
+
+	highlightable := term.highlightable()
+	for _, name := range alert.Widgets {
+		assert.Contains(t, highlightable, name, "Every widget of alert rules can be highlighted")
+	}
+
+	data := ui.UIData{
+		Gauges: ui.GaugeValues{
+			GRQ:        struct{ Current, Max int }{0, 1},
+			Goroutines: struct{ Current, Max int }{0, 1},
+			Threads:    struct{ Current, Max int }{0, 1},
+			IdleProcs:  struct{ Current, Max int }{0, 1},
+		},
+		Alerts: []ui.AlertValues{{Name: "busy", Widgets: []string{"grq", "plots"}}},
+	}
+	threads := term.threadsGauge.BorderStyle.Fg
+	term.Update(data)
+
+	term.mu.Lock()
+	assert.Equal(t, termui.ColorRed, term.grqGauge.BorderStyle.Fg)
+	assert.Equal(t, termui.ColorRed, term.linearPlot.BorderStyle.Fg)
+	assert.Equal(t, termui.ColorRed, term.logPlot.BorderStyle.Fg)
+	assert.Equal(t, threads, term.threadsGauge.BorderStyle.Fg)
+	term.mu.Unlock()
+
+	data.Alerts = nil
+	term.Update(data)
+
+	term.mu.Lock()
+	assert.Equal(t, threads, term.grqGauge.BorderStyle.Fg, "Resolved alert restores the border")
+	term.mu.Unlock()
+}
+
+func TestTermUI_Tabs(t *testing.T) {
+	mock := newTestTerminal()
+	term := newWithTerminal(mock)
+
+	err := term.Start()
+	require.NoError(t, err)
+
+	term.Update(ui.UIData{
+		Gauges: ui.GaugeValues{
+			GRQ:        struct{ Current, Max int }{0, 1},
+			Goroutines: struct{ Current, Max int }{0, 1},
+			Threads:    struct{ Current, Max int }{0, 1},
+			IdleProcs:  struct{ Current, Max int }{0, 1},
+		},
+		Tabs: []string{"producer", "consumer"},
+		Tab:  1,
+		Exit: &ui.ExitValues{Code: 0},
+	})
+
+	term.mu.Lock()
+	assert.True(t, term.showTabs)
+	assert.Equal(t, 1, term.tabs.ActiveTabIndex)
+
+	// Tab bar is on top, exit banner of the shown source right below it
+	term.grid.Draw(termui.NewBuffer(term.grid.GetRect()))
+	assert.Equal(t, 0, term.tabs.GetRect().Min.Y)
+	assert.Equal(t, widgets.SourceTabsHeight, term.exit.GetRect().Min.Y)
+	term.mu.Unlock()
+
+	term.Stop()
+}
+
+func TestTermUI_Split(t *testing.T) {
+	mock := newTestTerminal()
+	term := newWithTerminal(mock)
+
+	err := term.Start()
+	require.NoError(t, err)
+
+	history := []ui.HistoricalValues{{Goroutines: 10}, {Goroutines: 20}}
+	term.Update(ui.UIData{
+		Gauges: ui.GaugeValues{
+			GRQ:        struct{ Current, Max int }{0, 1},
+			Goroutines: struct{ Current, Max int }{0, 1},
+			Threads:    struct{ Current, Max int }{0, 1},
+			IdleProcs:  struct{ Current, Max int }{0, 1},
+		},
+		Tabs: []string{"producer", "consumer"},
+		Split: []ui.SourceHistory{
+			{Name: "producer", History: history},
+			{Name: "consumer", History: history},
+		},
+		SplitWindow: time.Minute,
+	})
+
+	mock.SendEvent(termui.Event{Type: termui.KeyboardEvent, ID: "s"})
+	require.Eventually(t, func() bool {
+		term.mu.Lock()
+		defer term.mu.Unlock()
+		return term.showSplit
+	}, time.Second, 10*time.Millisecond)
+
+	// Source plots are stacked below the tab bar
+	term.mu.Lock()
+	require.Len(t, term.sourcePlots, 2)
+	term.grid.Draw(termui.NewBuffer(term.grid.GetRect()))
+	first, second := term.sourcePlots[0].GetRect(), term.sourcePlots[1].GetRect()
+	assert.Equal(t, widgets.SourceTabsHeight, first.Min.Y)
+	assert.InDelta(t, first.Max.Y, second.Min.Y, 1)
+	assert.Equal(t, first.Min.X, second.Min.X)
+	assert.Contains(t, term.sourcePlots[0].Title, "producer")
+	term.mu.Unlock()
+
+	term.Stop()
+}
@@ -0,0 +1,78 @@
+package widgets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// maxWaitReasons limits how many wait reasons are listed.
+const maxWaitReasons = 5
+
+// SchedDetailBox displays which M holds each P and where goroutines are parked.
+// It requires the target to run with GODEBUG scheddetail=1.
+type SchedDetailBox struct {
+	*widgets.Paragraph
+}
+
+// NewSchedDetailBox creates a new scheduler detail widget.
+func NewSchedDetailBox() *SchedDetailBox {
+	d := &SchedDetailBox{
+		Paragraph: widgets.NewParagraph(),
+	}
+	d.Title = "P/M/G Detail"
+	d.BorderStyle.Fg = tui.ColorCyan
+	d.Text = "No scheddetail data, run with -detail"
+	return d
+}
+
+// Update refreshes P to M mapping and wait reasons.
+func (d *SchedDetailBox) Update(detail *ui.DetailValues) {
+	if detail == nil {
+		d.Text = "No scheddetail data, run with -detail"
+		return
+	}
+
+	var b strings.Builder
+	for _, p := range detail.Procs {
+		fmt.Fprintf(&b, "P%-3d %-8s M:%-4s G:%-5s runq %d\n",
+			p.ID, p.Status, formatRef(p.M), formatRef(p.CurG), p.RunqSize)
+	}
+
+	reasons := make([]string, 0, len(detail.Waiting))
+	for reason := range detail.Waiting {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		ci, cj := detail.Waiting[reasons[i]], detail.Waiting[reasons[j]]
+		if ci != cj {
+			return ci > cj
+		}
+		return reasons[i] < reasons[j]
+	})
+	if len(reasons) > maxWaitReasons {
+		reasons = reasons[:maxWaitReasons]
+	}
+
+	if len(reasons) > 0 {
+		b.WriteString("Parked goroutines:\n")
+		for _, reason := range reasons {
+			fmt.Fprintf(&b, "  %-20s %d\n", reason, detail.Waiting[reason])
+		}
+	}
+
+	d.Text = strings.TrimRight(b.String(), "\n")
+}
+
+// formatRef formats an ID reference, "-" stands for none.
+func formatRef(id int) string {
+	if id < 0 {
+		return "-"
+	}
+	return fmt.Sprint(id)
+}
@@ -0,0 +1,50 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+func TestSchedDetailBox_New(t *testing.T) {
+	d := NewSchedDetailBox()
+	require.NotNil(t, d)
+	assert.Equal(t, "P/M/G Detail", d.Title)
+	assert.Contains(t, d.Text, "-detail", "Empty widget should hint how to enable detail")
+}
+
+func TestSchedDetailBox_Update(t *testing.T) {
+	d := NewSchedDetailBox()
+	d.Update(&ui.DetailValues{
+		Procs: []ui.ProcValues{
+			{ID: 0, Status: "running", M: 2, CurG: 17, RunqSize: 3},
+			{ID: 1, Status: "idle", M: -1, CurG: -1},
+		},
+		Waiting: map[string]int{
+			"chan receive": 10,
+			"select":       3,
+			"sleep":        3,
+			"IO wait":      2,
+			"semacquire":   1,
+			"sync.Cond":    1,
+		},
+	})
+
+	lines := strings.Split(d.Text, "\n")
+	require.Len(t, lines, 2+1+maxWaitReasons)
+
+	assert.Equal(t, "P0   running  M:2    G:17    runq 3", lines[0])
+	assert.Equal(t, "P1   idle     M:-    G:-     runq 0", lines[1])
+	assert.Equal(t, "Parked goroutines:", lines[2])
+	assert.Contains(t, lines[3], "chan receive")
+	assert.Contains(t, lines[4], "select", "Equal counts should be sorted by name")
+	assert.Contains(t, lines[5], "sleep")
+	assert.Contains(t, lines[7], "semacquire")
+
+	d.Update(nil)
+	assert.Contains(t, d.Text, "No scheddetail data")
+}
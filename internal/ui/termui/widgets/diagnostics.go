@@ -0,0 +1,87 @@
+package widgets
+
+import (
+	"fmt"
+	"strings"
+
+	tui "github.com/gizak/termui/v3"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// DiagnosticsPane shows how well the input of the source is parsed:
+// line counters and the latest lines that were dropped or kept with a warning.
+type DiagnosticsPane struct {
+	tui.Block
+
+	diagnostics *ui.DiagnosticsValues
+}
+
+// NewDiagnosticsPane creates a new diagnostics pane.
+func NewDiagnosticsPane() *DiagnosticsPane {
+	d := &DiagnosticsPane{Block: *tui.NewBlock()}
+	d.Title = "Parse Diagnostics (d to close)"
+	d.BorderStyle.Fg = tui.ColorMagenta
+	return d
+}
+
+// Update replaces displayed diagnostics, nil means the source doesn't parse trace output.
+func (d *DiagnosticsPane) Update(diagnostics *ui.DiagnosticsValues) {
+	d.diagnostics = diagnostics
+}
+
+// Lines returns text lines of the pane with their styles, newest issue first.
+func (d *DiagnosticsPane) Lines() ([]string, []tui.Style) {
+	if d.diagnostics == nil {
+		return []string{"The source doesn't parse trace output"}, []tui.Style{tui.NewStyle(tui.ColorWhite)}
+	}
+
+	diag := d.diagnostics
+	lines := []string{
+		fmt.Sprintf("Lines: %d  Snapshots: %d  Unmatched: %d  Malformed: %d  Rejected: %d  Suspicious: %d",
+			diag.Lines, diag.Snapshots, diag.Unmatched, diag.Malformed, diag.Rejected, diag.Suspicious),
+	}
+	styles := []tui.Style{tui.NewStyle(tui.ColorWhite)}
+
+	if diag.FieldSet != "" {
+		format := "Trace fields: " + diag.FieldSet
+		if len(diag.Unknown) > 0 {
+			format += "  Unknown: " + strings.Join(diag.Unknown, ", ")
+		}
+		lines = append(lines, format)
+		styles = append(styles, tui.NewStyle(tui.ColorWhite))
+	}
+
+	if diag.Lines > 0 && diag.Snapshots == 0 && len(diag.Recent) == 0 {
+		lines = append(lines, "No scheduler trace lines found, is GODEBUG=schedtrace set?")
+		styles = append(styles, tui.NewStyle(tui.ColorYellow))
+	}
+
+	for i := len(diag.Recent) - 1; i >= 0; i-- {
+		issue := diag.Recent[i]
+		style := tui.NewStyle(tui.ColorRed)
+		if issue.Reason == "suspicious" {
+			style = tui.NewStyle(tui.ColorYellow)
+		}
+		lines = append(lines, fmt.Sprintf("%s %s: %s", issue.Time.Format("15:04:05.000"), issue.Reason, issue.Detail))
+		styles = append(styles, style)
+		if issue.Line != "" {
+			lines = append(lines, "  "+strings.ReplaceAll(issue.Line, "\t", "    "))
+			styles = append(styles, tui.NewStyle(tui.ColorWhite))
+		}
+	}
+	return lines, styles
+}
+
+// Draw draws counters and recent issues as plain text.
+func (d *DiagnosticsPane) Draw(buf *tui.Buffer) {
+	d.Block.Draw(buf)
+
+	lines, styles := d.Lines()
+	for i, line := range lines {
+		if i >= d.Inner.Dy() {
+			return
+		}
+		drawClipped(buf, d.Inner, line, styles[i], d.Inner.Min.Y+i)
+	}
+}
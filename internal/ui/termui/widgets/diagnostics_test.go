@@ -0,0 +1,61 @@
+package widgets
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	tui "github.com/gizak/termui/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+func TestDiagnosticsPane_Lines(t *testing.T) {
+	pane := NewDiagnosticsPane()
+
+	lines, _ := pane.Lines()
+	assert.Equal(t, []string{"The source doesn't parse trace output"}, lines)
+
+	at := time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local)
+	pane.Update(&ui.DiagnosticsValues{
+		Lines:     10,
+		Snapshots: 2,
+		Unmatched: 6,
+		Malformed: 1,
+		Rejected:  1,
+		FieldSet:  "go1.21+",
+		Unknown:   []string{"schedticks"},
+		Recent: []ui.ParseIssue{
+			{Time: at, Reason: "malformed", Detail: "missing field needspinning", Line: "SCHED 1000ms: gomaxprocs=2"},
+			{Time: at.Add(time.Second), Reason: "rejected", Detail: "gomaxprocs=0 is not positive"},
+		},
+	})
+
+	lines, styles := pane.Lines()
+	assert.Equal(t, []string{
+		"Lines: 10  Snapshots: 2  Unmatched: 6  Malformed: 1  Rejected: 1  Suspicious: 0",
+		"Trace fields: go1.21+  Unknown: schedticks",
+		"15:04:06.000 rejected: gomaxprocs=0 is not positive",
+		"15:04:05.000 malformed: missing field needspinning",
+		"  SCHED 1000ms: gomaxprocs=2",
+	}, lines, "Newest issue goes first")
+	assert.Equal(t, tui.ColorRed, styles[2].Fg)
+}
+
+func TestDiagnosticsPane_NoTrace(t *testing.T) {
+	pane := NewDiagnosticsPane()
+	pane.Update(&ui.DiagnosticsValues{Lines: 50, Unmatched: 50})
+
+	lines, _ := pane.Lines()
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "GODEBUG=schedtrace")
+
+	// Lines are drawn as plain text, clipped to the pane
+	pane.SetRect(0, 0, 20, 5)
+	buf := tui.NewBuffer(image.Rect(0, 0, 20, 5))
+	pane.Draw(buf)
+	assert.Equal(t, 'L', buf.GetCell(image.Pt(1, 1)).Rune)
+	assert.Equal(t, 'N', buf.GetCell(image.Pt(1, 2)).Rune)
+}
@@ -0,0 +1,62 @@
+package widgets
+
+import (
+	"fmt"
+	"time"
+
+	tui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// ExitBannerHeight is the number of terminal rows taken by the exit banner.
+const ExitBannerHeight = 3
+
+// ExitBanner tells how the monitored program ended.
+// It's shown above the other widgets only after the program exits.
+type ExitBanner struct {
+	*widgets.Paragraph
+}
+
+// NewExitBanner creates a new exit banner widget.
+func NewExitBanner() *ExitBanner {
+	b := &ExitBanner{
+		Paragraph: widgets.NewParagraph(),
+	}
+	b.Title = "Program Exited"
+	return b
+}
+
+// Update refreshes the banner with the exit status, nil clears it.
+func (b *ExitBanner) Update(exit *ui.ExitValues) {
+	if exit == nil {
+		b.Text = ""
+		return
+	}
+
+	color := tui.ColorGreen
+	if exit.Code != 0 || exit.Signal != "" {
+		color = tui.ColorRed
+	}
+	b.BorderStyle.Fg = color
+	b.TitleStyle.Fg = color
+
+	runtime := exit.Runtime.Round(100 * time.Millisecond)
+	text := fmt.Sprintf("Exited with code %d after %s", exit.Code, runtime)
+	if exit.Signal != "" {
+		text = fmt.Sprintf("Killed by signal %q after %s", exit.Signal, runtime)
+	}
+	if exit.Restarts > 0 {
+		text += fmt.Sprintf(", restarted %d times before", exit.Restarts)
+	}
+	switch {
+	case exit.Restarting:
+		text += ". Restarting..."
+	case exit.Watching:
+		text += ". Waiting for source changes, press 'q' to quit"
+	default:
+		text += ". History is kept, press 'q' to quit"
+	}
+	b.Text = text
+}
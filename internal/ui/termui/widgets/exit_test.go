@@ -0,0 +1,59 @@
+package widgets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gizak/termui/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+func TestExitBanner_Update(t *testing.T) {
+	tests := []struct {
+		name      string
+		exit      *ui.ExitValues
+		wantText  string
+		wantColor termui.Color
+	}{
+		{
+			name:      "success",
+			exit:      &ui.ExitValues{Code: 0, Runtime: 2540 * time.Millisecond},
+			wantText:  "Exited with code 0 after 2.5s. History is kept, press 'q' to quit",
+			wantColor: termui.ColorGreen,
+		},
+		{
+			name:      "restarting",
+			exit:      &ui.ExitValues{Code: 1, Runtime: time.Second, Restarts: 2, Restarting: true},
+			wantText:  "Exited with code 1 after 1s, restarted 2 times before. Restarting...",
+			wantColor: termui.ColorRed,
+		},
+		{
+			name:      "watching",
+			exit:      &ui.ExitValues{Code: 0, Runtime: time.Second, Watching: true},
+			wantText:  "Exited with code 0 after 1s. Waiting for source changes, press 'q' to quit",
+			wantColor: termui.ColorGreen,
+		},
+		{
+			name:      "signal",
+			exit:      &ui.ExitValues{Code: -1, Signal: "segmentation fault", Runtime: time.Minute},
+			wantText:  `Killed by signal "segmentation fault" after 1m0s. History is kept, press 'q' to quit`,
+			wantColor: termui.ColorRed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			banner := NewExitBanner()
+			banner.Update(tt.exit)
+			assert.Equal(t, tt.wantText, banner.Text)
+			assert.Equal(t, tt.wantColor, banner.BorderStyle.Fg)
+		})
+	}
+
+	banner := NewExitBanner()
+	banner.Update(&ui.ExitValues{Code: 1})
+	banner.Update(nil)
+	assert.Empty(t, banner.Text)
+}
@@ -0,0 +1,57 @@
+package widgets
+
+import (
+	"fmt"
+	"strings"
+
+	tui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// GCPanel displays the latest garbage collection cycle and a summary of recent ones.
+// It requires the target to run with GODEBUG gctrace=1.
+type GCPanel struct {
+	*widgets.Paragraph
+}
+
+// NewGCPanel creates a new garbage collection panel.
+func NewGCPanel() *GCPanel {
+	g := &GCPanel{
+		Paragraph: widgets.NewParagraph(),
+	}
+	g.Title = "Garbage Collection"
+	g.BorderStyle.Fg = tui.ColorBlue
+	g.Text = "No GC cycles, run with -gc"
+	return g
+}
+
+// Update refreshes GC cycle information.
+func (g *GCPanel) Update(gc *ui.GCValues) {
+	if gc == nil {
+		g.Text = "No GC cycles, run with -gc"
+		return
+	}
+
+	last := gc.Last
+	forced := ""
+	if last.Forced {
+		forced = " [(forced)](fg:yellow)"
+	}
+	merged := ""
+	if last.Merged > 0 {
+		merged = fmt.Sprintf(" (+%d earlier)", last.Merged)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Last: #%d%s @%.3fs%s\n", last.Number, merged, float64(last.AtMs)/1000, forced)
+	fmt.Fprintf(&b, "Heap: %d→%d→%d MB, goal %d MB\n", last.HeapStartMB, last.HeapEndMB, last.HeapLiveMB, last.HeapGoalMB)
+	fmt.Fprintf(&b, "STW: %.3f + %.3f ms\n", last.SweepTermMs, last.MarkTermMs)
+	fmt.Fprintf(&b, "Mark: %.3f ms, assist CPU %.3f ms\n", last.MarkMs, last.AssistCPUMs)
+	fmt.Fprintf(&b, "GC CPU: %d%%\n", last.CPUPercent)
+	fmt.Fprintf(&b, "Recent: %d cycles, %d forced\n", gc.Cycles, gc.Forced)
+	fmt.Fprintf(&b, "Max STW: %.3f ms", gc.MaxSTWMs)
+
+	g.Text = b.String()
+}
@@ -0,0 +1,54 @@
+package widgets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+func TestGCPanel_Update(t *testing.T) {
+	g := NewGCPanel()
+	require.NotNil(t, g)
+	assert.Equal(t, "Garbage Collection", g.Title)
+	assert.Contains(t, g.Text, "-gc", "Empty panel should hint how to enable GC trace")
+
+	g.Update(&ui.GCValues{
+		Last: ui.GCCycleValues{
+			Number:      7,
+			AtMs:        1025,
+			CPUPercent:  2,
+			SweepTermMs: 0.022,
+			MarkMs:      1.3,
+			MarkTermMs:  0.005,
+			AssistCPUMs: 0.45,
+			HeapStartMB: 4,
+			HeapEndMB:   5,
+			HeapLiveMB:  2,
+			HeapGoalMB:  5,
+			Forced:      true,
+		},
+		Cycles:   3,
+		Forced:   1,
+		MaxSTWMs: 0.12,
+	})
+
+	lines := strings.Split(g.Text, "\n")
+	require.Len(t, lines, 7)
+	assert.Equal(t, "Last: #7 @1.025s [(forced)](fg:yellow)", lines[0])
+	assert.Equal(t, "Heap: 4→5→2 MB, goal 5 MB", lines[1])
+	assert.Equal(t, "STW: 0.022 + 0.005 ms", lines[2])
+	assert.Equal(t, "Mark: 1.300 ms, assist CPU 0.450 ms", lines[3])
+	assert.Equal(t, "GC CPU: 2%", lines[4])
+	assert.Equal(t, "Recent: 3 cycles, 1 forced", lines[5])
+	assert.Equal(t, "Max STW: 0.120 ms", lines[6])
+
+	g.Update(&ui.GCValues{Last: ui.GCCycleValues{Number: 12, AtMs: 3000, Merged: 2}, Cycles: 3})
+	assert.True(t, strings.HasPrefix(g.Text, "Last: #12 (+2 earlier) @3.000s\n"), "Cycles polled together are shown")
+
+	g.Update(nil)
+	assert.Contains(t, g.Text, "No GC cycles")
+}
@@ -0,0 +1,165 @@
+package widgets
+
+import (
+	"fmt"
+	"strings"
+
+	tui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// stateColors colors goroutine states, aligned with ui.GoroutineStates.
+var stateColors = []tui.Color{
+	tui.ColorGreen,   // running
+	tui.ColorYellow,  // runnable
+	tui.ColorBlue,    // io_wait
+	tui.ColorCyan,    // chan_receive
+	tui.Color(14),    // chan_send, bright cyan
+	tui.ColorMagenta, // select
+	tui.ColorWhite,   // syscall
+	tui.Color(8),     // sleep, gray
+	tui.ColorRed,     // semacquire
+	tui.Color(240),   // other, dark gray
+}
+
+// stateLabel returns a readable name of a goroutine state, e.g. "chan receive".
+func stateLabel(state string) string {
+	if state == "io_wait" {
+		return "IO wait"
+	}
+	return strings.ReplaceAll(state, "_", " ")
+}
+
+// GoroutineStatesChart shows goroutine counts by state as stacked bars,
+// a bar per sample with the latest on the right.
+type GoroutineStatesChart struct {
+	*widgets.StackedBarChart
+
+	samples []ui.GoroutineSample
+}
+
+// NewGoroutineStatesChart creates a new goroutine states chart.
+func NewGoroutineStatesChart() *GoroutineStatesChart {
+	c := &GoroutineStatesChart{StackedBarChart: widgets.NewStackedBarChart()}
+	c.BorderStyle.Fg = tui.ColorCyan
+	c.BarWidth = 2
+	c.BarGap = 1
+	c.BarColors = stateColors
+	// Bars are too narrow for numbers, counts are listed next to the chart
+	c.NumFormatter = func(float64) string { return "" }
+	c.Update(nil)
+	return c
+}
+
+// Update replaces displayed samples, nil means the program doesn't report its goroutine profile.
+func (c *GoroutineStatesChart) Update(goroutines *ui.GoroutineValues) {
+	c.samples = nil
+	if goroutines != nil {
+		c.samples = goroutines.Samples
+	}
+
+	if len(c.samples) == 0 {
+		c.Title = "Goroutine States: no data (g to close)"
+		return
+	}
+	total := 0
+	for _, n := range c.samples[len(c.samples)-1].Counts {
+		total += n
+	}
+	c.Title = fmt.Sprintf("Goroutine States: %d goroutines (g to close)", total)
+}
+
+// Bars returns the number of bars that fit the chart.
+func (c *GoroutineStatesChart) Bars() int {
+	return max((c.Inner.Dx()+c.BarGap)/(c.BarWidth+c.BarGap), 0)
+}
+
+// Draw draws as many latest samples as fit the chart.
+func (c *GoroutineStatesChart) Draw(buf *tui.Buffer) {
+	samples := c.samples
+	if bars := c.Bars(); len(samples) > bars {
+		samples = samples[len(samples)-bars:]
+	}
+
+	c.Data, c.MaxVal = nil, 0
+	for _, s := range samples {
+		bar := make([]float64, len(s.Counts))
+		sum := 0.0
+		for i, n := range s.Counts {
+			bar[i] = float64(n)
+			sum += bar[i]
+		}
+		c.Data = append(c.Data, bar)
+		c.MaxVal = max(c.MaxVal, sum)
+	}
+	if c.MaxVal == 0 {
+		// Nothing to scale bars to
+		c.Data = nil
+	}
+
+	c.StackedBarChart.Draw(buf)
+}
+
+// GoroutineSitesBox lists goroutine counts by state of the latest sample,
+// colored like the chart, and functions that created most goroutines.
+type GoroutineSitesBox struct {
+	tui.Block
+
+	goroutines *ui.GoroutineValues
+}
+
+// NewGoroutineSitesBox creates a new goroutine states legend and creation sites list.
+func NewGoroutineSitesBox() *GoroutineSitesBox {
+	b := &GoroutineSitesBox{Block: *tui.NewBlock()}
+	b.Title = "States & Creation Sites"
+	b.BorderStyle.Fg = tui.ColorCyan
+	return b
+}
+
+// Update replaces displayed values.
+func (b *GoroutineSitesBox) Update(goroutines *ui.GoroutineValues) {
+	b.goroutines = goroutines
+}
+
+// Lines returns text lines of the box with their styles.
+func (b *GoroutineSitesBox) Lines() ([]string, []tui.Style) {
+	if b.goroutines == nil || len(b.goroutines.Samples) == 0 {
+		return []string{"No goroutine profile, use metrics.WithGoroutineProfile"}, []tui.Style{tui.NewStyle(tui.ColorWhite)}
+	}
+
+	var lines []string
+	var styles []tui.Style
+	last := b.goroutines.Samples[len(b.goroutines.Samples)-1]
+	for i, state := range ui.GoroutineStates {
+		if i >= len(last.Counts) || last.Counts[i] == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("█ %-13s %d", stateLabel(state), last.Counts[i]))
+		styles = append(styles, tui.NewStyle(stateColors[i]))
+	}
+
+	if len(b.goroutines.Sites) > 0 {
+		lines = append(lines, "Created by:")
+		styles = append(styles, tui.NewStyle(tui.ColorWhite, tui.ColorClear, tui.ModifierBold))
+		for _, site := range b.goroutines.Sites {
+			lines = append(lines, fmt.Sprintf("%7d %s", site.Count, site.Function))
+			styles = append(styles, tui.NewStyle(tui.ColorWhite))
+		}
+	}
+	return lines, styles
+}
+
+// Draw draws the legend and creation sites as plain text.
+func (b *GoroutineSitesBox) Draw(buf *tui.Buffer) {
+	b.Block.Draw(buf)
+
+	lines, styles := b.Lines()
+	for i, line := range lines {
+		if i >= b.Inner.Dy() {
+			return
+		}
+		drawClipped(buf, b.Inner, line, styles[i], b.Inner.Min.Y+i)
+	}
+}
@@ -0,0 +1,79 @@
+package widgets
+
+import (
+	"image"
+	"testing"
+
+	tui "github.com/gizak/termui/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// goroutineSample returns a sample with given running and chan receive counts.
+func goroutineSample(running, chanReceive int) ui.GoroutineSample {
+	counts := make([]int, len(ui.GoroutineStates))
+	counts[0], counts[3] = running, chanReceive
+	return ui.GoroutineSample{Counts: counts}
+}
+
+func TestGoroutineStatesChart_Draw(t *testing.T) {
+	chart := NewGoroutineStatesChart()
+	assert.Contains(t, chart.Title, "no data")
+	assert.Len(t, stateColors, len(ui.GoroutineStates))
+
+	var samples []ui.GoroutineSample
+	for i := range 20 {
+		samples = append(samples, goroutineSample(1, i))
+	}
+	chart.Update(&ui.GoroutineValues{Samples: samples})
+	assert.Equal(t, "Goroutine States: 20 goroutines (g to close)", chart.Title)
+
+	chart.SetRect(0, 0, 14, 10)
+	buf := tui.NewBuffer(image.Rect(0, 0, 20, 10))
+	chart.Draw(buf)
+
+	// 12 columns fit 4 bars of 2 with gaps, only the latest samples are drawn
+	require.Equal(t, 4, chart.Bars())
+	require.Len(t, chart.Data, 4)
+	assert.Equal(t, []float64{1, 0, 0, 19, 0, 0, 0, 0, 0, 0}, chart.Data[3])
+
+	// Chan receive goroutines fill the latest bar, the last row is for labels
+	bottom := chart.Inner.Max.Y - 2
+	assert.Equal(t, tui.ColorCyan, buf.GetCell(image.Pt(10, bottom)).Style.Bg)
+	assert.Equal(t, tui.ColorCyan, buf.GetCell(image.Pt(11, chart.Inner.Min.Y+1)).Style.Bg)
+	assert.Equal(t, tui.ColorClear, buf.GetCell(image.Pt(15, bottom)).Style.Bg, "Bars stay inside the chart")
+
+	chart.Update(&ui.GoroutineValues{Samples: []ui.GoroutineSample{goroutineSample(0, 0)}})
+	assert.NotPanics(t, func() { chart.Draw(buf) })
+	assert.Empty(t, chart.Data, "Empty samples have nothing to scale to")
+}
+
+func TestGoroutineSitesBox_Lines(t *testing.T) {
+	box := NewGoroutineSitesBox()
+	lines, _ := box.Lines()
+	assert.Equal(t, []string{"No goroutine profile, use metrics.WithGoroutineProfile"}, lines)
+
+	counts := make([]int, len(ui.GoroutineStates))
+	counts[1], counts[2] = 3, 12
+	box.Update(&ui.GoroutineValues{
+		Samples: []ui.GoroutineSample{goroutineSample(1, 1), {Counts: counts}},
+		Sites:   []ui.GoroutineSite{{Function: "main.worker", Count: 12}},
+	})
+	lines, styles := box.Lines()
+	assert.Equal(t, []string{
+		"█ runnable      3",
+		"█ IO wait       12",
+		"Created by:",
+		"     12 main.worker",
+	}, lines)
+	assert.Equal(t, tui.ColorYellow, styles[0].Fg)
+	assert.Equal(t, tui.ColorBlue, styles[1].Fg)
+}
+
+func TestStateLabel(t *testing.T) {
+	assert.Equal(t, "chan receive", stateLabel("chan_receive"))
+	assert.Equal(t, "IO wait", stateLabel("io_wait"))
+	assert.Equal(t, "running", stateLabel("running"))
+}
@@ -17,6 +17,8 @@ import (
 // - Exit instructions
 // - Last update timestamp
 // - Maximum values for GRQ and goroutines
+// - Data source status, when available
+// - Goroutine leak warning, when the goroutines count grows steadily
 type InfoBox struct {
 	*widgets.Paragraph
 }
@@ -33,6 +35,11 @@ func NewInfoBox() *InfoBox {
 
 // Update refreshes info box with current monitoring state.
 func (i *InfoBox) Update(current ui.CurrentValues, gauges ui.GaugeValues) {
+	i.UpdateWithStatus(current, gauges, "")
+}
+
+// UpdateWithStatus refreshes info box and appends data source status line if it's not empty.
+func (i *InfoBox) UpdateWithStatus(current ui.CurrentValues, gauges ui.GaugeValues, status string) {
 	i.Text = fmt.Sprintf(
 		"Last update: %s\n"+
 			"Max GRQ: %d\n"+
@@ -42,4 +49,17 @@ func (i *InfoBox) Update(current ui.CurrentValues, gauges ui.GaugeValues) {
 		gauges.GRQ.Max,
 		gauges.Goroutines.Max,
 	)
+	if status != "" {
+		i.Text += "\n" + status
+	}
+}
+
+// Warn puts a goroutine leak warning on top of the info box, nil leaves the box as is.
+// It's called after UpdateWithStatus.
+func (i *InfoBox) Warn(leak *ui.LeakValues) {
+	if leak == nil {
+		return
+	}
+	i.Text = fmt.Sprintf("[Goroutine leak? +%d in %s (%.1f/s, %.0f%% sure)](fg:red,mod:bold)\n",
+		leak.Growth, leak.Window.Round(time.Second), leak.Slope, leak.Confidence*100) + i.Text
 }
@@ -3,6 +3,7 @@ package widgets
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gizak/termui/v3"
 	"github.com/stretchr/testify/assert"
@@ -113,3 +114,35 @@ func TestInfoBox_UpdateConsistency(t *testing.T) {
 		assert.Equal(t, "Exit: press 'q'", lines[3])
 	}
 }
+
+func TestInfoBox_UpdateWithStatus(t *testing.T) {
+	info := NewInfoBox()
+	gaugeValues := ui.GaugeValues{
+		GRQ:        struct{ Current, Max int }{5, 10},
+		Goroutines: struct{ Current, Max int }{100, 200},
+	}
+
+	info.UpdateWithStatus(ui.CurrentValues{}, gaugeValues, "Replay 00:10/01:00 x2 paused")
+	lines := strings.Split(info.Text, "\n")
+	require.Equal(t, 5, len(lines), "Status should be added as a separate line")
+	assert.Equal(t, "Exit: press 'q'", lines[3])
+	assert.Equal(t, "Replay 00:10/01:00 x2 paused", lines[4])
+
+	info.UpdateWithStatus(ui.CurrentValues{}, gaugeValues, "")
+	assert.Equal(t, 4, len(strings.Split(info.Text, "\n")), "Empty status should not add a line")
+}
+
+func TestInfoBox_Warn(t *testing.T) {
+	info := NewInfoBox()
+	info.UpdateWithStatus(ui.CurrentValues{}, ui.GaugeValues{}, "")
+	text := info.Text
+
+	info.Warn(nil)
+	assert.Equal(t, text, info.Text, "No leak, no warning")
+
+	info.Warn(&ui.LeakValues{Slope: 10, Confidence: 0.93, Growth: 110, Window: 11200 * time.Millisecond})
+	lines := strings.Split(info.Text, "\n")
+	require.Equal(t, 5, len(lines))
+	assert.Equal(t, "[Goroutine leak? +110 in 11s (10.0/s, 93% sure)](fg:red,mod:bold)", lines[0], "Warning goes on top")
+	assert.Equal(t, text, strings.Join(lines[1:], "\n"))
+}
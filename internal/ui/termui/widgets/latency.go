@@ -0,0 +1,160 @@
+package widgets
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	tui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// heatRunes are drawn for a band holding a growing share of scheduled goroutines.
+var heatRunes = []rune("░▒▓█")
+
+// latencyLabelWidth is the width of band labels left of the heatmap.
+const latencyLabelWidth = 7
+
+// LatencyHeatmap shows how long runnable goroutines waited before they ran:
+// a column per sample, a row per latency band, darker cells hold more goroutines.
+type LatencyHeatmap struct {
+	tui.Block
+
+	points []ui.LatencyPoint
+}
+
+// NewLatencyHeatmap creates a new scheduling latency heatmap.
+func NewLatencyHeatmap() *LatencyHeatmap {
+	h := &LatencyHeatmap{Block: *tui.NewBlock()}
+	h.BorderStyle.Fg = tui.ColorCyan
+	h.Update(nil)
+	return h
+}
+
+// Update replaces displayed samples, nil means the program doesn't report latency.
+func (h *LatencyHeatmap) Update(latency *ui.LatencyValues) {
+	h.points = nil
+	if latency != nil {
+		h.points = latency.Points
+	}
+
+	if len(h.points) == 0 {
+		h.Title = "Scheduling Latency: no data (h to close)"
+		return
+	}
+	last := h.points[len(h.points)-1]
+	h.Title = fmt.Sprintf("Scheduling Latency p50 %s p90 %s p99 %s (h to close)",
+		formatLatency(last.P50), formatLatency(last.P90), formatLatency(last.P99))
+}
+
+// bandLabel returns the label of a latency band.
+func bandLabel(band int) string {
+	if band < len(ui.LatencyBounds) {
+		return "<" + formatLatency(ui.LatencyBounds[band])
+	}
+	return "≥" + formatLatency(ui.LatencyBounds[len(ui.LatencyBounds)-1])
+}
+
+// bandStyle colors bands by how bad the latency is.
+func bandStyle(band int) tui.Style {
+	if band >= len(ui.LatencyBounds) {
+		return tui.NewStyle(tui.ColorRed)
+	}
+	switch bound := ui.LatencyBounds[band]; {
+	case bound <= 100*time.Microsecond:
+		return tui.NewStyle(tui.ColorGreen)
+	case bound <= 10*time.Millisecond:
+		return tui.NewStyle(tui.ColorYellow)
+	}
+	return tui.NewStyle(tui.ColorRed)
+}
+
+// heatRune returns the cell rune for a band holding count of total goroutines.
+func heatRune(count, total uint64) rune {
+	if count == 0 || total == 0 {
+		return ' '
+	}
+	level := int(float64(count) / float64(total) * float64(len(heatRunes)))
+	return heatRunes[min(level, len(heatRunes)-1)]
+}
+
+// Draw draws band labels and a column per sample, the latest on the right.
+func (h *LatencyHeatmap) Draw(buf *tui.Buffer) {
+	h.Block.Draw(buf)
+
+	bands := len(ui.LatencyBounds) + 1
+	columns := max(h.Inner.Dx()-latencyLabelWidth, 0)
+	points := h.points
+	if len(points) > columns {
+		points = points[len(points)-columns:]
+	}
+
+	// Slowest band on top
+	for band := range bands {
+		y := h.Inner.Min.Y + bands - 1 - band
+		if y >= h.Inner.Max.Y {
+			continue
+		}
+		label := image.Rect(h.Inner.Min.X, y, min(h.Inner.Min.X+latencyLabelWidth-1, h.Inner.Max.X), y+1)
+		drawClipped(buf, label, bandLabel(band), tui.NewStyle(tui.ColorWhite), y)
+
+		for i, p := range points {
+			var total uint64
+			for _, n := range p.Bands {
+				total += n
+			}
+			if band >= len(p.Bands) {
+				continue
+			}
+			x := h.Inner.Min.X + latencyLabelWidth + i
+			buf.SetCell(tui.NewCell(heatRune(p.Bands[band], total), bandStyle(band)), image.Pt(x, y))
+		}
+	}
+}
+
+// LatencyPlot shows p50, p90 and p99 scheduling latency over time on a log scale.
+type LatencyPlot struct {
+	*widgets.Plot
+}
+
+// NewLatencyPlot creates a new scheduling latency percentiles plot.
+func NewLatencyPlot() *LatencyPlot {
+	p := &LatencyPlot{Plot: widgets.NewPlot()}
+	p.Title = "Latency p50/p90/p99 (log10 µs)"
+	p.DataLabels = []string{"p50", "p90", "p99"}
+	p.LineColors = []tui.Color{tui.ColorGreen, tui.ColorYellow, tui.ColorRed}
+	p.AxesColor = tui.ColorWhite
+	p.DrawDirection = widgets.DrawLeft
+	p.Update(nil)
+	return p
+}
+
+// Update updates plot with percentiles of every sample.
+func (p *LatencyPlot) Update(latency *ui.LatencyValues) {
+	if latency == nil || len(latency.Points) < 2 {
+		p.Data = [][]float64{{0, 0}, {0, 0}, {0, 0}}
+		return
+	}
+
+	p.Data = make([][]float64, 3)
+	for _, point := range latency.Points {
+		for i, d := range []time.Duration{point.P50, point.P90, point.P99} {
+			p.Data[i] = append(p.Data[i], toLogScale(float64(d)/float64(time.Microsecond)))
+		}
+	}
+}
+
+// formatLatency formats a duration with three significant digits.
+func formatLatency(d time.Duration) string {
+	switch {
+	case d < time.Microsecond:
+		return fmt.Sprintf("%dns", d.Nanoseconds())
+	case d < time.Millisecond:
+		return fmt.Sprintf("%.3gµs", float64(d)/float64(time.Microsecond))
+	case d < time.Second:
+		return fmt.Sprintf("%.3gms", float64(d)/float64(time.Millisecond))
+	}
+	return fmt.Sprintf("%.3gs", d.Seconds())
+}
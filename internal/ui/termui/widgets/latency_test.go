@@ -0,0 +1,57 @@
+package widgets
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	tui "github.com/gizak/termui/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+func TestLatencyHeatmap_Update(t *testing.T) {
+	heatmap := NewLatencyHeatmap()
+	assert.Contains(t, heatmap.Title, "no data")
+
+	bands := make([]uint64, len(ui.LatencyBounds)+1)
+	bands[1], bands[4] = 90, 10
+	heatmap.Update(&ui.LatencyValues{Points: []ui.LatencyPoint{
+		{P50: 5 * time.Microsecond, P90: 10 * time.Microsecond, P99: 1200 * time.Microsecond, Bands: bands},
+	}})
+	assert.Equal(t, "Scheduling Latency p50 5µs p90 10µs p99 1.2ms (h to close)", heatmap.Title)
+
+	heatmap.SetRect(0, 0, 30, 10)
+	buf := tui.NewBuffer(image.Rect(0, 0, 30, 10))
+	heatmap.Draw(buf)
+
+	// Slowest band is on top, bands are numbered from the fastest
+	bandRow := func(band int) int { return 1 + len(ui.LatencyBounds) - band }
+	x := 1 + latencyLabelWidth
+	assert.Equal(t, '█', buf.GetCell(image.Pt(x, bandRow(1))).Rune)
+	assert.Equal(t, '░', buf.GetCell(image.Pt(x, bandRow(4))).Rune)
+	assert.Equal(t, ' ', buf.GetCell(image.Pt(x, bandRow(0))).Rune)
+	assert.Equal(t, '≥', buf.GetCell(image.Pt(1, 1)).Rune)
+}
+
+func TestLatencyPlot_Update(t *testing.T) {
+	plot := NewLatencyPlot()
+	assert.Len(t, plot.Data, 3)
+
+	plot.Update(&ui.LatencyValues{Points: []ui.LatencyPoint{
+		{P50: time.Microsecond, P90: 10 * time.Microsecond, P99: 100 * time.Microsecond},
+		{P50: 10 * time.Microsecond, P90: 100 * time.Microsecond, P99: time.Millisecond},
+	}})
+	assert.Equal(t, []float64{0, 1}, plot.Data[0])
+	assert.Equal(t, []float64{2, 3}, plot.Data[2])
+}
+
+func TestFormatLatency(t *testing.T) {
+	assert.Equal(t, "500ns", formatLatency(500*time.Nanosecond))
+	assert.Equal(t, "12.5µs", formatLatency(12500*time.Nanosecond))
+	assert.Equal(t, "100ms", formatLatency(100*time.Millisecond))
+	assert.Equal(t, "1.5s", formatLatency(1500*time.Millisecond))
+	assert.Equal(t, "<1µs", bandLabel(0))
+	assert.Equal(t, "≥1s", bandLabel(len(ui.LatencyBounds)))
+}
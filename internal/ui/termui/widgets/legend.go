@@ -24,7 +24,10 @@ func NewPlotLegend() *PlotLegend {
 		"[-- [LRQ]](fg:magenta)\n" +
 		"[-- [THR]](fg:red)\n" +
 		"[-- [IDL]](fg:yellow)\n" +
-		"[-- [GRT]](fg:cyan)"
+		"[-- [GRT]](fg:cyan)\n" +
+		"[┊  [GC]](fg:blue)\n" +
+		"[│  [RST]](fg:white)\n" +
+		"[┃  [BLD]](fg:white)"
 
 	return l
 }
@@ -0,0 +1,245 @@
+package widgets
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	tui "github.com/gizak/termui/v3"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// LogPane displays output of the monitored program with scrollback and search.
+// Lines are drawn as plain text, so program output is never interpreted as termui markup.
+type LogPane struct {
+	tui.Block
+
+	lines  []ui.LogLine
+	offset int    // lines scrolled up from the newest one
+	anchor uint64 // Seq of the newest visible line while scrolled up
+
+	query     string // active search query
+	input     string // query being typed
+	searching bool
+	match     uint64 // Seq of the current match, 0 if none
+}
+
+// NewLogPane creates a new log pane.
+func NewLogPane() *LogPane {
+	l := &LogPane{Block: *tui.NewBlock()}
+	l.Title = "Output"
+	l.BorderStyle.Fg = tui.ColorWhite
+	return l
+}
+
+// Update replaces displayed lines. A scrolled view stays on the same lines
+// while new ones arrive.
+func (l *LogPane) Update(lines []ui.LogLine) {
+	l.lines = lines
+	if l.offset > 0 {
+		l.offset = len(lines) - 1 - l.indexOf(l.anchor)
+	}
+	l.setOffset(l.offset)
+}
+
+// ScrollUp moves the view n lines back.
+func (l *LogPane) ScrollUp(n int) {
+	l.setOffset(l.offset + n)
+}
+
+// ScrollDown moves the view n lines forward.
+func (l *LogPane) ScrollDown(n int) {
+	l.setOffset(l.offset - n)
+}
+
+// ScrollToEnd shows the newest lines and keeps following them.
+func (l *LogPane) ScrollToEnd() {
+	l.setOffset(0)
+}
+
+// PageSize returns the number of visible lines.
+func (l *LogPane) PageSize() int {
+	return max(l.Inner.Dy(), 1)
+}
+
+// Searching reports whether a search query is being typed.
+func (l *LogPane) Searching() bool {
+	return l.searching
+}
+
+// StartSearch begins typing a new search query.
+func (l *LogPane) StartSearch() {
+	l.searching = true
+	l.input = ""
+}
+
+// HandleSearchKey processes a key event while a query is typed.
+func (l *LogPane) HandleSearchKey(id string) {
+	switch id {
+	case "<Enter>":
+		l.searching = false
+		l.query = l.input
+		l.match = 0
+		if l.query != "" {
+			l.findMatch(len(l.lines), -1)
+		}
+	case "<Escape>":
+		l.searching = false
+	case "<Backspace>", "<C-<Backspace>>":
+		if r := []rune(l.input); len(r) > 0 {
+			l.input = string(r[:len(r)-1])
+		}
+	case "<Space>":
+		l.input += " "
+	default:
+		if len([]rune(id)) == 1 {
+			l.input += id
+		}
+	}
+}
+
+// Query returns the active search query.
+func (l *LogPane) Query() string {
+	return l.query
+}
+
+// ClearSearch removes the active query.
+func (l *LogPane) ClearSearch() {
+	l.query = ""
+	l.match = 0
+}
+
+// NextMatch jumps to the previous (older) line matching the query.
+func (l *LogPane) NextMatch() {
+	l.findMatch(l.matchIndex(), -1)
+}
+
+// PrevMatch jumps to the next (newer) line matching the query.
+func (l *LogPane) PrevMatch() {
+	l.findMatch(l.matchIndex(), 1)
+}
+
+// matchIndex returns the index to search from: the current match or the newest visible line.
+func (l *LogPane) matchIndex() int {
+	if l.match != 0 && len(l.lines) > 0 {
+		if i := l.indexOf(l.match); l.lines[i].Seq == l.match {
+			return i
+		}
+	}
+	return len(l.lines) - l.offset
+}
+
+// findMatch searches from index from in direction step and scrolls to the match.
+func (l *LogPane) findMatch(from, step int) {
+	if l.query == "" {
+		return
+	}
+	for i := from + step; i >= 0 && i < len(l.lines); i += step {
+		if l.matches(l.lines[i]) {
+			l.match = l.lines[i].Seq
+			l.setOffset(len(l.lines) - 1 - i)
+			return
+		}
+	}
+}
+
+// matches reports whether the line contains the query, ignoring case.
+func (l *LogPane) matches(line ui.LogLine) bool {
+	return l.query != "" && strings.Contains(strings.ToLower(line.Text), strings.ToLower(l.query))
+}
+
+// indexOf returns the index of the line with the given Seq,
+// or the oldest line if it was dropped.
+func (l *LogPane) indexOf(seq uint64) int {
+	for i := len(l.lines) - 1; i >= 0; i-- {
+		if l.lines[i].Seq <= seq {
+			return i
+		}
+	}
+	return 0
+}
+
+// setOffset clamps the scroll offset and remembers the anchor line.
+func (l *LogPane) setOffset(offset int) {
+	l.offset = min(max(offset, 0), max(len(l.lines)-1, 0))
+	l.anchor = 0
+	if l.offset > 0 {
+		l.anchor = l.lines[len(l.lines)-1-l.offset].Seq
+	}
+}
+
+// Draw implements termui.Drawable.
+func (l *LogPane) Draw(buf *tui.Buffer) {
+	l.Block.Title = l.title()
+	l.Block.Draw(buf)
+
+	rows := l.Inner.Dy()
+	if l.searching {
+		rows--
+		prompt := "/" + l.input + "_"
+		l.drawLine(buf, prompt, tui.NewStyle(tui.ColorYellow), l.Inner.Max.Y-1)
+	}
+	if rows <= 0 {
+		return
+	}
+
+	// Near the oldest line the view is filled from the top
+	end := max(len(l.lines)-l.offset, min(rows, len(l.lines)))
+	start := max(end-rows, 0)
+	for i, line := range l.lines[start:end] {
+		style := tui.NewStyle(tui.ColorWhite)
+		if line.Stream == "stderr" {
+			style = tui.NewStyle(tui.ColorRed)
+		}
+		if l.matches(line) {
+			style = tui.NewStyle(tui.ColorBlack, tui.ColorYellow)
+			if line.Seq == l.match {
+				style = tui.NewStyle(tui.ColorBlack, tui.ColorGreen)
+			}
+		}
+		l.drawLine(buf, formatLogLine(line), style, l.Inner.Min.Y+i)
+	}
+}
+
+// title describes scroll and search state.
+func (l *LogPane) title() string {
+	title := "Output"
+	if l.offset > 0 {
+		title += fmt.Sprintf(" [-%d, End to follow]", l.offset)
+	}
+	if l.query != "" {
+		title += fmt.Sprintf(" [/%s n/N]", l.query)
+	}
+	return title
+}
+
+// drawLine draws text in a single row, clipped to the pane.
+func (l *LogPane) drawLine(buf *tui.Buffer, text string, style tui.Style, y int) {
+	drawClipped(buf, l.Inner, text, style, y)
+}
+
+// drawClipped draws plain text in a single row of the area, clipped to its width.
+// Unlike Paragraph it never interprets text as termui markup.
+func drawClipped(buf *tui.Buffer, area image.Rectangle, text string, style tui.Style, y int) {
+	runes := []rune(text)
+	if width := max(area.Dx(), 0); len(runes) > width {
+		runes = runes[:width]
+	}
+	for i, r := range runes {
+		buf.SetCell(tui.NewCell(r, style), image.Pt(area.Min.X+i, y))
+	}
+}
+
+// formatLogLine formats a line with its receive time and stream.
+func formatLogLine(line ui.LogLine) string {
+	stream := "   "
+	switch line.Stream {
+	case "stdout":
+		stream = "out"
+	case "stderr":
+		stream = "err"
+	}
+	text := strings.ReplaceAll(line.Text, "\t", "    ")
+	return fmt.Sprintf("%s %s %s", line.Time.Format("15:04:05.000"), stream, text)
+}
@@ -0,0 +1,130 @@
+package widgets
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"testing"
+	"time"
+
+	tui "github.com/gizak/termui/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// makeLogLines creates lines with Seq from first to last.
+func makeLogLines(first, last int) []ui.LogLine {
+	var lines []ui.LogLine
+	for i := first; i <= last; i++ {
+		lines = append(lines, ui.LogLine{
+			Seq:    uint64(i),
+			Time:   time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+			Stream: "stdout",
+			Text:   fmt.Sprintf("line %d", i),
+		})
+	}
+	return lines
+}
+
+// rowText returns the text drawn in a row of the pane.
+func rowText(l *LogPane, buf *tui.Buffer, row int) string {
+	var b strings.Builder
+	for x := l.Inner.Min.X; x < l.Inner.Max.X; x++ {
+		b.WriteRune(buf.GetCell(image.Pt(x, l.Inner.Min.Y+row)).Rune)
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+func TestLogPane_Draw(t *testing.T) {
+	l := NewLogPane()
+	l.SetRect(0, 0, 60, 5) // three visible rows
+	l.Update([]ui.LogLine{
+		{Seq: 1, Time: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), Stream: "stderr", Text: "[red](fg:red)\tmarkup"},
+	})
+
+	buf := tui.NewBuffer(l.GetRect())
+	l.Draw(buf)
+	assert.Equal(t, "15:04:05.000 err [red](fg:red)    markup", rowText(l, buf, 0),
+		"Program output must be drawn verbatim")
+}
+
+func TestLogPane_Scroll(t *testing.T) {
+	l := NewLogPane()
+	l.SetRect(0, 0, 60, 5)
+	l.Update(makeLogLines(1, 10))
+
+	buf := tui.NewBuffer(l.GetRect())
+	l.Draw(buf)
+	assert.Contains(t, rowText(l, buf, 2), "line 10", "Newest line should be at the bottom")
+
+	l.ScrollUp(2)
+	// New lines don't move a scrolled view
+	l.Update(makeLogLines(3, 15))
+
+	buf = tui.NewBuffer(l.GetRect())
+	l.Draw(buf)
+	assert.Contains(t, rowText(l, buf, 2), "line 8")
+	assert.Contains(t, l.Title, "-7")
+
+	l.ScrollUp(100)
+	assert.Equal(t, 12, l.offset, "Scrolling should stop at the oldest line")
+
+	l.ScrollToEnd()
+	l.Update(makeLogLines(5, 20))
+	assert.Zero(t, l.offset, "View at the end should follow new lines")
+}
+
+func TestLogPane_Search(t *testing.T) {
+	l := NewLogPane()
+	l.SetRect(0, 0, 60, 5)
+	l.Update(makeLogLines(1, 30))
+
+	l.StartSearch()
+	require.True(t, l.Searching())
+	for _, key := range []string{"L", "i", "n", "e", "<Space>", "2", "x", "<C-<Backspace>>"} {
+		l.HandleSearchKey(key)
+	}
+	l.HandleSearchKey("<Enter>")
+	require.False(t, l.Searching())
+
+	// Case-insensitive, newest match first: "line 29"
+	assert.Equal(t, uint64(29), l.match)
+	l.NextMatch()
+	assert.Equal(t, uint64(28), l.match)
+	l.PrevMatch()
+	assert.Equal(t, uint64(29), l.match)
+
+	for i := 0; i < 20; i++ {
+		l.NextMatch()
+	}
+	assert.Equal(t, uint64(2), l.match, "Search should stop at the oldest match")
+
+	buf := tui.NewBuffer(l.GetRect())
+	l.Draw(buf)
+	assert.Contains(t, rowText(l, buf, 1), "line 2", "Match should be scrolled into view")
+
+	l.StartSearch()
+	l.HandleSearchKey("x")
+	l.HandleSearchKey("<Escape>")
+	assert.Equal(t, "Line 2", l.query, "Escape keeps the previous query")
+
+	l.ClearSearch()
+	l.NextMatch()
+	assert.Zero(t, l.match)
+}
+
+func TestLogPane_Empty(t *testing.T) {
+	l := NewLogPane()
+	l.SetRect(0, 0, 60, 5)
+	assert.NotPanics(t, func() {
+		l.ScrollUp(5)
+		l.StartSearch()
+		l.HandleSearchKey("a")
+		l.HandleSearchKey("<Enter>")
+		l.NextMatch()
+		l.PrevMatch()
+		l.Draw(tui.NewBuffer(l.GetRect()))
+	})
+}
@@ -2,6 +2,7 @@
 package widgets
 
 import (
+	"image"
 	"math"
 
 	tui "github.com/gizak/termui/v3"
@@ -10,9 +11,85 @@ import (
 	"github.com/JustSkiv/goschedviz/internal/ui"
 )
 
+// Plot layout constants matching termui Plot axes
+const (
+	plotAxisLabelsWidth  = 4
+	plotAxisLabelsHeight = 1
+)
+
+// Runes drawn for vertical event markers
+const (
+	markerRune  = '┊'
+	restartRune = '│'
+	rebuildRune = '┃'
+)
+
+// HistoryMarker is a vertical line drawn over the plot at a history point
+type HistoryMarker struct {
+	Index int // Index of the history point
+	Color tui.Color
+	Rune  rune // Line rune, markerRune if zero
+}
+
 // BaseHistoryPlot encapsulates common plot functionality
 type BaseHistoryPlot struct {
 	*widgets.Plot
+	Markers []HistoryMarker
+}
+
+// historyMarkers builds markers for notable events in history
+func historyMarkers(history []ui.HistoricalValues) []HistoryMarker {
+	var markers []HistoryMarker
+	for i, h := range history {
+		switch {
+		case h.Rebuilt:
+			markers = append(markers, HistoryMarker{Index: i, Color: tui.ColorWhite, Rune: rebuildRune})
+		case h.Restarted:
+			markers = append(markers, HistoryMarker{Index: i, Color: tui.ColorWhite, Rune: restartRune})
+		case h.GCCycles > 0:
+			markers = append(markers, HistoryMarker{Index: i, Color: tui.ColorBlue})
+		}
+	}
+	return markers
+}
+
+// Draw draws the plot with event markers in cells not occupied by lines
+func (p *BaseHistoryPlot) Draw(buf *tui.Buffer) {
+	p.Plot.Draw(buf)
+
+	area := p.Inner
+	if p.ShowAxes {
+		area = image.Rect(
+			p.Inner.Min.X+plotAxisLabelsWidth+1, p.Inner.Min.Y,
+			p.Inner.Max.X, p.Inner.Max.Y-plotAxisLabelsHeight-1,
+		)
+	}
+
+	for _, m := range p.Markers {
+		r := m.Rune
+		if r == 0 {
+			r = markerRune
+		}
+		x := area.Min.X + m.Index*p.HorizontalScale
+		if x >= area.Max.X {
+			continue
+		}
+		for y := area.Min.Y; y < area.Max.Y; y++ {
+			point := image.Pt(x, y)
+			if buf.GetCell(point).Rune == ' ' {
+				buf.SetCell(tui.NewCell(r, tui.NewStyle(m.Color)), point)
+			}
+		}
+	}
+}
+
+// historyTitle appends wall-clock times of the first and the last history point
+// to the plot title when they are known
+func historyTitle(title string, history []ui.HistoricalValues) string {
+	if len(history) == 0 || history[0].Time.IsZero() || history[len(history)-1].Time.IsZero() {
+		return title
+	}
+	return title + " " + history[0].Time.Format("15:04:05") + "-" + history[len(history)-1].Time.Format("15:04:05")
 }
 
 // newBasePlot creates a new base plot with common settings
@@ -68,6 +145,7 @@ func (p *LinearHistoryPlot) Update(history []ui.HistoricalValues) {
 		for i := 0; i < 5; i++ {
 			p.Data[i] = []float64{0, 0}
 		}
+		p.Markers = nil
 		return
 	}
 
@@ -91,6 +169,8 @@ func (p *LinearHistoryPlot) Update(history []ui.HistoricalValues) {
 	p.Data[2] = threadVals
 	p.Data[3] = idleProcVals
 	p.Data[4] = goroutineVals
+	p.Markers = historyMarkers(history)
+	p.Title = historyTitle("History Plot (linear)", history)
 }
 
 // LogHistoryPlot displays metrics using logarithmic scale
@@ -121,6 +201,7 @@ func (p *LogHistoryPlot) Update(history []ui.HistoricalValues) {
 		for i := 0; i < 5; i++ {
 			p.Data[i] = []float64{0, 0}
 		}
+		p.Markers = nil
 		return
 	}
 
@@ -144,4 +225,6 @@ func (p *LogHistoryPlot) Update(history []ui.HistoricalValues) {
 	p.Data[2] = threadVals
 	p.Data[3] = idleProcVals
 	p.Data[4] = goroutineVals
+	p.Markers = historyMarkers(history)
+	p.Title = historyTitle("History Plot (log)", history)
 }
@@ -1,8 +1,10 @@
 package widgets
 
 import (
+	"image"
 	"math"
 	"testing"
+	"time"
 
 	tui "github.com/gizak/termui/v3"
 	"github.com/stretchr/testify/assert"
@@ -141,3 +143,62 @@ func TestPlot_Update(t *testing.T) {
 		})
 	}
 }
+
+func TestPlot_Markers(t *testing.T) {
+	history := []ui.HistoricalValues{
+		{GRQ: 1, Goroutines: 10},
+		{GRQ: 1, Goroutines: 10, GCCycles: 1},
+		{GRQ: 1, Goroutines: 10},
+		{GRQ: 1, Goroutines: 10, GCCycles: 2},
+		{GRQ: 1, Goroutines: 10, GCCycles: 1, Restarted: true},
+		{GRQ: 1, Goroutines: 10, Rebuilt: true},
+	}
+
+	plot := NewLinearHistoryPlot()
+	plot.Update(history)
+	require.Equal(t, []HistoryMarker{
+		{Index: 1, Color: tui.ColorBlue},
+		{Index: 3, Color: tui.ColorBlue},
+		{Index: 4, Color: tui.ColorWhite, Rune: restartRune},
+		{Index: 5, Color: tui.ColorWhite, Rune: rebuildRune},
+	}, plot.Markers, "Restart marker takes precedence over GC")
+
+	plot.SetRect(0, 0, 30, 12)
+	buf := tui.NewBuffer(plot.GetRect())
+	plot.Draw(buf)
+
+	// Marker column is drawn where no plot line passes
+	x := plot.Inner.Min.X + plotAxisLabelsWidth + 1 + 3
+	column := func(x int) []rune {
+		var runes []rune
+		for y := plot.Inner.Min.Y; y < plot.Inner.Max.Y; y++ {
+			runes = append(runes, buf.GetCell(image.Pt(x, y)).Rune)
+		}
+		return runes
+	}
+	assert.Contains(t, column(x), markerRune)
+	assert.Contains(t, column(x+1), restartRune)
+
+	plot.Update(history[:1])
+	assert.Empty(t, plot.Markers, "Markers should be cleared with the data")
+}
+
+func TestHistoryPlot_TimeRange(t *testing.T) {
+	start := time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local)
+	history := []ui.HistoricalValues{
+		{Goroutines: 1, Time: start},
+		{Goroutines: 2, Time: start.Add(time.Minute)},
+	}
+
+	linear := NewLinearHistoryPlot()
+	linear.Update(history)
+	assert.Equal(t, "History Plot (linear) 15:04:05-15:05:05", linear.Title)
+
+	logPlot := NewLogHistoryPlot()
+	logPlot.Update(history)
+	assert.Equal(t, "History Plot (log) 15:04:05-15:05:05", logPlot.Title)
+
+	// Points without receive time keep the plain title
+	linear.Update([]ui.HistoricalValues{{Goroutines: 1}, {Goroutines: 2}})
+	assert.Equal(t, "History Plot (linear)", linear.Title)
+}
@@ -0,0 +1,180 @@
+package widgets
+
+import (
+	"fmt"
+	"image"
+	"slices"
+	"strings"
+
+	tui "github.com/gizak/termui/v3"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// sparkRunes are drawn for sparkline values from the lowest to the highest.
+var sparkRunes = []rune("▁▂▃▄▅▆▇█")
+
+// Column widths of the runtime metrics pane
+const (
+	metricNameWidth  = 40
+	metricValueWidth = 14
+)
+
+// MetricSeries is a runtime metric with its values over history.
+// Cumulative metrics, like CPU seconds or GC cycles, are converted to rates per second.
+type MetricSeries struct {
+	Name   string
+	Rate   bool
+	Values []float64
+}
+
+// RuntimeMetricsPane shows runtime/metrics values reported by the program
+// with a sparkline of their recent history.
+type RuntimeMetricsPane struct {
+	tui.Block
+
+	series []MetricSeries
+}
+
+// NewRuntimeMetricsPane creates a new runtime metrics pane.
+func NewRuntimeMetricsPane() *RuntimeMetricsPane {
+	m := &RuntimeMetricsPane{Block: *tui.NewBlock()}
+	m.Title = "Runtime Metrics (m to close)"
+	m.BorderStyle.Fg = tui.ColorCyan
+	return m
+}
+
+// Update rebuilds series from history.
+func (m *RuntimeMetricsPane) Update(history []ui.HistoricalValues) {
+	m.series = metricSeries(history)
+}
+
+// Series returns displayed series sorted by name.
+func (m *RuntimeMetricsPane) Series() []MetricSeries {
+	return m.series
+}
+
+// metricSeries collects every metric seen in history. Points without the metric are skipped,
+// rates between points across a restart are zero.
+func metricSeries(history []ui.HistoricalValues) []MetricSeries {
+	var names []string
+	for _, h := range history {
+		for name := range h.Metrics {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	series := make([]MetricSeries, len(names))
+	for i, name := range names {
+		s := MetricSeries{Name: name, Rate: cumulativeMetric(name)}
+		var prev *ui.HistoricalValues
+		for j := range history {
+			h := &history[j]
+			v, ok := h.Metrics[name]
+			if !ok {
+				continue
+			}
+			if !s.Rate {
+				s.Values = append(s.Values, v)
+				continue
+			}
+			if prev != nil {
+				rate := 0.0
+				if dt := float64(h.TimeMs-prev.TimeMs) / 1000; dt > 0 && v >= prev.Metrics[name] {
+					rate = (v - prev.Metrics[name]) / dt
+				}
+				s.Values = append(s.Values, rate)
+			}
+			prev = h
+		}
+		series[i] = s
+	}
+	return series
+}
+
+// cumulativeMetric reports whether a runtime/metrics value only grows over time.
+// Memory totals are sizes, they go up and down.
+func cumulativeMetric(name string) bool {
+	if strings.HasSuffix(name, ":bytes") {
+		return false
+	}
+	return strings.HasPrefix(name, "/cpu/classes/") ||
+		strings.HasPrefix(name, "/gc/cycles/") ||
+		strings.Contains(name, "/total:") ||
+		strings.HasSuffix(name, ":seconds")
+}
+
+// formatMetric formats the latest value of a series with its unit.
+func formatMetric(s MetricSeries) string {
+	if len(s.Values) == 0 {
+		return "-"
+	}
+	v := s.Values[len(s.Values)-1]
+
+	_, unit, _ := strings.Cut(s.Name, ":")
+	switch {
+	case unit == "bytes":
+		return fmt.Sprintf("%.1f MB", v/(1<<20))
+	case s.Rate && unit == "cpu-seconds":
+		return fmt.Sprintf("%.2f cores", v)
+	case s.Rate && unit == "seconds":
+		return fmt.Sprintf("%.3f s/s", v)
+	case s.Rate:
+		return fmt.Sprintf("%.1f/s", v)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+// sparkline renders the last width values scaled between their minimum and maximum.
+func sparkline(values []float64, width int) string {
+	if width <= 0 || len(values) == 0 {
+		return ""
+	}
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+
+	lo, hi := slices.Min(values), slices.Max(values)
+	runes := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(sparkRunes)-1))
+		}
+		runes[i] = sparkRunes[level]
+	}
+	return string(runes)
+}
+
+// Draw draws a row per metric: name, latest value and sparkline.
+func (m *RuntimeMetricsPane) Draw(buf *tui.Buffer) {
+	m.Block.Draw(buf)
+
+	if len(m.series) == 0 {
+		drawClipped(buf, m.Inner, "No runtime metrics, use metrics.Reporter or -url with metrics.Handler",
+			tui.NewStyle(tui.ColorWhite), m.Inner.Min.Y)
+		return
+	}
+
+	x := m.Inner.Min.X
+	for i, s := range m.series {
+		y := m.Inner.Min.Y + i
+		if y >= m.Inner.Max.Y {
+			return
+		}
+		column := func(from, to int) image.Rectangle {
+			return image.Rect(min(x+from, m.Inner.Max.X), y, min(x+to, m.Inner.Max.X), y+1)
+		}
+		drawClipped(buf, column(0, metricNameWidth-1), s.Name, tui.NewStyle(tui.ColorWhite), y)
+
+		value := formatMetric(s)
+		drawClipped(buf, column(metricNameWidth+metricValueWidth-1-len(value), metricNameWidth+metricValueWidth),
+			value, tui.NewStyle(tui.ColorYellow), y)
+
+		spark := column(metricNameWidth+metricValueWidth+1, m.Inner.Dx())
+		drawClipped(buf, spark, sparkline(s.Values, spark.Dx()), tui.NewStyle(tui.ColorCyan), y)
+	}
+}
@@ -0,0 +1,66 @@
+package widgets
+
+import (
+	"image"
+	"testing"
+
+	tui "github.com/gizak/termui/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+func TestRuntimeMetricsPane_Update(t *testing.T) {
+	pane := NewRuntimeMetricsPane()
+	pane.Update([]ui.HistoricalValues{
+		{TimeMs: 1000},
+		{TimeMs: 2000, Metrics: map[string]float64{
+			"/cpu/classes/gc/total:cpu-seconds": 1,
+			"/gc/heap/live:bytes":               2 << 20,
+		}},
+		{TimeMs: 3000, Metrics: map[string]float64{
+			"/cpu/classes/gc/total:cpu-seconds": 1.5,
+			"/gc/heap/live:bytes":               3 << 20,
+		}},
+		// Restarted program starts counting from zero
+		{TimeMs: 500, Metrics: map[string]float64{
+			"/cpu/classes/gc/total:cpu-seconds": 0.1,
+			"/gc/heap/live:bytes":               1 << 20,
+		}},
+	})
+
+	series := pane.Series()
+	require.Len(t, series, 2)
+
+	assert.Equal(t, "/cpu/classes/gc/total:cpu-seconds", series[0].Name)
+	assert.True(t, series[0].Rate, "CPU time is cumulative")
+	assert.Equal(t, []float64{0.5, 0}, series[0].Values)
+	assert.Equal(t, "0.00 cores", formatMetric(series[0]))
+
+	assert.Equal(t, "/gc/heap/live:bytes", series[1].Name)
+	assert.False(t, series[1].Rate)
+	assert.Equal(t, []float64{2 << 20, 3 << 20, 1 << 20}, series[1].Values)
+	assert.Equal(t, "1.0 MB", formatMetric(series[1]))
+
+	// Narrow panes must not panic
+	pane.SetRect(0, 0, 30, 5)
+	buf := tui.NewBuffer(image.Rect(0, 0, 30, 5))
+	pane.Draw(buf)
+	assert.Equal(t, '/', buf.GetCell(image.Pt(1, 1)).Rune)
+}
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▄█", sparkline([]float64{0, 5, 10}, 10))
+	assert.Equal(t, "▁█", sparkline([]float64{0, 5, 10}, 2), "Only the latest values fit")
+	assert.Equal(t, "▁▁", sparkline([]float64{3, 3}, 10))
+	assert.Empty(t, sparkline(nil, 10))
+}
+
+func TestCumulativeMetric(t *testing.T) {
+	assert.True(t, cumulativeMetric("/gc/cycles/total:gc-cycles"))
+	assert.True(t, cumulativeMetric("/sync/mutex/wait/total:seconds"))
+	assert.True(t, cumulativeMetric("/cpu/classes/user:cpu-seconds"))
+	assert.False(t, cumulativeMetric("/sched/goroutines:goroutines"))
+	assert.False(t, cumulativeMetric("/memory/classes/total:bytes"), "Memory totals go up and down")
+}
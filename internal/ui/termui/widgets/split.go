@@ -0,0 +1,45 @@
+package widgets
+
+import (
+	"fmt"
+	"time"
+
+	tui "github.com/gizak/termui/v3"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+// SourcePlot shows history of a single source in the split view.
+// All source plots cover the same time window, so their points line up.
+type SourcePlot struct {
+	*LinearHistoryPlot
+}
+
+// NewSourcePlot creates a new source plot.
+func NewSourcePlot() *SourcePlot {
+	return &SourcePlot{
+		LinearHistoryPlot: NewLinearHistoryPlot(),
+	}
+}
+
+// Update refreshes the plot with source history. The active source is highlighted.
+func (p *SourcePlot) Update(source ui.SourceHistory, window time.Duration, active bool) {
+	p.LinearHistoryPlot.Update(source.History)
+
+	p.Title = fmt.Sprintf("%s | Gs: %d GRQ: %d LRQ: %d | last %s",
+		source.Name,
+		source.Current.Goroutines,
+		source.Current.RunQueue,
+		source.Current.LRQSum,
+		window.Round(time.Second),
+	)
+	if source.Exited {
+		p.Title += " | exited"
+	}
+
+	p.BorderStyle.Fg = tui.ColorWhite
+	if active {
+		p.BorderStyle.Fg = tui.ColorCyan
+	}
+	p.TitleStyle.Fg = p.BorderStyle.Fg
+}
@@ -0,0 +1,31 @@
+package widgets
+
+import (
+	"testing"
+	"time"
+
+	tui "github.com/gizak/termui/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/JustSkiv/goschedviz/internal/ui"
+)
+
+func TestSourcePlot_Update(t *testing.T) {
+	plot := NewSourcePlot()
+	source := ui.SourceHistory{
+		Name:    "consumer",
+		Current: ui.CurrentValues{Goroutines: 42, RunQueue: 3, LRQSum: 7},
+		History: []ui.HistoricalValues{{Goroutines: 40}, {Goroutines: 42, GCCycles: 1}},
+	}
+
+	plot.Update(source, 59600*time.Millisecond, true)
+	assert.Equal(t, "consumer | Gs: 42 GRQ: 3 LRQ: 7 | last 1m0s", plot.Title)
+	assert.Equal(t, tui.ColorCyan, plot.BorderStyle.Fg)
+	assert.Equal(t, []float64{40, 42}, plot.Data[4])
+	assert.Equal(t, []HistoryMarker{{Index: 1, Color: tui.ColorBlue}}, plot.Markers)
+
+	source.Exited = true
+	plot.Update(source, 10*time.Second, false)
+	assert.Equal(t, "consumer | Gs: 42 GRQ: 3 LRQ: 7 | last 10s | exited", plot.Title)
+	assert.Equal(t, tui.ColorWhite, plot.BorderStyle.Fg)
+}
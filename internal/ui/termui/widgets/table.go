@@ -1,6 +1,7 @@
 package widgets
 
 import (
+	"fmt"
 	"strconv"
 
 	tui "github.com/gizak/termui/v3"
@@ -55,4 +56,7 @@ func (t *TableWidget) Update(data ui.CurrentValues) {
 		{"LRQ (sum)", strconv.Itoa(data.LRQSum)},
 		{"Number of P", strconv.Itoa(data.NumP)},
 	}
+	if !data.Received.IsZero() {
+		t.Rows = append(t.Rows, []string{"Received", fmt.Sprintf("%s #%d", data.Received.Format("15:04:05.000"), data.Seq)})
+	}
 }
@@ -2,6 +2,7 @@ package widgets
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -154,3 +155,14 @@ func TestTableWidget_UpdateDataConsistency(t *testing.T) {
 			"Each row should have exactly 2 columns")
 	}
 }
+
+func TestTableWidget_Received(t *testing.T) {
+	table := NewTableWidget()
+	table.Update(ui.CurrentValues{
+		GoMaxProcs: 1,
+		Received:   time.Date(2025, 1, 2, 15, 4, 5, 120*int(time.Millisecond), time.Local),
+		Seq:        42,
+	})
+
+	assert.Equal(t, []string{"Received", "15:04:05.120 #42"}, table.Rows[len(table.Rows)-1])
+}
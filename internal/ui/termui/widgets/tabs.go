@@ -0,0 +1,36 @@
+package widgets
+
+import (
+	"fmt"
+
+	tui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// SourceTabsHeight is the number of terminal rows taken by the tab bar.
+const SourceTabsHeight = 3
+
+// SourceTabs shows names of monitored sources and highlights the one on screen.
+type SourceTabs struct {
+	*widgets.TabPane
+}
+
+// NewSourceTabs creates a new tab bar.
+func NewSourceTabs() *SourceTabs {
+	t := &SourceTabs{
+		TabPane: widgets.NewTabPane(),
+	}
+	t.Title = "Sources (Tab or 1-9 to switch, s to split)"
+	t.BorderStyle.Fg = tui.ColorCyan
+	t.ActiveTabStyle = tui.NewStyle(tui.ColorBlack, tui.ColorCyan)
+	return t
+}
+
+// Update sets source names and the active one. Names are numbered for quick switching.
+func (t *SourceTabs) Update(names []string, active int) {
+	t.TabNames = make([]string, len(names))
+	for i, name := range names {
+		t.TabNames[i] = fmt.Sprintf("%d:%s", i+1, name)
+	}
+	t.ActiveTabIndex = active
+}
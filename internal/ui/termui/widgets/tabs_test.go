@@ -0,0 +1,15 @@
+package widgets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceTabs_Update(t *testing.T) {
+	tabs := NewSourceTabs()
+	tabs.Update([]string{"load-1:7070", "load-2:7070"}, 1)
+
+	assert.Equal(t, []string{"1:load-1:7070", "2:load-2:7070"}, tabs.TabNames)
+	assert.Equal(t, 1, tabs.ActiveTabIndex)
+}
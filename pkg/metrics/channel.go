@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChannelEnv is the environment variable goschedviz uses to pass a side channel
+// for metrics lines to the monitored program, so they don't mix with its stderr:
+//
+//	GOSCHEDVIZ_METRICS=fd:3              inherited file descriptor (pipe or socket)
+//	GOSCHEDVIZ_METRICS=unix:/tmp/m.sock  Unix socket goschedviz listens on
+//
+// NewReporter reads the variable and removes it from the environment, so child
+// processes started afterwards don't write to a descriptor that isn't theirs.
+const ChannelEnv = "GOSCHEDVIZ_METRICS"
+
+// dialTimeout limits connecting to a Unix socket.
+const dialTimeout = time.Second
+
+// defaultChannel is shared by all reporters of the process.
+var defaultChannel = &sideChannel{}
+
+// sideChannel writes metrics lines to the channel from ChannelEnv,
+// or to stderr when no channel is configured or it failed.
+type sideChannel struct {
+	once sync.Once
+	mu   sync.Mutex
+	w    io.WriteCloser // nil means stderr
+}
+
+// open connects the channel described by ChannelEnv.
+// A missing or broken channel leaves stderr as output.
+func (c *sideChannel) open() {
+	spec, ok := os.LookupEnv(ChannelEnv)
+	if !ok {
+		return
+	}
+	os.Unsetenv(ChannelEnv)
+
+	if w, err := openChannel(spec); err == nil {
+		c.w = w
+	}
+}
+
+// init opens the channel once.
+func (c *sideChannel) init() {
+	c.once.Do(c.open)
+}
+
+// writeLine writes a single metrics line.
+func (c *sideChannel) writeLine(line string) {
+	c.init()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.w != nil {
+		if _, err := io.WriteString(c.w, line); err == nil {
+			return
+		}
+		// goschedviz went away, keep reporting to stderr
+		c.w.Close()
+		c.w = nil
+	}
+	io.WriteString(os.Stderr, line)
+}
+
+// openChannel opens a channel described as "fd:N" or "unix:PATH".
+func openChannel(spec string) (io.WriteCloser, error) {
+	kind, addr, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "fd":
+		fd, err := strconv.Atoi(addr)
+		if err != nil || fd <= 2 {
+			return nil, fmt.Errorf("invalid file descriptor %q", addr)
+		}
+		f := os.NewFile(uintptr(fd), "goschedviz-metrics")
+		if f == nil {
+			return nil, fmt.Errorf("invalid file descriptor %d", fd)
+		}
+		// Only pipes and sockets are accepted, a regular file at this number
+		// means the descriptor wasn't passed by goschedviz
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if info.Mode()&(os.ModeNamedPipe|os.ModeSocket) == 0 {
+			return nil, fmt.Errorf("file descriptor %d is not a pipe or socket", fd)
+		}
+		return f, nil
+	case "unix":
+		if addr == "" {
+			return nil, fmt.Errorf("empty socket path")
+		}
+		return net.DialTimeout("unix", addr, dialTimeout)
+	}
+	return nil, fmt.Errorf("unsupported metrics channel %q", spec)
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureStderr redirects stderr while fn runs and returns what was written.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	old := os.Stderr
+	os.Stderr = w
+	fn()
+	os.Stderr = old
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestSideChannel_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("Unix sockets are not supported: %v", err)
+	}
+	defer ln.Close()
+
+	t.Setenv(ChannelEnv, "unix:"+path)
+	c := &sideChannel{}
+	c.writeLine(prefix + " num_goroutines=3\n")
+
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, prefix+" num_goroutines=3\n", line)
+}
+
+func TestSideChannel_Fallback(t *testing.T) {
+	t.Run("no channel", func(t *testing.T) {
+		c := &sideChannel{}
+		stderr := captureStderr(t, func() { c.writeLine("line\n") })
+		assert.Equal(t, "line\n", stderr)
+	})
+
+	t.Run("invalid channel", func(t *testing.T) {
+		t.Setenv(ChannelEnv, "tcp:localhost:1")
+		c := &sideChannel{}
+		stderr := captureStderr(t, func() { c.writeLine("line\n") })
+		assert.Equal(t, "line\n", stderr)
+	})
+
+	t.Run("reader gone", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		r.Close()
+
+		c := &sideChannel{w: w}
+		c.once.Do(func() {})
+		stderr := captureStderr(t, func() {
+			c.writeLine("first\n")
+			c.writeLine("second\n")
+		})
+		assert.Equal(t, "first\nsecond\n", stderr, "Lines should go to stderr once the channel breaks")
+	})
+}
+
+func TestOpenChannel_Errors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"fd:",
+		"fd:2",
+		"fd:abc",
+		"unix:",
+		"unix:" + filepath.Join(t.TempDir(), "missing.sock"),
+		"udp:localhost:9",
+	} {
+		_, err := openChannel(spec)
+		assert.Error(t, err, "Spec %q should be rejected", spec)
+	}
+}
@@ -0,0 +1,59 @@
+//go:build unix
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dupFd duplicates the descriptor of f, so the copy is owned only by the code under test.
+func dupFd(t *testing.T, f *os.File) int {
+	t.Helper()
+	fd, err := syscall.Dup(int(f.Fd()))
+	require.NoError(t, err)
+	return fd
+}
+
+func TestSideChannel_FileDescriptor(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+
+	t.Setenv(ChannelEnv, fmt.Sprintf("fd:%d", dupFd(t, w)))
+	w.Close()
+
+	c := &sideChannel{}
+	defer func() {
+		if c.w != nil {
+			c.w.Close()
+		}
+	}()
+
+	stderr := captureStderr(t, func() {
+		c.writeLine(prefix + " num_goroutines=7\n")
+	})
+	assert.Empty(t, stderr, "Stderr must stay clean")
+
+	_, ok := os.LookupEnv(ChannelEnv)
+	assert.False(t, ok, "Channel variable should not leak to child processes")
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, prefix+" num_goroutines=7\n", line)
+}
+
+func TestOpenChannel_RegularFile(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "regular")
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = openChannel(fmt.Sprintf("fd:%d", dupFd(t, f)))
+	assert.ErrorContains(t, err, "not a pipe or socket")
+}
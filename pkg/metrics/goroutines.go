@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"runtime/pprof"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GoroutineStates lists states goroutines are counted by, in the order they are reported.
+// Waits on sync.Mutex, sync.RWMutex, sync.WaitGroup and sync.Cond are counted as semacquire,
+// states not listed here as other.
+var GoroutineStates = []string{
+	"running",
+	"runnable",
+	"io_wait",
+	"chan_receive",
+	"chan_send",
+	"select",
+	"syscall",
+	"sleep",
+	"semacquire",
+	"other",
+}
+
+// topSites is the number of creation sites reported with the most goroutines.
+const topSites = 5
+
+// goroutineSite is a function that created goroutines.
+type goroutineSite struct {
+	function string
+	count    int
+}
+
+// goroutineSummary counts goroutines by state and creation site.
+type goroutineSummary struct {
+	states map[string]int
+	sites  []goroutineSite // Most goroutines first
+}
+
+// WithGoroutineProfile makes the reporter summarize the goroutine profile every interval:
+// goroutines are counted by state and by the function that created them.
+// Taking the profile stops the world for a time proportional to the number
+// of goroutines, so keep the interval well above the reporting one for large programs.
+// The latest summary is written with every line:
+//
+//	PROCMETR num_goroutines=1004 goroutine_states=running:1,runnable:3,chan_receive:1000 goroutine_sites=main.worker:1000
+func WithGoroutineProfile(interval time.Duration) ReporterOption {
+	return func(r *Reporter) {
+		r.profileInterval = interval
+	}
+}
+
+// profileGoroutines summarizes stacks of all goroutines.
+func profileGoroutines() goroutineSummary {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		return goroutineSummary{}
+	}
+	return summarizeGoroutines(buf.Bytes())
+}
+
+// summarizeGoroutines parses a goroutine stack dump in the runtime.Stack format:
+//
+//	goroutine 7 [chan receive, 2 minutes]:
+//	main.worker()
+//		/app/main.go:12 +0x25
+//	created by main.main in goroutine 1
+//		/app/main.go:20 +0x4f
+func summarizeGoroutines(dump []byte) goroutineSummary {
+	summary := goroutineSummary{states: make(map[string]int)}
+	sites := make(map[string]int)
+
+	scanner := bufio.NewScanner(bytes.NewReader(dump))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header, ok := strings.CutPrefix(line, "goroutine "); ok {
+			_, status, found := strings.Cut(header, " [")
+			if !found {
+				continue
+			}
+			status, _, _ = strings.Cut(status, "]")
+			summary.states[goroutineState(status)]++
+			continue
+		}
+		if site, ok := strings.CutPrefix(line, "created by "); ok {
+			site, _, _ = strings.Cut(site, " in goroutine ")
+			sites[site]++
+		}
+	}
+
+	for function, count := range sites {
+		summary.sites = append(summary.sites, goroutineSite{function: function, count: count})
+	}
+	slices.SortFunc(summary.sites, func(a, b goroutineSite) int {
+		if c := cmp.Compare(b.count, a.count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.function, b.function)
+	})
+	if len(summary.sites) > topSites {
+		summary.sites = summary.sites[:topSites]
+	}
+	return summary
+}
+
+// goroutineState maps a goroutine status of a stack dump, e.g. "chan receive, 5 minutes"
+// or "select (no cases)", to one of GoroutineStates.
+func goroutineState(status string) string {
+	status, _, _ = strings.Cut(status, ",")
+	status, _, _ = strings.Cut(status, " (")
+	switch {
+	case status == "running", status == "runnable", status == "select",
+		status == "syscall", status == "sleep", status == "semacquire":
+		return status
+	case status == "IO wait":
+		return "io_wait"
+	case status == "chan receive":
+		return "chan_receive"
+	case status == "chan send":
+		return "chan_send"
+	case strings.HasPrefix(status, "sync."):
+		return "semacquire"
+	}
+	return "other"
+}
+
+// fields formats the summary as goroutine_states and goroutine_sites fields of a line.
+// States without goroutines are left out.
+func (s goroutineSummary) fields() string {
+	var states, sites []string
+	for _, state := range GoroutineStates {
+		if n := s.states[state]; n > 0 {
+			states = append(states, state+":"+strconv.Itoa(n))
+		}
+	}
+	for _, site := range s.sites {
+		sites = append(sites, site.function+":"+strconv.Itoa(site.count))
+	}
+
+	var b strings.Builder
+	if len(states) > 0 {
+		b.WriteString(" goroutine_states=" + strings.Join(states, ","))
+	}
+	if len(sites) > 0 {
+		b.WriteString(" goroutine_sites=" + strings.Join(sites, ","))
+	}
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const stackDump = `goroutine 1 [running]:
+main.main()
+	/app/main.go:30 +0x1d
+
+goroutine 7 [chan receive, 2 minutes]:
+main.worker()
+	/app/main.go:12 +0x25
+created by main.main in goroutine 1
+	/app/main.go:20 +0x4f
+
+goroutine 8 [chan receive (nil chan)]:
+main.worker()
+	/app/main.go:12 +0x25
+created by main.main in goroutine 1
+	/app/main.go:20 +0x4f
+
+goroutine 9 [sync.WaitGroup.Wait]:
+sync.(*WaitGroup).Wait(0xc000012345)
+	/usr/local/go/src/sync/waitgroup.go:118 +0x60
+created by main.startPool
+	/app/pool.go:8 +0x3a
+
+goroutine 10 [select (no cases)]:
+main.idle()
+	/app/main.go:40 +0x18
+created by main.startPool
+	/app/pool.go:9 +0x3a
+
+goroutine 11 [IO wait]:
+internal/poll.runtime_pollWait(0x7f, 0x72)
+	/usr/local/go/src/runtime/netpoll.go:351 +0x85
+created by net/http.(*Server).Serve in goroutine 1
+	/usr/local/go/src/net/http/server.go:3454 +0x485
+
+goroutine 12 [GC worker (idle)]:
+runtime.gcBgMarkWorker(0xc000076000)
+	/usr/local/go/src/runtime/mgc.go:1423 +0xe9
+created by runtime.gcBgMarkStartWorkers in goroutine 1
+	/usr/local/go/src/runtime/mgc.go:1339 +0x105
+`
+
+func TestSummarizeGoroutines(t *testing.T) {
+	summary := summarizeGoroutines([]byte(stackDump))
+
+	assert.Equal(t, map[string]int{
+		"running":      1,
+		"chan_receive": 2,
+		"semacquire":   1,
+		"select":       1,
+		"io_wait":      1,
+		"other":        1,
+	}, summary.states)
+	assert.Equal(t, []goroutineSite{
+		{function: "main.main", count: 2},
+		{function: "main.startPool", count: 2},
+		{function: "net/http.(*Server).Serve", count: 1},
+		{function: "runtime.gcBgMarkStartWorkers", count: 1},
+	}, summary.sites, "Sites are sorted by count, then by name")
+
+	assert.Equal(t,
+		" goroutine_states=running:1,io_wait:1,chan_receive:2,select:1,semacquire:1,other:1"+
+			" goroutine_sites=main.main:2,main.startPool:2,net/http.(*Server).Serve:1,runtime.gcBgMarkStartWorkers:1",
+		summary.fields())
+	assert.Empty(t, goroutineSummary{}.fields())
+}
+
+func TestGoroutineState(t *testing.T) {
+	for status, state := range map[string]string{
+		"runnable":                  "runnable",
+		"syscall, locked to thread": "syscall",
+		"sleep, 5 minutes":          "sleep",
+		"semacquire":                "semacquire",
+		"sync.Mutex.Lock":           "semacquire",
+		"sync.Cond.Wait":            "semacquire",
+		"chan send":                 "chan_send",
+		"select":                    "select",
+		"finalizer wait":            "other",
+	} {
+		assert.Equal(t, state, goroutineState(status), status)
+	}
+}
+
+func TestReporter_GoroutineProfile(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	for range 3 {
+		go func() { <-block }()
+	}
+
+	line := NewReporter(time.Second, WithMetrics(), WithGoroutineProfile(time.Minute)).line()
+
+	fields := strings.Fields(line)
+	require.Len(t, fields, 4)
+	assert.True(t, strings.HasPrefix(fields[2], "goroutine_states="), fields[2])
+	assert.Contains(t, fields[2], "running:1")
+	assert.True(t, strings.HasPrefix(fields[3], "goroutine_sites="), fields[3])
+	assert.Contains(t, fields[3], "TestReporter_GoroutineProfile")
+
+	line = NewReporter(time.Second, WithMetrics()).line()
+	assert.NotContains(t, line, "goroutine_states", "Profile is opt-in")
+}
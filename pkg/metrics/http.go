@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	rtmetrics "runtime/metrics"
+	"sync"
+	"time"
+)
+
+// DefaultPath is the conventional path to mount Handler on.
+const DefaultPath = "/debug/goschedviz"
+
+// processStart approximates the process start time, so uptime is comparable
+// with schedtrace timestamps.
+var processStart = time.Now()
+
+// Snapshot is a point-in-time copy of runtime/metrics served by Handler.
+// Metrics are keyed by their full runtime/metrics name, e.g. "/sched/goroutines:goroutines".
+type Snapshot struct {
+	Time       time.Time            `json:"time"`
+	UptimeMs   int64                `json:"uptime_ms"`
+	Metrics    map[string]float64   `json:"metrics"`
+	Histograms map[string]Histogram `json:"histograms,omitempty"`
+}
+
+// Histogram is a runtime/metrics histogram.
+// Buckets holds len(Counts)+1 boundaries; infinite boundaries are
+// replaced with ±math.MaxFloat64 because JSON can't represent them.
+type Histogram struct {
+	Counts  []uint64  `json:"counts"`
+	Buckets []float64 `json:"buckets"`
+}
+
+// sampler reads all metrics supported by the running Go version.
+type sampler struct {
+	mu      sync.Mutex
+	samples []rtmetrics.Sample
+}
+
+func newSampler() *sampler {
+	descs := rtmetrics.All()
+	samples := make([]rtmetrics.Sample, len(descs))
+	for i, d := range descs {
+		samples[i].Name = d.Name
+	}
+	return &sampler{samples: samples}
+}
+
+// read takes a new snapshot of all metrics.
+func (s *sampler) read() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	rtmetrics.Read(s.samples)
+
+	snapshot := Snapshot{
+		Time:       now,
+		UptimeMs:   now.Sub(processStart).Milliseconds(),
+		Metrics:    make(map[string]float64, len(s.samples)),
+		Histograms: make(map[string]Histogram),
+	}
+
+	for _, sample := range s.samples {
+		switch sample.Value.Kind() {
+		case rtmetrics.KindUint64:
+			snapshot.Metrics[sample.Name] = float64(sample.Value.Uint64())
+		case rtmetrics.KindFloat64:
+			snapshot.Metrics[sample.Name] = sample.Value.Float64()
+		case rtmetrics.KindFloat64Histogram:
+			h := sample.Value.Float64Histogram()
+			buckets := make([]float64, len(h.Buckets))
+			for i, b := range h.Buckets {
+				buckets[i] = finite(b)
+			}
+			snapshot.Histograms[sample.Name] = Histogram{
+				Counts:  append([]uint64(nil), h.Counts...),
+				Buckets: buckets,
+			}
+		}
+	}
+
+	return snapshot
+}
+
+// finite clamps infinite values to the largest float64.
+func finite(v float64) float64 {
+	switch {
+	case math.IsInf(v, 1):
+		return math.MaxFloat64
+	case math.IsInf(v, -1):
+		return -math.MaxFloat64
+	}
+	return v
+}
+
+// Handler returns an HTTP handler that serves a JSON Snapshot of runtime/metrics
+// on every GET request. It lets goschedviz watch an already running service:
+//
+//	http.Handle(metrics.DefaultPath, metrics.Handler())
+//
+// and then run goschedviz -url=http://host:port/debug/goschedviz.
+func Handler() http.Handler {
+	s := newSampler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if r.Method == http.MethodHead {
+			return
+		}
+		json.NewEncoder(w).Encode(s.read())
+	})
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var snapshot Snapshot
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&snapshot))
+
+	assert.False(t, snapshot.Time.IsZero())
+	assert.Positive(t, snapshot.UptimeMs)
+	assert.Equal(t, float64(runtime.GOMAXPROCS(0)), snapshot.Metrics["/sched/gomaxprocs:threads"])
+	assert.Positive(t, snapshot.Metrics["/sched/goroutines:goroutines"])
+
+	latencies, ok := snapshot.Histograms["/sched/latencies:seconds"]
+	require.True(t, ok, "Histograms should be served")
+	assert.Len(t, latencies.Buckets, len(latencies.Counts)+1)
+}
+
+func TestHandler_Method(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, DefaultPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestFinite(t *testing.T) {
+	assert.Equal(t, math.MaxFloat64, finite(math.Inf(1)))
+	assert.Equal(t, -math.MaxFloat64, finite(math.Inf(-1)))
+	assert.Equal(t, 1.5, finite(1.5))
+}
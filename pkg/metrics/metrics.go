@@ -1,17 +1,30 @@
 // Package metrics provides functionality for exporting runtime metrics
 // that can be consumed by goschedviz monitoring tool.
 //
+// Metrics are written to the side channel goschedviz passes in ChannelEnv,
+// or to stderr when the program runs without it.
+//
 // Example usage:
 //
 //	reporter := metrics.NewReporter(time.Second)
 //	reporter.Start()
 //	defer reporter.Stop()
+//
+// Every line carries the goroutines count and a set of runtime/metrics values.
+// Histograms are written as upper bound:count pairs of non-empty buckets:
+//
+//	PROCMETR num_goroutines=12 /gc/cycles/total:gc-cycles=3 /sched/latencies:seconds=1.024e-06:15,2.048e-06:3
+//
+// WithGoroutineProfile adds goroutine counts by state and creation site.
 package metrics
 
 import (
 	"fmt"
-	"os"
+	"math"
 	"runtime"
+	rtmetrics "runtime/metrics"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -21,20 +34,89 @@ const (
 	prefix = "PROCMETR"
 )
 
+// DefaultMetrics lists runtime/metrics reported by default.
+// A name ending with "*" selects every metric with that prefix.
+var DefaultMetrics = []string{
+	"/sched/gomaxprocs:threads",
+	"/sched/goroutines:goroutines",
+	"/gc/cycles/total:gc-cycles",
+	"/gc/heap/live:bytes",
+	"/gc/heap/goal:bytes",
+	"/memory/classes/heap/objects:bytes",
+	"/memory/classes/total:bytes",
+	"/cpu/classes/*",
+	"/sync/mutex/wait/total:seconds",
+	"/sched/latencies:seconds",
+}
+
+// Match reports whether the metric name is selected by any of the patterns.
+// A pattern ending with "*" matches every name with that prefix.
+func Match(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if p == name {
+			return true
+		}
+	}
+	return false
+}
+
 // Reporter handles periodic metrics reporting
 type Reporter struct {
 	interval time.Duration
 	done     chan struct{}
 	stopOnce sync.Once
+	out      *sideChannel
+
+	mu      sync.Mutex
+	samples []rtmetrics.Sample // selected metrics supported by the running Go version
+
+	profileInterval time.Duration // 0 if the goroutine profile is not summarized
+	profiled        time.Time
+	goroutines      goroutineSummary // latest goroutine profile summary
+}
+
+// ReporterOption configures a Reporter.
+type ReporterOption func(*Reporter)
+
+// WithMetrics replaces DefaultMetrics with the given runtime/metrics names.
+// Metrics the running Go version doesn't support are skipped.
+// Without names only the goroutines count is reported.
+func WithMetrics(names ...string) ReporterOption {
+	return func(r *Reporter) {
+		r.samples = selectSamples(names)
+	}
 }
 
 // NewReporter creates a new metrics reporter that will output metrics
-// at the specified interval
-func NewReporter(interval time.Duration) *Reporter {
-	return &Reporter{
+// at the specified interval. It opens the side channel from ChannelEnv right away,
+// so create it before starting child processes.
+func NewReporter(interval time.Duration, opts ...ReporterOption) *Reporter {
+	r := &Reporter{
 		interval: interval,
 		done:     make(chan struct{}),
+		out:      defaultChannel,
+		samples:  selectSamples(DefaultMetrics),
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.out.init()
+	return r
+}
+
+// selectSamples returns samples for supported metrics matching the patterns.
+func selectSamples(patterns []string) []rtmetrics.Sample {
+	var samples []rtmetrics.Sample
+	for _, d := range rtmetrics.All() {
+		if Match(patterns, d.Name) {
+			samples = append(samples, rtmetrics.Sample{Name: d.Name})
+		}
+	}
+	return samples
 }
 
 // Start begins periodic metrics reporting.
@@ -64,11 +146,57 @@ func (r *Reporter) Stop() {
 	})
 }
 
-// report outputs current metrics to stderr
+// report outputs current metrics to the side channel or stderr
 func (r *Reporter) report() {
-	// Get number of goroutines
-	numG := runtime.NumGoroutine()
+	r.out.writeLine(r.line())
+}
+
+// line formats current metrics in a format that can be parsed by the monitor.
+func (r *Reporter) line() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s num_goroutines=%d", prefix, runtime.NumGoroutine())
+
+	rtmetrics.Read(r.samples)
+	for _, sample := range r.samples {
+		switch sample.Value.Kind() {
+		case rtmetrics.KindUint64:
+			b.WriteString(" " + sample.Name + "=" + strconv.FormatUint(sample.Value.Uint64(), 10))
+		case rtmetrics.KindFloat64:
+			b.WriteString(" " + sample.Name + "=" + strconv.FormatFloat(sample.Value.Float64(), 'g', -1, 64))
+		case rtmetrics.KindFloat64Histogram:
+			if buckets := formatHistogram(sample.Value.Float64Histogram()); buckets != "" {
+				b.WriteString(" " + sample.Name + "=" + buckets)
+			}
+		}
+	}
 
-	// Output in a format that can be parsed by the monitor
-	fmt.Fprintf(os.Stderr, "%s num_goroutines=%d\n", prefix, numG)
+	if r.profileInterval > 0 {
+		if now := time.Now(); now.Sub(r.profiled) >= r.profileInterval {
+			r.goroutines, r.profiled = profileGoroutines(), now
+		}
+		b.WriteString(r.goroutines.fields())
+	}
+
+	b.WriteString("\n")
+	return b.String()
+}
+
+// formatHistogram formats non-empty buckets as comma-separated upper bound:count pairs,
+// "inf" stands for the unbounded last bucket. Empty histograms are formatted as "".
+func formatHistogram(h *rtmetrics.Float64Histogram) string {
+	var pairs []string
+	for i, count := range h.Counts {
+		if count == 0 {
+			continue
+		}
+		upper := "inf"
+		if !math.IsInf(h.Buckets[i+1], 1) {
+			upper = strconv.FormatFloat(h.Buckets[i+1], 'g', -1, 64)
+		}
+		pairs = append(pairs, upper+":"+strconv.FormatUint(count, 10))
+	}
+	return strings.Join(pairs, ",")
 }
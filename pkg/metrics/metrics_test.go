@@ -2,7 +2,10 @@ package metrics
 
 import (
 	"bufio"
+	"math"
 	"os"
+	"path/filepath"
+	rtmetrics "runtime/metrics"
 	"strings"
 	"testing"
 	"time"
@@ -63,3 +66,58 @@ func TestReporter_StartStop(t *testing.T) {
 	reporter.Stop()
 	reporter.Stop()
 }
+
+func TestNewReporter_OpensChannel(t *testing.T) {
+	old := defaultChannel
+	defaultChannel = &sideChannel{}
+	defer func() { defaultChannel = old }()
+
+	t.Setenv(ChannelEnv, "unix:"+filepath.Join(t.TempDir(), "missing.sock"))
+	reporter := NewReporter(time.Hour)
+	defer reporter.Stop()
+
+	_, ok := os.LookupEnv(ChannelEnv)
+	assert.False(t, ok, "Child processes started before the first report must not inherit the channel")
+}
+
+func TestReporter_Line(t *testing.T) {
+	line := NewReporter(time.Second).line()
+	require.True(t, strings.HasSuffix(line, "\n"))
+
+	fields := strings.Fields(line)
+	assert.Equal(t, prefix, fields[0])
+	assert.True(t, strings.HasPrefix(fields[1], "num_goroutines="), "Goroutines count goes first")
+
+	values := make(map[string]string)
+	for _, f := range fields[2:] {
+		name, value, ok := strings.Cut(f, "=")
+		require.True(t, ok, f)
+		values[name] = value
+	}
+	assert.Contains(t, values, "/sched/gomaxprocs:threads")
+	assert.Contains(t, values, "/gc/cycles/total:gc-cycles")
+	assert.Contains(t, values, "/cpu/classes/gc/total:cpu-seconds", "Prefix patterns select all matching metrics")
+
+	line = NewReporter(time.Second, WithMetrics()).line()
+	assert.Len(t, strings.Fields(line), 2, "Only goroutines count without metrics")
+
+	line = NewReporter(time.Second, WithMetrics("/no/such:metric")).line()
+	assert.Len(t, strings.Fields(line), 2, "Unknown metrics are skipped")
+}
+
+func TestFormatHistogram(t *testing.T) {
+	h := &rtmetrics.Float64Histogram{
+		Counts:  []uint64{0, 15, 0, 2},
+		Buckets: []float64{0, 1e-6, 2e-6, 4e-6, math.Inf(1)},
+	}
+	assert.Equal(t, "2e-06:15,inf:2", formatHistogram(h))
+	assert.Empty(t, formatHistogram(&rtmetrics.Float64Histogram{Counts: []uint64{0}, Buckets: []float64{0, 1}}))
+}
+
+func TestMatch(t *testing.T) {
+	patterns := []string{"/sched/goroutines:goroutines", "/cpu/classes/*"}
+	assert.True(t, Match(patterns, "/sched/goroutines:goroutines"))
+	assert.True(t, Match(patterns, "/cpu/classes/user:cpu-seconds"))
+	assert.False(t, Match(patterns, "/sched/gomaxprocs:threads"))
+	assert.False(t, Match(nil, "/sched/goroutines:goroutines"))
+}